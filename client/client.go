@@ -0,0 +1,299 @@
+// Package client is a Go client for myredis built on the server's RESP codec.
+//
+// A Client is safe for concurrent use: every call borrows a connection from
+// an internal pool and returns it when the reply has been read.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"ivanSaichkin/myredis/internal/protocol"
+	"net"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrNil is returned when the server replies with a null bulk string or
+	// null array, e.g. GET on a missing key.
+	ErrNil = errors.New("myredis: nil reply")
+
+	ErrClosed          = errors.New("myredis: client is closed")
+	ErrUnexpectedReply = errors.New("myredis: unexpected reply type")
+)
+
+// Error is an error reply sent by the server, such as "ERR unknown command".
+// It is never retried.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+type Options struct {
+	// Network and Addr are passed to net.Dialer.DialContext.
+	// Network defaults to "tcp" and Addr to "localhost:6379".
+	Network string
+	Addr    string
+
+	// TLSConfig enables TLS when set. Provide Certificates for servers that
+	// require client certificates.
+	TLSConfig *tls.Config
+
+	// Username and Password authenticate every new connection with AUTH.
+	// An empty Username authenticates as the default user.
+	Username string
+	Password string
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// PoolSize is the maximum number of open connections and MaxIdleConns
+	// the number kept open between calls. Connections idle for longer than
+	// IdleTimeout are closed instead of reused.
+	PoolSize     int
+	MaxIdleConns int
+	IdleTimeout  time.Duration
+
+	// MaxRetries is the number of times a command is retried when dialling
+	// or writing it fails, waiting between MinRetryBackoff and
+	// MaxRetryBackoff. Commands are retried on a fresh connection. A failure
+	// to read the reply is never retried, as the server may have run the
+	// command, and neither are server error replies.
+	MaxRetries      int
+	MinRetryBackoff time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+func (o *Options) setDefaults() {
+	if o.Network == "" {
+		o.Network = "tcp"
+	}
+	if o.Addr == "" {
+		o.Addr = "localhost:6379"
+	}
+	if o.DialTimeout == 0 {
+		o.DialTimeout = 5 * time.Second
+	}
+	if o.PoolSize <= 0 {
+		o.PoolSize = 10
+	}
+	if o.MaxIdleConns <= 0 {
+		o.MaxIdleConns = o.PoolSize
+	}
+	if o.MinRetryBackoff == 0 {
+		o.MinRetryBackoff = 8 * time.Millisecond
+	}
+	if o.MaxRetryBackoff == 0 {
+		o.MaxRetryBackoff = 512 * time.Millisecond
+	}
+}
+
+type Client struct {
+	opts Options
+	pool *pool
+}
+
+func NewClient(opts Options) *Client {
+	opts.setDefaults()
+
+	c := &Client{opts: opts}
+	c.pool = newPool(&c.opts, c.dial)
+	return c
+}
+
+func (c *Client) Close() error {
+	return c.pool.close()
+}
+
+// Do sends a single command and returns its decoded reply. Replies are
+// decoded as string (simple and bulk strings), int64, []interface{} or nil
+// for null replies; error replies are returned as Error.
+func (c *Client) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	replies, err := c.do(ctx, [][]interface{}{args})
+	if err != nil {
+		return nil, err
+	}
+
+	reply := replies[0]
+	if err, ok := reply.(Error); ok {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// do writes every command in a single round trip and reads one reply per
+// command. Failures to dial or write are retried according to
+// Options.MaxRetries.
+func (c *Client) do(ctx context.Context, cmds [][]interface{}) ([]interface{}, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= c.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.retryBackoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		cn, err := c.pool.get(ctx)
+		if err != nil {
+			if errors.Is(err, ErrClosed) || ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+
+		replies, err := cn.roundTrip(ctx, cmds, c.opts.ReadTimeout, c.opts.WriteTimeout)
+		c.pool.put(cn, err != nil || cn.interrupted)
+		if err == nil {
+			return replies, nil
+		}
+
+		var readErr *readError
+		if errors.As(err, &readErr) {
+			return nil, readErr.err
+		}
+		lastErr = err
+		if ctx.Err() != nil || !isRetryable(err) {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+
+	var netConn net.Conn
+	var err error
+	if c.opts.TLSConfig != nil {
+		tlsDialer := tls.Dialer{NetDialer: &dialer, Config: c.opts.TLSConfig}
+		netConn, err = tlsDialer.DialContext(ctx, c.opts.Network, c.opts.Addr)
+	} else {
+		netConn, err = dialer.DialContext(ctx, c.opts.Network, c.opts.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cn := newConn(netConn)
+	if c.opts.Password != "" {
+		if err := c.auth(ctx, cn); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) auth(ctx context.Context, cn *conn) error {
+	args := []interface{}{"AUTH", c.opts.Password}
+	if c.opts.Username != "" {
+		args = []interface{}{"AUTH", c.opts.Username, c.opts.Password}
+	}
+
+	replies, err := cn.roundTrip(ctx, [][]interface{}{args}, c.opts.ReadTimeout, c.opts.WriteTimeout)
+	if err != nil {
+		return err
+	}
+	if err, ok := replies[0].(Error); ok {
+		return err
+	}
+	return toStatus(replies[0], nil)
+}
+
+func (c *Client) retryBackoff(attempt int) time.Duration {
+	backoff := c.opts.MinRetryBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > c.opts.MaxRetryBackoff {
+		backoff = c.opts.MaxRetryBackoff
+	}
+	return backoff
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func isRetryable(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// encodeArgs converts command arguments into a RESP array of bulk strings.
+func encodeArgs(args []interface{}) protocol.Value {
+	array := make([]protocol.Value, len(args))
+	for i, arg := range args {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case int:
+			s = strconv.Itoa(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			if v {
+				s = "1"
+			} else {
+				s = "0"
+			}
+		case nil:
+			s = ""
+		default:
+			s = fmt.Sprint(v)
+		}
+		array[i] = protocol.Value{Type: protocol.BulkString, Bulk: s}
+	}
+
+	return protocol.Value{Type: protocol.Array, Array: array}
+}
+
+// decodeReply converts a RESP value into the Go representation returned by Do.
+func decodeReply(v protocol.Value) interface{} {
+	switch v.Type {
+	case protocol.SimpleString:
+		return v.Str
+	case protocol.Error:
+		return Error(v.Str)
+	case protocol.Integer:
+		return int64(v.Num)
+	case protocol.BulkString:
+		if v.IsNull {
+			return nil
+		}
+		return v.Bulk
+	case protocol.Array:
+		if v.IsNull {
+			return nil
+		}
+		result := make([]interface{}, len(v.Array))
+		for i, item := range v.Array {
+			result[i] = decodeReply(item)
+		}
+		return result
+	default:
+		return Error(fmt.Sprintf("myredis: unsupported reply type %q", v.Type))
+	}
+}
@@ -0,0 +1,336 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/server"
+	"ivanSaichkin/myredis/internal/storage"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startServer serves a fresh in-memory store on a random loopback port.
+func startServer(t *testing.T) string {
+	t.Helper()
+	return startServerWithConfig(t, config.DefaulteConfig())
+}
+
+func startServerWithConfig(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	srv := server.NewTCPServer(listener.Addr().String(), server.NewHandler(storage.NewMemoryStorage(), cfg))
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+
+	return listener.Addr().String()
+}
+
+func newTestClient(t *testing.T, opts Options) *Client {
+	t.Helper()
+
+	if opts.Addr == "" {
+		opts.Addr = startServer(t)
+	}
+	c := NewClient(opts)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClientAuth(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaulteConfig()
+	cfg.RequirePass = "secret"
+	addr := startServerWithConfig(t, cfg)
+
+	unauthenticated := newTestClient(t, Options{Addr: addr})
+	var replyErr Error
+	if err := unauthenticated.Ping(ctx); !errors.As(err, &replyErr) {
+		t.Errorf("PING without AUTH: expected an error reply, got %v", err)
+	}
+
+	wrong := newTestClient(t, Options{Addr: addr, Password: "wrong"})
+	if err := wrong.Ping(ctx); !errors.As(err, &replyErr) {
+		t.Errorf("PING with a wrong password: expected an error reply, got %v", err)
+	}
+
+	c := newTestClient(t, Options{Addr: addr, Password: "secret", PoolSize: 2})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Ping(ctx); err != nil {
+				t.Errorf("PING with a password: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestClientCommands(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, Options{})
+
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("PING failed: %v", err)
+	}
+
+	binary := "bin\r\n\x00\xff"
+	if err := c.Set(ctx, "key", binary); err != nil {
+		t.Fatalf("SET failed: %v", err)
+	}
+	value, err := c.Get(ctx, "key")
+	if err != nil || value != binary {
+		t.Errorf("GET: expected %q, got %q (%v)", binary, value, err)
+	}
+
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("GET missing: expected ErrNil, got %v", err)
+	}
+
+	if err := c.SetEX(ctx, "temp", "v", time.Minute); err != nil {
+		t.Fatalf("SET EX failed: %v", err)
+	}
+	ttl, err := c.TTL(ctx, "temp")
+	if err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL: unexpected %v (%v)", ttl, err)
+	}
+
+	if n, err := c.HSet(ctx, "hash", "a", "1", "b", "2"); err != nil || n != 2 {
+		t.Errorf("HSET: expected 2, got %d (%v)", n, err)
+	}
+	fields, err := c.HGetAll(ctx, "hash")
+	if err != nil || len(fields) != 2 || fields["a"] != "1" || fields["b"] != "2" {
+		t.Errorf("HGETALL: unexpected %v (%v)", fields, err)
+	}
+
+	if n, err := c.RPush(ctx, "list", "a", "b", "c"); err != nil || n != 3 {
+		t.Errorf("RPUSH: expected 3, got %d (%v)", n, err)
+	}
+	elements, err := c.LRange(ctx, "list", 0, -1)
+	if err != nil || !slices.Equal(elements, []string{"a", "b", "c"}) {
+		t.Errorf("LRANGE: unexpected %v (%v)", elements, err)
+	}
+
+	if n, err := c.SAdd(ctx, "set", "x", "y"); err != nil || n != 2 {
+		t.Errorf("SADD: expected 2, got %d (%v)", n, err)
+	}
+	if ok, err := c.SIsMember(ctx, "set", "x"); err != nil || !ok {
+		t.Errorf("SISMEMBER: expected true, got %v (%v)", ok, err)
+	}
+
+	if _, err := c.HGet(ctx, "key", "field"); err == nil {
+		t.Error("HGET on a string key should fail")
+	} else if _, ok := err.(Error); !ok {
+		t.Errorf("Expected server Error, got %T", err)
+	}
+
+	if n, err := c.Del(ctx, "key", "hash", "missing"); err != nil || n != 2 {
+		t.Errorf("DEL: expected 2, got %d (%v)", n, err)
+	}
+}
+
+func TestClientPipeline(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, Options{})
+
+	pipe := c.Pipeline()
+	pipe.Queue("SET", "counter", "1")
+	getIndex := pipe.Queue("GET", "counter")
+	badIndex := pipe.Queue("LPOP", "counter")
+	pipe.Queue("GET", "missing")
+
+	replies, err := pipe.Exec(ctx)
+	if err != nil {
+		t.Fatalf("Pipeline failed: %v", err)
+	}
+	if len(replies) != 4 {
+		t.Fatalf("Expected 4 replies, got %d", len(replies))
+	}
+	if replies[getIndex] != "1" {
+		t.Errorf("Expected '1', got %v", replies[getIndex])
+	}
+	if _, ok := replies[badIndex].(Error); !ok {
+		t.Errorf("Expected error reply, got %v", replies[badIndex])
+	}
+	if replies[3] != nil {
+		t.Errorf("Expected nil reply, got %v", replies[3])
+	}
+	if pipe.Len() != 0 {
+		t.Errorf("Pipeline should be empty after Exec, has %d commands", pipe.Len())
+	}
+}
+
+func TestClientPoolConcurrency(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, Options{PoolSize: 3})
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for range 10 {
+				if _, err := c.SAdd(ctx, "members", id); err != nil {
+					t.Errorf("SADD failed: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n, err := c.SCard(ctx, "members"); err != nil || n != 20 {
+		t.Errorf("SCARD: expected 20, got %d (%v)", n, err)
+	}
+	if idle := len(c.pool.idle); idle > 3 {
+		t.Errorf("Pool kept %d idle connections, expected at most 3", idle)
+	}
+}
+
+func TestClientContextTimeout(t *testing.T) {
+	// A listener that accepts but never replies.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	c := newTestClient(t, Options{Addr: listener.Addr().String(), MaxRetries: 2})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = c.Ping(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("PING took %v, expected to honour the context deadline", elapsed)
+	}
+}
+
+func TestClientRetriesBrokenConnection(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, Options{MaxRetries: 1})
+
+	if err := c.Set(ctx, "key", "value"); err != nil {
+		t.Fatalf("SET failed: %v", err)
+	}
+
+	// Break the pooled connection behind the client's back.
+	c.pool.mu.Lock()
+	for _, cn := range c.pool.idle {
+		cn.netConn.Close()
+	}
+	c.pool.mu.Unlock()
+
+	value, err := c.Get(ctx, "key")
+	if err != nil || value != "value" {
+		t.Errorf("GET after broken connection: expected 'value', got %q (%v)", value, err)
+	}
+}
+
+func TestClientDoesNotRetryAfterWrite(t *testing.T) {
+	// A listener that reads one command per connection and hangs up
+	// without replying.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	var received atomic.Int64
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if _, err := protocol.NewRESPReader(conn).Read(); err == nil {
+				received.Add(1)
+			}
+			conn.Close()
+		}
+	}()
+
+	c := newTestClient(t, Options{Addr: listener.Addr().String(), MaxRetries: 2})
+	if _, err := c.Do(context.Background(), "INCR", "counter"); err == nil {
+		t.Fatal("Expected INCR to fail")
+	}
+	if n := received.Load(); n != 1 {
+		t.Errorf("Expected INCR to be sent once, got %d", n)
+	}
+}
+
+func TestClientClosed(t *testing.T) {
+	c := NewClient(Options{Addr: startServer(t)})
+	c.Close()
+
+	if err := c.Ping(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestClientPubSub(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := newTestClient(t, Options{})
+
+	ps, err := c.Subscribe(ctx, "news")
+	if err != nil {
+		t.Fatalf("SUBSCRIBE failed: %v", err)
+	}
+	defer ps.Close()
+	if err := ps.PSubscribe(ctx, "sport.*"); err != nil {
+		t.Fatalf("PSUBSCRIBE failed: %v", err)
+	}
+
+	if n, err := c.Publish(ctx, "news", "hello"); err != nil || n != 1 {
+		t.Fatalf("PUBLISH news: expected 1, got %d (%v)", n, err)
+	}
+	if _, err := c.Publish(ctx, "sport.tennis", "ace"); err != nil {
+		t.Fatalf("PUBLISH sport.tennis failed: %v", err)
+	}
+
+	expected := []Message{
+		{Channel: "news", Payload: "hello"},
+		{Pattern: "sport.*", Channel: "sport.tennis", Payload: "ace"},
+	}
+	for _, want := range expected {
+		msg, err := ps.ReceiveMessage(ctx)
+		if err != nil {
+			t.Fatalf("ReceiveMessage failed: %v", err)
+		}
+		if *msg != want {
+			t.Errorf("Expected %+v, got %+v", want, *msg)
+		}
+	}
+
+	// Cancelling the context interrupts a blocked receive.
+	waitCtx, waitCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer waitCancel()
+	if _, err := ps.ReceiveMessage(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
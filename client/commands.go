@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// String commands
+
+func (c *Client) Ping(ctx context.Context) error {
+	return toStatus(c.Do(ctx, "PING"))
+}
+
+func (c *Client) Set(ctx context.Context, key string, value interface{}) error {
+	return toStatus(c.Do(ctx, "SET", key, value))
+}
+
+// SetEX sets key with a time to live, truncated to whole seconds.
+func (c *Client) SetEX(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return toStatus(c.Do(ctx, "SET", key, value, "EX", int64(ttl/time.Second)))
+}
+
+// Get returns ErrNil when key does not exist.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	return toString(c.Do(ctx, "GET", key))
+}
+
+func (c *Client) Del(ctx context.Context, keys ...string) (int64, error) {
+	return toInt64(c.Do(ctx, prepend("DEL", keys)...))
+}
+
+// Unlink deletes keys. The server treats it as an alias of Del.
+func (c *Client) Unlink(ctx context.Context, keys ...string) (int64, error) {
+	return toInt64(c.Do(ctx, prepend("UNLINK", keys)...))
+}
+
+func (c *Client) Exists(ctx context.Context, keys ...string) (int64, error) {
+	return toInt64(c.Do(ctx, prepend("EXISTS", keys)...))
+}
+
+func (c *Client) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return toBool(c.Do(ctx, "EXPIRE", key, int64(ttl/time.Second)))
+}
+
+// TTL returns the remaining time to live of key, -1 if key has no expiry and
+// -2 if it does not exist, mirroring the server reply.
+func (c *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
+	seconds, err := toInt64(c.Do(ctx, "TTL", key))
+	if err != nil {
+		return 0, err
+	}
+	if seconds < 0 {
+		return time.Duration(seconds), nil
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func (c *Client) Type(ctx context.Context, key string) (string, error) {
+	return toString(c.Do(ctx, "TYPE", key))
+}
+
+// Hash commands
+
+// HSet sets field/value pairs on the hash at key and returns the number of
+// fields that were created.
+func (c *Client) HSet(ctx context.Context, key string, fieldValues ...interface{}) (int64, error) {
+	args := append([]interface{}{"HSET", key}, fieldValues...)
+	return toInt64(c.Do(ctx, args...))
+}
+
+// HGet returns ErrNil when key or field does not exist.
+func (c *Client) HGet(ctx context.Context, key, field string) (string, error) {
+	return toString(c.Do(ctx, "HGET", key, field))
+}
+
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return toInt64(c.Do(ctx, prepend("HDEL", append([]string{key}, fields...))...))
+}
+
+func (c *Client) HExists(ctx context.Context, key, field string) (bool, error) {
+	return toBool(c.Do(ctx, "HEXISTS", key, field))
+}
+
+func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return toStringMap(c.Do(ctx, "HGETALL", key))
+}
+
+func (c *Client) HKeys(ctx context.Context, key string) ([]string, error) {
+	return toStrings(c.Do(ctx, "HKEYS", key))
+}
+
+func (c *Client) HLen(ctx context.Context, key string) (int64, error) {
+	return toInt64(c.Do(ctx, "HLEN", key))
+}
+
+// List commands
+
+func (c *Client) LPush(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	args := append([]interface{}{"LPUSH", key}, values...)
+	return toInt64(c.Do(ctx, args...))
+}
+
+func (c *Client) RPush(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	args := append([]interface{}{"RPUSH", key}, values...)
+	return toInt64(c.Do(ctx, args...))
+}
+
+// LPop returns ErrNil when the list is empty or does not exist.
+func (c *Client) LPop(ctx context.Context, key string) (string, error) {
+	return toString(c.Do(ctx, "LPOP", key))
+}
+
+// RPop returns ErrNil when the list is empty or does not exist.
+func (c *Client) RPop(ctx context.Context, key string) (string, error) {
+	return toString(c.Do(ctx, "RPOP", key))
+}
+
+func (c *Client) LLen(ctx context.Context, key string) (int64, error) {
+	return toInt64(c.Do(ctx, "LLEN", key))
+}
+
+func (c *Client) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return toStrings(c.Do(ctx, "LRANGE", key, start, stop))
+}
+
+// Set commands
+
+func (c *Client) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	args := append([]interface{}{"SADD", key}, members...)
+	return toInt64(c.Do(ctx, args...))
+}
+
+func (c *Client) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	args := append([]interface{}{"SREM", key}, members...)
+	return toInt64(c.Do(ctx, args...))
+}
+
+func (c *Client) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	return toBool(c.Do(ctx, "SISMEMBER", key, member))
+}
+
+func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	return toStrings(c.Do(ctx, "SMEMBERS", key))
+}
+
+func (c *Client) SCard(ctx context.Context, key string) (int64, error) {
+	return toInt64(c.Do(ctx, "SCARD", key))
+}
+
+func (c *Client) SInter(ctx context.Context, keys ...string) ([]string, error) {
+	return toStrings(c.Do(ctx, prepend("SINTER", keys)...))
+}
+
+// Utility commands
+
+func (c *Client) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return toStrings(c.Do(ctx, "KEYS", pattern))
+}
+
+func (c *Client) FlushDB(ctx context.Context) error {
+	return toStatus(c.Do(ctx, "FLUSHDB"))
+}
+
+// FlushDBAsync removes all keys. The server treats it like FlushDB.
+func (c *Client) FlushDBAsync(ctx context.Context) error {
+	return toStatus(c.Do(ctx, "FLUSHDB", "ASYNC"))
+}
+
+func (c *Client) Save(ctx context.Context) error {
+	return toStatus(c.Do(ctx, "SAVE"))
+}
+
+func (c *Client) BGSave(ctx context.Context) error {
+	return toStatus(c.Do(ctx, "BGSAVE"))
+}
+
+func (c *Client) LastSave(ctx context.Context) (time.Time, error) {
+	unix, err := toInt64(c.Do(ctx, "LASTSAVE"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(unix, 0), nil
+}
+
+// Info returns the INFO report for sections, or the default sections if
+// none are given.
+func (c *Client) Info(ctx context.Context, sections ...string) (string, error) {
+	return toString(c.Do(ctx, prepend("INFO", sections)...))
+}
+
+// Shutdown asks the server to shut down gracefully. modifiers may be SAVE,
+// NOSAVE or ABORT.
+func (c *Client) Shutdown(ctx context.Context, modifiers ...string) error {
+	return toStatus(c.Do(ctx, prepend("SHUTDOWN", modifiers)...))
+}
+
+// Digest returns a digest of the server's keyspace. Servers holding the
+// same data return the same digest.
+func (c *Client) Digest(ctx context.Context) (string, error) {
+	return toString(c.Do(ctx, "DIGEST"))
+}
+
+// DebugPopulate creates the keys prefix:0 to prefix:count-1 that do not
+// exist yet, holding values padded or truncated to size bytes unless size
+// is negative. The server must allow DEBUG with enable-debug-command.
+func (c *Client) DebugPopulate(ctx context.Context, count int, prefix string, size int) error {
+	args := []interface{}{"DEBUG", "POPULATE", count, prefix}
+	if size >= 0 {
+		args = append(args, size)
+	}
+	return toStatus(c.Do(ctx, args...))
+}
+
+// DebugReload makes the server save a snapshot and load it back.
+func (c *Client) DebugReload(ctx context.Context) error {
+	return toStatus(c.Do(ctx, "DEBUG", "RELOAD"))
+}
+
+// DebugSleep blocks the server for d.
+func (c *Client) DebugSleep(ctx context.Context, d time.Duration) error {
+	return toStatus(c.Do(ctx, "DEBUG", "SLEEP", d.Seconds()))
+}
+
+// Connection commands
+
+// Auth authenticates the pooled connection that happens to serve the call;
+// set Options.Username and Options.Password to authenticate every
+// connection.
+func (c *Client) Auth(ctx context.Context, username, password string) error {
+	if username == "" {
+		return toStatus(c.Do(ctx, "AUTH", password))
+	}
+	return toStatus(c.Do(ctx, "AUTH", username, password))
+}
+
+func (c *Client) ClientID(ctx context.Context) (int64, error) {
+	return toInt64(c.Do(ctx, "CLIENT", "ID"))
+}
+
+// ClientSetName names the pooled connection that happens to serve the call;
+// it is mostly useful with a PoolSize of one.
+func (c *Client) ClientSetName(ctx context.Context, name string) error {
+	return toStatus(c.Do(ctx, "CLIENT", "SETNAME", name))
+}
+
+func (c *Client) ClientList(ctx context.Context) (string, error) {
+	return toString(c.Do(ctx, "CLIENT", "LIST"))
+}
+
+// ClientKill closes the connections matching filters, given as name/value
+// pairs such as "ID", "12" or "ADDR", "10.0.0.1:51234".
+func (c *Client) ClientKill(ctx context.Context, filters ...string) (int64, error) {
+	return toInt64(c.Do(ctx, prepend("CLIENT", append([]string{"KILL"}, filters...))...))
+}
+
+// Pub/Sub commands
+
+// Publish posts message to channel and returns the number of subscribers
+// that received it. Use Subscribe or PSubscribe to receive messages.
+func (c *Client) Publish(ctx context.Context, channel string, message interface{}) (int64, error) {
+	return toInt64(c.Do(ctx, "PUBLISH", channel, message))
+}
+
+func prepend(name string, args []string) []interface{} {
+	result := make([]interface{}, 0, len(args)+1)
+	result = append(result, name)
+	for _, arg := range args {
+		result = append(result, arg)
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"ivanSaichkin/myredis/internal/protocol"
+	"net"
+	"time"
+)
+
+type conn struct {
+	netConn net.Conn
+	reader  *protocol.RESPReader
+	writer  *protocol.RESPWriter
+	usedAt  time.Time
+
+	// interrupted is set when a context cancellation expired the deadline
+	// concurrently with a completed round trip; such a connection is not
+	// returned to the pool.
+	interrupted bool
+}
+
+func newConn(netConn net.Conn) *conn {
+	return &conn{
+		netConn: netConn,
+		reader:  protocol.NewRESPReader(netConn),
+		writer:  protocol.NewRESPWriter(netConn),
+		usedAt:  time.Now(),
+	}
+}
+
+// readError is a failure to read replies after the commands were written.
+// The server may have run the commands, so they must not be retried.
+type readError struct {
+	err error
+}
+
+func (e *readError) Error() string {
+	return e.err.Error()
+}
+
+func (e *readError) Unwrap() error {
+	return e.err
+}
+
+// roundTrip pipelines cmds over the connection and reads one reply per
+// command. Cancelling ctx interrupts blocked reads and writes by expiring
+// the connection deadline; the connection must then be discarded. Errors
+// after the commands were written are returned as *readError.
+func (cn *conn) roundTrip(ctx context.Context, cmds [][]interface{}, readTimeout, writeTimeout time.Duration) ([]interface{}, error) {
+	stop := context.AfterFunc(ctx, func() {
+		cn.netConn.SetDeadline(time.Unix(1, 0))
+	})
+	defer func() {
+		if !stop() {
+			cn.interrupted = true
+		}
+	}()
+
+	if err := cn.netConn.SetWriteDeadline(deadline(ctx, writeTimeout)); err != nil {
+		return nil, err
+	}
+	for _, args := range cmds {
+		if err := cn.writer.Write(encodeArgs(args)); err != nil {
+			return nil, cn.contextError(ctx, err)
+		}
+	}
+	if err := cn.writer.Flush(); err != nil {
+		return nil, cn.contextError(ctx, err)
+	}
+
+	if err := cn.netConn.SetReadDeadline(deadline(ctx, readTimeout)); err != nil {
+		return nil, &readError{err}
+	}
+	replies := make([]interface{}, len(cmds))
+	for i := range cmds {
+		value, err := cn.reader.Read()
+		if err != nil {
+			return nil, &readError{cn.contextError(ctx, err)}
+		}
+		replies[i] = decodeReply(value)
+	}
+
+	cn.usedAt = time.Now()
+	return replies, nil
+}
+
+// contextError reports the context error instead of the i/o timeout caused
+// by the deadline set on cancellation or taken from the context, which may
+// expire just before the context reports it.
+func (cn *conn) contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if d, ok := ctx.Deadline(); ok && !time.Now().Before(d) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+func (cn *conn) close() error {
+	return cn.netConn.Close()
+}
+
+// deadline returns the earlier of the context deadline and now+timeout, or
+// the zero time when neither applies.
+func deadline(ctx context.Context, timeout time.Duration) time.Time {
+	var d time.Time
+	if timeout > 0 {
+		d = time.Now().Add(timeout)
+	}
+	if ctxDeadline, ok := ctx.Deadline(); ok && (d.IsZero() || ctxDeadline.Before(d)) {
+		d = ctxDeadline
+	}
+	return d
+}
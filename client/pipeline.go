@@ -0,0 +1,39 @@
+package client
+
+import "context"
+
+// Pipeline buffers commands and sends them to the server in a single round
+// trip on one connection. It is not safe for concurrent use.
+type Pipeline struct {
+	client *Client
+	cmds   [][]interface{}
+}
+
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{client: c}
+}
+
+// Queue adds a command to the pipeline and returns its index in the slice
+// returned by Exec.
+func (p *Pipeline) Queue(args ...interface{}) int {
+	p.cmds = append(p.cmds, args)
+	return len(p.cmds) - 1
+}
+
+func (p *Pipeline) Len() int {
+	return len(p.cmds)
+}
+
+// Exec sends all queued commands and returns one reply per command, decoded
+// as in Client.Do. Error replies are returned in place as Error values so a
+// failing command does not hide the results of the others. The pipeline is
+// empty afterwards and can be reused.
+func (p *Pipeline) Exec(ctx context.Context) ([]interface{}, error) {
+	if len(p.cmds) == 0 {
+		return nil, nil
+	}
+
+	cmds := p.cmds
+	p.cmds = nil
+	return p.client.do(ctx, cmds)
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pool hands out at most PoolSize connections at a time and keeps up to
+// MaxIdleConns of them open between calls.
+type pool struct {
+	opts *Options
+	dial func(ctx context.Context) (*conn, error)
+
+	// slots holds one token per connection that may be checked out.
+	slots chan struct{}
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+func newPool(opts *Options, dial func(ctx context.Context) (*conn, error)) *pool {
+	return &pool{
+		opts:  opts,
+		dial:  dial,
+		slots: make(chan struct{}, opts.PoolSize),
+	}
+}
+
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.slots
+		return nil, ErrClosed
+	}
+	for len(p.idle) > 0 {
+		cn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+
+		if p.opts.IdleTimeout > 0 && time.Since(cn.usedAt) > p.opts.IdleTimeout {
+			cn.close()
+			continue
+		}
+
+		p.mu.Unlock()
+		return cn, nil
+	}
+	p.mu.Unlock()
+
+	cn, err := p.dial(ctx)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return cn, nil
+}
+
+// put returns cn to the pool. Broken connections, and connections beyond
+// MaxIdleConns, are closed.
+func (p *pool) put(cn *conn, broken bool) {
+	defer func() { <-p.slots }()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if broken || p.closed || len(p.idle) >= p.opts.MaxIdleConns {
+		cn.close()
+		return
+	}
+	p.idle = append(p.idle, cn)
+}
+
+func (p *pool) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+	p.closed = true
+
+	var firstErr error
+	for _, cn := range p.idle {
+		if err := cn.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	p.idle = nil
+	return firstErr
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Message is a message received on a subscribed channel. Pattern is set
+// when the message matched a pattern subscription.
+type Message struct {
+	Pattern string
+	Channel string
+	Payload string
+}
+
+// PubSub is a connection dedicated to receiving Pub/Sub messages, outside
+// the client's pool. It is not safe for concurrent use.
+type PubSub struct {
+	client  *Client
+	cn      *conn
+	pending []*Message
+}
+
+// Subscribe opens a dedicated connection subscribed to channels.
+func (c *Client) Subscribe(ctx context.Context, channels ...string) (*PubSub, error) {
+	return c.newPubSub(ctx, "SUBSCRIBE", channels)
+}
+
+// PSubscribe opens a dedicated connection subscribed to patterns.
+func (c *Client) PSubscribe(ctx context.Context, patterns ...string) (*PubSub, error) {
+	return c.newPubSub(ctx, "PSUBSCRIBE", patterns)
+}
+
+func (c *Client) newPubSub(ctx context.Context, kind string, names []string) (*PubSub, error) {
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := &PubSub{client: c, cn: cn}
+	if err := ps.subscribe(ctx, kind, names); err != nil {
+		cn.close()
+		return nil, err
+	}
+	return ps, nil
+}
+
+func (ps *PubSub) Subscribe(ctx context.Context, channels ...string) error {
+	return ps.subscribe(ctx, "SUBSCRIBE", channels)
+}
+
+func (ps *PubSub) PSubscribe(ctx context.Context, patterns ...string) error {
+	return ps.subscribe(ctx, "PSUBSCRIBE", patterns)
+}
+
+// Unsubscribe unsubscribes from channels, or from all channels if none are
+// given. Confirmations are skipped by ReceiveMessage.
+func (ps *PubSub) Unsubscribe(ctx context.Context, channels ...string) error {
+	return ps.send(ctx, prepend("UNSUBSCRIBE", channels))
+}
+
+func (ps *PubSub) PUnsubscribe(ctx context.Context, patterns ...string) error {
+	return ps.send(ctx, prepend("PUNSUBSCRIBE", patterns))
+}
+
+// subscribe waits for one confirmation per name. Messages arriving in the
+// meantime are kept for ReceiveMessage.
+func (ps *PubSub) subscribe(ctx context.Context, kind string, names []string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("myredis: %s requires at least one name", kind)
+	}
+	if err := ps.send(ctx, prepend(kind, names)); err != nil {
+		return err
+	}
+
+	for confirmed := 0; confirmed < len(names); {
+		msg, confirmation, err := ps.receive(ctx)
+		if err != nil {
+			return err
+		}
+		if msg != nil {
+			ps.pending = append(ps.pending, msg)
+		}
+		if confirmation {
+			confirmed++
+		}
+	}
+	return nil
+}
+
+// ReceiveMessage blocks until a message arrives or ctx is done.
+func (ps *PubSub) ReceiveMessage(ctx context.Context) (*Message, error) {
+	if len(ps.pending) > 0 {
+		msg := ps.pending[0]
+		ps.pending = ps.pending[1:]
+		return msg, nil
+	}
+
+	for {
+		msg, _, err := ps.receive(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if msg != nil {
+			return msg, nil
+		}
+	}
+}
+
+func (ps *PubSub) Close() error {
+	return ps.cn.close()
+}
+
+func (ps *PubSub) send(ctx context.Context, args []interface{}) error {
+	cn := ps.cn
+	if err := cn.netConn.SetWriteDeadline(deadline(ctx, ps.client.opts.WriteTimeout)); err != nil {
+		return err
+	}
+	if err := cn.writer.Write(encodeArgs(args)); err != nil {
+		return err
+	}
+	return cn.writer.Flush()
+}
+
+// receive reads one reply and returns it as a message, or reports whether
+// it confirmed a subscription. Only ctx bounds the wait: cancelling it
+// interrupts the read, after which the PubSub should be closed.
+func (ps *PubSub) receive(ctx context.Context) (*Message, bool, error) {
+	cn := ps.cn
+	if err := cn.netConn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, false, err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		cn.netConn.SetReadDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	value, err := cn.reader.Read()
+	if err != nil {
+		return nil, false, cn.contextError(ctx, err)
+	}
+
+	reply := decodeReply(value)
+	if err, ok := reply.(Error); ok {
+		return nil, false, err
+	}
+
+	items, _ := reply.([]interface{})
+	if len(items) == 0 {
+		return nil, false, ErrUnexpectedReply
+	}
+	kind, _ := items[0].(string)
+
+	switch {
+	case kind == "message" && len(items) == 3:
+		channel, _ := items[1].(string)
+		payload, _ := items[2].(string)
+		return &Message{Channel: channel, Payload: payload}, false, nil
+	case kind == "pmessage" && len(items) == 4:
+		pattern, _ := items[1].(string)
+		channel, _ := items[2].(string)
+		payload, _ := items[3].(string)
+		return &Message{Pattern: pattern, Channel: channel, Payload: payload}, false, nil
+	case kind == "subscribe" || kind == "psubscribe":
+		return nil, true, nil
+	case kind == "unsubscribe" || kind == "punsubscribe" || kind == "pong":
+		return nil, false, nil
+	default:
+		return nil, false, ErrUnexpectedReply
+	}
+}
@@ -0,0 +1,82 @@
+package client
+
+import "fmt"
+
+// Reply conversion helpers used by the typed command methods. They pass
+// through err and translate nil replies into ErrNil.
+
+func toString(reply interface{}, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	switch v := reply.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", ErrNil
+	default:
+		return "", fmt.Errorf("%w: %T, expected string", ErrUnexpectedReply, reply)
+	}
+}
+
+func toInt64(reply interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	switch v := reply.(type) {
+	case int64:
+		return v, nil
+	case nil:
+		return 0, ErrNil
+	default:
+		return 0, fmt.Errorf("%w: %T, expected integer", ErrUnexpectedReply, reply)
+	}
+}
+
+func toBool(reply interface{}, err error) (bool, error) {
+	n, err := toInt64(reply, err)
+	return n == 1, err
+}
+
+func toStatus(reply interface{}, err error) error {
+	_, err = toString(reply, err)
+	return err
+}
+
+func toStrings(reply interface{}, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch v := reply.(type) {
+	case []interface{}:
+		result := make([]string, len(v))
+		for i, item := range v {
+			s, err := toString(item, nil)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = s
+		}
+		return result, nil
+	case nil:
+		return nil, ErrNil
+	default:
+		return nil, fmt.Errorf("%w: %T, expected array", ErrUnexpectedReply, reply)
+	}
+}
+
+func toStringMap(reply interface{}, err error) (map[string]string, error) {
+	values, err := toStrings(reply, err)
+	if err != nil {
+		return nil, err
+	}
+	if len(values)%2 != 0 {
+		return nil, fmt.Errorf("%w: odd number of elements in map reply", ErrUnexpectedReply)
+	}
+
+	result := make(map[string]string, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		result[values[i]] = values[i+1]
+	}
+	return result, nil
+}
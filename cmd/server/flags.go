@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ivanSaichkin/myredis/internal/config"
+)
+
+type options struct {
+	configFile string
+	testConfig bool
+	overrides  []config.Override
+}
+
+// parseFlags parses "[config-file] [--name value ...]". Every config
+// parameter is also a flag, and --listener may be repeated.
+func parseFlags(args []string, output io.Writer) (*options, error) {
+	opts := &options{}
+
+	fs := flag.NewFlagSet("myredis", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&opts.configFile, "config", "", "read parameters from this redis.conf style `file`")
+	fs.BoolVar(&opts.testConfig, "test-config", false, "validate the configuration and exit")
+	fs.Func("listener", "add a listener: \"name tcp|unix address [tls] [permissions]\"", func(value string) error {
+		opts.overrides = append(opts.overrides, config.Override{Name: "listener", Value: value})
+		return nil
+	})
+	for _, p := range config.Params() {
+		name := p.Name
+		fs.Func(name, fmt.Sprintf("set %s (environment %s)", name, config.EnvName(name)), func(value string) error {
+			opts.overrides = append(opts.overrides, config.Override{Name: name, Value: value})
+			return nil
+		})
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: myredis [config-file] [--parameter value ...]\n\n")
+		fmt.Fprintf(fs.Output(), "Parameters are read, in increasing order of precedence, from the defaults,\n")
+		fmt.Fprintf(fs.Output(), "the config file, %s* environment variables and the flags.\n\n", config.EnvPrefix)
+		fs.PrintDefaults()
+	}
+
+	// The config file may be given before or after the flags.
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return opts, nil
+		}
+		if opts.configFile != "" {
+			return nil, errors.New("more than one config file given")
+		}
+		opts.configFile = fs.Arg(0)
+		args = fs.Args()[1:]
+	}
+}
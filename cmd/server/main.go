@@ -1,50 +1,204 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"ivanSaichkin/myredis/internal/command"
 	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/logging"
 	"ivanSaichkin/myredis/internal/server"
 	"ivanSaichkin/myredis/internal/storage"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 )
 
 func main() {
-	cfg := config.DefaulteConfig()
+	opts, err := parseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(opts.configFile, os.LookupEnv, opts.overrides)
+	if err == nil {
+		err = server.CheckConfig(cfg)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+	if opts.testConfig {
+		fmt.Println("Configuration OK")
+		return
+	}
+
+	var output io.Writer = os.Stderr
+	var logFile *logging.File
+	if cfg.LogFile != "" {
+		if logFile, err = logging.OpenFile(cfg.LogFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer logFile.Close()
+		output = logFile
+	}
+	logger, err := logging.New(output, cfg.LogFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	logging.SetLevel(level)
+	// Messages of the standard log package, e.g. from net/http, end up
+	// in the same log.
+	slog.SetDefault(logger)
+
+	if cfg.Persistence.Enabled {
+		if err := os.MkdirAll(cfg.Persistence.DataDir, 0755); err != nil {
+			fatal(logger, "Failed to create data directory", err)
+		}
+	}
 	store := storage.NewMemoryStorageWithPersistence(&cfg.Persistence)
+	store.SetLogger(logger.With("component", "storage"))
 
 	if err := store.StartPersistence(); err != nil {
-		log.Printf("Warning: failed to start persistence: %v", err)
+		logger.Warn("Failed to start persistence", "error", err)
 	}
 
-	store.StartExpirationChecker(30 * time.Second)
+	store.StartExpirationChecker(cfg.ExpirationCheckInterval)
 
-	handler := server.NewHandler(store)
-	server := server.NewTCPServer(cfg.Address, handler)
+	serverLogger := logger.With("component", "server")
+	handler := server.NewHandler(store, cfg)
+	handler.SetLogger(serverLogger)
+	if cfg.ACLFile != "" {
+		if err := handler.ACL().Load(); err != nil {
+			fatal(logger, "Failed to load ACL file", err)
+		}
+	}
+	tcpServer := server.NewTCPServer(cfg.Address, handler)
+	tcpServer.SetLogger(serverLogger)
+
+	access, err := server.NewAccessControl(cfg, handler.ACL())
+	if err != nil {
+		fatal(logger, "Access control configuration error", err)
+	}
+	tcpServer.SetAccessControl(access)
+	if access.ProtectedModeActive() {
+		logger.Warn("Protected mode is enabled and no password is set, only loopback clients are accepted")
+	}
+
+	listeners := cfg.AllListeners()
+
+	var tlsManager *server.TLSManager
+	var tlsConfig *tls.Config
+	for _, lc := range listeners {
+		if !lc.TLS {
+			continue
+		}
+		var err error
+		if tlsManager, err = server.NewTLSManager(cfg.TLS); err != nil {
+			fatal(logger, "TLS configuration error", err)
+		}
+		tlsConfig = tlsManager.Config()
+		break
+	}
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+
+	logger.Info("Starting MyRedis server", "persistence", cfg.Persistence.Enabled, "config_file", cfg.ConfigFile)
+
 	go func() {
-		log.Println("Starting MyRedis server...")
-		log.Printf("Server listening on %s", cfg.Address)
-		log.Printf("Persistence enabled: %v", cfg.Persistence.Enabled)
-		if err := server.Start(); err != nil {
-			log.Fatalf("Server error: %v", err)
+		if err := tcpServer.ListenAndServe(listeners, tlsConfig); err != nil && err != server.ErrServerClosed {
+			fatal(logger, "Server error", err)
 		}
 	}()
 
-	sig := <-sigChan
-	log.Printf("Received signal %v, shutting down...", sig)
+	var metricsServer *http.Server
+	if cfg.MetricsAddress != "" {
+		metricsServer = server.NewMetricsServer(cfg.MetricsAddress, handler)
+		go func() {
+			logger.Info("Serving metrics", "address", cfg.MetricsAddress, "path", "/metrics")
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Warn("Metrics server error", "error", err)
+			}
+		}()
+	}
+
+	go func() {
+		for range reloadChan {
+			// Reopening lets log rotation move the file away first.
+			if logFile != nil {
+				if err := logFile.Reopen(); err != nil {
+					logger.Warn("Failed to reopen log file", "path", cfg.LogFile, "error", err)
+				} else {
+					logger.Info("Log file reopened", "path", cfg.LogFile)
+				}
+			}
+			if tlsManager == nil {
+				continue
+			}
+			if err := tlsManager.Reload(); err != nil {
+				logger.Warn("Failed to reload TLS certificates", "error", err)
+			} else {
+				logger.Info("TLS certificates reloaded")
+			}
+		}
+	}()
+
+	go func() {
+		for sig := range sigChan {
+			logger.Info("Received signal, shutting down", "signal", sig.String())
+			if err := tcpServer.RequestShutdown(command.ShutdownDefault); err != nil {
+				logger.Warn("Shutdown failed", "error", err)
+			}
+		}
+	}()
+
+	<-tcpServer.ShutdownRequested()
+
+	// The timeout may have been changed with CONFIG SET.
+	shutdownTimeout := handler.Config().ShutdownTimeout
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := tcpServer.Shutdown(ctx); err != nil {
+		logger.Warn("Closed remaining connections", "timeout", shutdownTimeout, "error", err)
+	}
+	if metricsServer != nil {
+		metricsServer.Shutdown(ctx)
+	}
+
 	if cfg.Persistence.Enabled {
-		log.Println("Stopping persistence...")
-		if err := store.StopPersistence(); err != nil {
-			log.Printf("Error stopping persistence: %v", err)
+		logger.Info("Stopping persistence")
+		// Commands answered while draining connections are included in
+		// the final snapshot unless NOSAVE was requested.
+		stop := store.StopPersistence
+		if tcpServer.ShutdownMode() == command.ShutdownNoSave {
+			stop = store.StopPersistenceNoSave
+		}
+		if err := stop(); err != nil {
+			logger.Warn("Error stopping persistence", "error", err)
 		} else {
-			log.Println("Persistence stopped successfully")
+			logger.Info("Persistence stopped successfully")
 		}
 	}
-	log.Println("MyRedis server stopped")
+	logger.Info("MyRedis server stopped")
+}
+
+// fatal logs err and exits.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
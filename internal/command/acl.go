@@ -0,0 +1,523 @@
+package command
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const DefaultUser = "default"
+
+var (
+	ErrNoACLFile        = errors.New("this instance is not configured to use an ACL file")
+	ErrDefaultUserFixed = errors.New("the 'default' user cannot be removed")
+)
+
+// User is an ACL user. Users are never modified in place: SETUSER applies
+// the rules to a copy and swaps it in, so permission checks can use a user
+// without holding the ACL lock.
+type User struct {
+	Name string
+
+	enabled   bool
+	nopass    bool
+	passwords map[string]bool // SHA-256 hex digests
+
+	// commandRules are the +/- command and category rules in the order
+	// they were applied; allowed is derived from them.
+	commandRules []string
+	allowed      map[string]bool
+
+	keyPatterns     []string
+	channelPatterns []string
+}
+
+func newUser(name string) *User {
+	return &User{
+		Name:      name,
+		passwords: make(map[string]bool),
+		allowed:   make(map[string]bool),
+	}
+}
+
+func newDefaultUser() *User {
+	u := newUser(DefaultUser)
+	u.applyRules([]string{"on", "nopass", "allkeys", "allchannels", "allcommands"})
+	return u
+}
+
+func (u *User) clone() *User {
+	c := &User{
+		Name:            u.Name,
+		enabled:         u.enabled,
+		nopass:          u.nopass,
+		passwords:       make(map[string]bool, len(u.passwords)),
+		commandRules:    append([]string(nil), u.commandRules...),
+		keyPatterns:     append([]string(nil), u.keyPatterns...),
+		channelPatterns: append([]string(nil), u.channelPatterns...),
+	}
+	for hash := range u.passwords {
+		c.passwords[hash] = true
+	}
+	c.computeAllowed()
+	return c
+}
+
+func (u *User) applyRules(rules []string) error {
+	for _, rule := range rules {
+		if err := u.applyRule(rule); err != nil {
+			return fmt.Errorf("error in ACL SETUSER modifier '%s': %v", rule, err)
+		}
+	}
+	u.computeAllowed()
+	return nil
+}
+
+func (u *User) applyRule(rule string) error {
+	switch strings.ToLower(rule) {
+	case "on":
+		u.enabled = true
+		return nil
+	case "off":
+		u.enabled = false
+		return nil
+	case "nopass":
+		u.nopass = true
+		u.passwords = make(map[string]bool)
+		return nil
+	case "resetpass":
+		u.nopass = false
+		u.passwords = make(map[string]bool)
+		return nil
+	case "allkeys":
+		u.keyPatterns = []string{"*"}
+		return nil
+	case "resetkeys":
+		u.keyPatterns = nil
+		return nil
+	case "allchannels":
+		u.channelPatterns = []string{"*"}
+		return nil
+	case "resetchannels":
+		u.channelPatterns = nil
+		return nil
+	case "allcommands":
+		return u.addCommandRule("+@all")
+	case "nocommands":
+		return u.addCommandRule("-@all")
+	case "reset":
+		u.enabled = false
+		u.nopass = false
+		u.passwords = make(map[string]bool)
+		u.keyPatterns = nil
+		u.channelPatterns = nil
+		u.commandRules = nil
+		return nil
+	}
+
+	if rule == "" {
+		return errors.New("syntax error")
+	}
+
+	switch rule[0] {
+	case '>':
+		u.passwords[hashPassword(rule[1:])] = true
+		u.nopass = false
+	case '<':
+		delete(u.passwords, hashPassword(rule[1:]))
+	case '#':
+		hash := strings.ToLower(rule[1:])
+		if !isPasswordHash(hash) {
+			return errors.New("the password hash must be exactly 64 characters and contain only lowercase hexadecimal characters")
+		}
+		u.passwords[hash] = true
+		u.nopass = false
+	case '!':
+		delete(u.passwords, strings.ToLower(rule[1:]))
+	case '~', '&':
+		// Rules are saved to the ACL file separated by spaces.
+		if strings.ContainsFunc(rule[1:], unicode.IsSpace) {
+			return errors.New("spaces are not allowed in patterns")
+		}
+		if rule[0] == '~' {
+			u.keyPatterns = append(u.keyPatterns, rule[1:])
+		} else {
+			u.channelPatterns = append(u.channelPatterns, rule[1:])
+		}
+	case '+', '-':
+		return u.addCommandRule(rule)
+	default:
+		return errors.New("syntax error")
+	}
+	return nil
+}
+
+func (u *User) addCommandRule(rule string) error {
+	rule = strings.ToLower(rule)
+	name := rule[1:]
+
+	if category, ok := strings.CutPrefix(name, "@"); ok {
+		if category != "all" && !isACLCategory(category) {
+			return errors.New("unknown command or category name in ACL")
+		}
+	} else {
+		base, _, _ := strings.Cut(name, "|")
+		if _, ok := commandTable[base]; !ok {
+			return errors.New("unknown command or category name in ACL")
+		}
+	}
+
+	// +@all and -@all override everything applied before them.
+	if name == "@all" {
+		u.commandRules = nil
+	}
+	u.commandRules = append(u.commandRules, rule)
+	return nil
+}
+
+func (u *User) computeAllowed() {
+	allowed := make(map[string]bool)
+	for _, rule := range u.commandRules {
+		allow := rule[0] == '+'
+		name := rule[1:]
+
+		category, ok := strings.CutPrefix(name, "@")
+		if !ok {
+			setAllowed(allowed, name, allow)
+			continue
+		}
+		for command, spec := range commandTable {
+			if spec.inCategory(category) {
+				setAllowed(allowed, command, allow)
+			}
+		}
+	}
+	u.allowed = allowed
+}
+
+// setAllowed records the rule for name; a rule for a whole command also
+// replaces earlier rules for its subcommands.
+func setAllowed(allowed map[string]bool, name string, allow bool) {
+	if !strings.Contains(name, "|") {
+		for command := range allowed {
+			if strings.HasPrefix(command, name+"|") {
+				delete(allowed, command)
+			}
+		}
+	}
+	allowed[name] = allow
+}
+
+// CanRun reports whether the user may run the command named fullName,
+// e.g. "get" or "client|kill".
+func (u *User) CanRun(fullName string) bool {
+	if allow, ok := u.allowed[fullName]; ok {
+		return allow
+	}
+	base, _, _ := strings.Cut(fullName, "|")
+	return u.allowed[base]
+}
+
+func (u *User) CanAccessKey(key string) bool {
+	for _, pattern := range u.keyPatterns {
+		if globMatch(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *User) CanAccessChannel(channel string) bool {
+	for _, pattern := range u.channelPatterns {
+		if globMatch(pattern, channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanSubscribePattern reports whether the user may PSUBSCRIBE pattern. As
+// in Redis, the pattern must equal one of the user's channel patterns,
+// since matching it against them would let a broader pattern through.
+func (u *User) CanSubscribePattern(pattern string) bool {
+	for _, allowed := range u.channelPatterns {
+		if allowed == "*" || allowed == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *User) checkPassword(password string) bool {
+	return u.nopass || u.passwords[hashPassword(password)]
+}
+
+// Rules describes the user in the ACL SETUSER syntax, as used by ACL LIST
+// and the ACL file.
+func (u *User) Rules() string {
+	parts := []string{u.flag()}
+
+	if u.nopass {
+		parts = append(parts, "nopass")
+	}
+	for _, hash := range u.sortedPasswords() {
+		parts = append(parts, "#"+hash)
+	}
+	for _, pattern := range u.keyPatterns {
+		parts = append(parts, "~"+pattern)
+	}
+	if len(u.channelPatterns) == 0 {
+		parts = append(parts, "resetchannels")
+	}
+	for _, pattern := range u.channelPatterns {
+		parts = append(parts, "&"+pattern)
+	}
+	parts = append(parts, u.commandsDescription())
+
+	return strings.Join(parts, " ")
+}
+
+func (u *User) flag() string {
+	if u.enabled {
+		return "on"
+	}
+	return "off"
+}
+
+func (u *User) sortedPasswords() []string {
+	hashes := make([]string, 0, len(u.passwords))
+	for hash := range u.passwords {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+func (u *User) commandsDescription() string {
+	if len(u.commandRules) == 0 {
+		return "-@all"
+	}
+	return strings.Join(u.commandRules, " ")
+}
+
+func hashPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func isPasswordHash(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func isACLCategory(category string) bool {
+	for _, c := range aclCategories() {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// ACL holds the users known to the server. Connections start out as the
+// default user when it is enabled and has no password, and must
+// authenticate otherwise.
+type ACL struct {
+	mu    sync.RWMutex
+	users map[string]*User
+	file  string
+}
+
+// NewACL creates an ACL with only the default user, which may run every
+// command without a password. file is used by Load and Save; an empty file
+// disables them.
+func NewACL(file string) *ACL {
+	return &ACL{
+		users: map[string]*User{DefaultUser: newDefaultUser()},
+		file:  file,
+	}
+}
+
+func (a *ACL) User(name string) (*User, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	user, ok := a.users[name]
+	return user, ok
+}
+
+// Users returns all users ordered by name.
+func (a *ACL) Users() []*User {
+	a.mu.RLock()
+	users := make([]*User, 0, len(a.users))
+	for _, user := range a.users {
+		users = append(users, user)
+	}
+	a.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name < users[j].Name
+	})
+	return users
+}
+
+// SetUser creates the user if needed and applies rules to it. Either all
+// rules are applied or, on error, the user is left unchanged.
+func (a *ACL) SetUser(name string, rules ...string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user := newUser(name)
+	if existing, ok := a.users[name]; ok {
+		user = existing.clone()
+	}
+	if err := user.applyRules(rules); err != nil {
+		return err
+	}
+	a.users[name] = user
+	return nil
+}
+
+// DeleteUser removes the named users and returns the names of those that
+// existed.
+func (a *ACL) DeleteUser(names ...string) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, name := range names {
+		if name == DefaultUser {
+			return nil, ErrDefaultUserFixed
+		}
+	}
+
+	var deleted []string
+	for _, name := range names {
+		if _, ok := a.users[name]; ok {
+			delete(a.users, name)
+			deleted = append(deleted, name)
+		}
+	}
+	return deleted, nil
+}
+
+// Authenticate returns the user if it is enabled and password matches.
+func (a *ACL) Authenticate(name, password string) (*User, bool) {
+	user, ok := a.User(name)
+	if !ok || !user.enabled || !user.checkPassword(password) {
+		return nil, false
+	}
+	return user, true
+}
+
+// RequiresAuth reports whether new connections must authenticate before
+// running commands.
+func (a *ACL) RequiresAuth() bool {
+	user, ok := a.User(DefaultUser)
+	return !ok || !user.enabled || !user.nopass
+}
+
+// userFor returns the user a client runs commands as, or false if the
+// client has to authenticate first.
+func (a *ACL) userFor(client *Client) (*User, bool) {
+	name := client.authUser()
+	if name == "" {
+		if a.RequiresAuth() {
+			return nil, false
+		}
+		name = DefaultUser
+	}
+
+	user, ok := a.User(name)
+	if !ok {
+		return nil, false
+	}
+	return user, true
+}
+
+// Load replaces all users with the ones defined in the ACL file. The
+// default user keeps its current settings unless the file defines it.
+func (a *ACL) Load() error {
+	if a.file == "" {
+		return ErrNoACLFile
+	}
+
+	file, err := os.Open(a.file)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	users := make(map[string]*User)
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "user" {
+			return fmt.Errorf("%s:%d: should start with user keyword followed by the username", a.file, lineNum)
+		}
+		name := fields[1]
+		if _, ok := users[name]; ok {
+			return fmt.Errorf("%s:%d: duplicate user '%s' found", a.file, lineNum, name)
+		}
+
+		user := newUser(name)
+		if err := user.applyRules(fields[2:]); err != nil {
+			return fmt.Errorf("%s:%d: %v", a.file, lineNum, err)
+		}
+		users[name] = user
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := users[DefaultUser]; !ok {
+		users[DefaultUser] = a.users[DefaultUser]
+	}
+	a.users = users
+	return nil
+}
+
+// Save writes all users to the ACL file, replacing it atomically.
+func (a *ACL) Save() error {
+	if a.file == "" {
+		return ErrNoACLFile
+	}
+
+	var b strings.Builder
+	for _, user := range a.Users() {
+		fmt.Fprintf(&b, "user %s %s\n", user.Name, user.Rules())
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(a.file), filepath.Base(a.file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), a.file)
+}
@@ -0,0 +1,265 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"sort"
+	"strings"
+)
+
+func (e *Executor) SetACL(acl *ACL) {
+	e.acl = acl
+}
+
+// authorize checks that client may run cmd with its arguments. It returns
+// the error reply and false when the command must not be executed.
+func (e *Executor) authorize(client *Client, cmd *Command) (protocol.Value, bool) {
+	if cmd.Name == "AUTH" {
+		return protocol.Value{}, true
+	}
+
+	user, ok := e.acl.userFor(client)
+	if !ok {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "NOAUTH Authentication required.",
+		}, false
+	}
+
+	fullName := cmd.FullName()
+	spec := lookupCommand(cmd)
+	if spec == nil {
+		// Unknown commands are rejected by the dispatcher.
+		return protocol.Value{}, true
+	}
+
+	if !user.CanRun(fullName) {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "NOPERM User " + user.Name + " has no permissions to run the '" + fullName + "' command",
+		}, false
+	}
+
+	for _, key := range spec.keys(cmd.Args) {
+		if !user.CanAccessKey(key) {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "NOPERM No permissions to access a key",
+			}, false
+		}
+	}
+
+	names, patterns := channelsToAuthorize(cmd)
+	for _, name := range names {
+		allowed := user.CanAccessChannel(name)
+		if patterns {
+			allowed = user.CanSubscribePattern(name)
+		}
+		if !allowed {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "NOPERM No permissions to access a channel",
+			}, false
+		}
+	}
+	return protocol.Value{}, true
+}
+
+// Connection commands
+
+func (e *Executor) auth(client *Client, cmd *Command) protocol.Value {
+	username, password := DefaultUser, cmd.Args[0]
+	if len(cmd.Args) == 2 {
+		username, password = cmd.Args[0], cmd.Args[1]
+	} else if !e.acl.RequiresAuth() {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?",
+		}
+	}
+
+	if _, ok := e.acl.Authenticate(username, password); !ok {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "WRONGPASS invalid username-password pair or user is disabled.",
+		}
+	}
+
+	if client != nil {
+		client.setUser(username)
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+// ACL commands
+
+func (e *Executor) aclSetUser(cmd *Command) protocol.Value {
+	if err := e.acl.SetUser(cmd.Args[1], cmd.Args[2:]...); err != nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR " + err.Error(),
+		}
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+func (e *Executor) aclGetUser(cmd *Command) protocol.Value {
+	user, ok := e.acl.User(cmd.Args[1])
+	if !ok {
+		return protocol.Value{
+			Type:   protocol.Array,
+			IsNull: true,
+		}
+	}
+
+	flags := []protocol.Value{{Type: protocol.SimpleString, Str: user.flag()}}
+	if user.nopass {
+		flags = append(flags, protocol.Value{Type: protocol.SimpleString, Str: "nopass"})
+	}
+
+	var keys, channels []string
+	for _, pattern := range user.keyPatterns {
+		keys = append(keys, "~"+pattern)
+	}
+	for _, pattern := range user.channelPatterns {
+		channels = append(channels, "&"+pattern)
+	}
+
+	return protocol.Value{
+		Type: protocol.Array,
+		Array: []protocol.Value{
+			{Type: protocol.BulkString, Bulk: "flags"},
+			{Type: protocol.Array, Array: flags},
+			{Type: protocol.BulkString, Bulk: "passwords"},
+			bulkArray(user.sortedPasswords()),
+			{Type: protocol.BulkString, Bulk: "commands"},
+			{Type: protocol.BulkString, Bulk: user.commandsDescription()},
+			{Type: protocol.BulkString, Bulk: "keys"},
+			{Type: protocol.BulkString, Bulk: strings.Join(keys, " ")},
+			{Type: protocol.BulkString, Bulk: "channels"},
+			{Type: protocol.BulkString, Bulk: strings.Join(channels, " ")},
+		},
+	}
+}
+
+func (e *Executor) aclDelUser(client *Client, cmd *Command) protocol.Value {
+	deleted, err := e.acl.DeleteUser(cmd.Args[1:]...)
+	if err != nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR " + err.Error(),
+		}
+	}
+
+	// Connections authenticated as a deleted user are closed. No connection
+	// runs as the empty name, which the filter would take for any user.
+	for _, name := range deleted {
+		if name != "" {
+			e.clients.Kill(ClientFilter{User: name}, client)
+		}
+	}
+
+	return protocol.Value{
+		Type: protocol.Integer,
+		Num:  len(deleted),
+	}
+}
+
+func (e *Executor) aclList(cmd *Command) protocol.Value {
+	var lines []string
+	for _, user := range e.acl.Users() {
+		lines = append(lines, "user "+user.Name+" "+user.Rules())
+	}
+	return bulkArray(lines)
+}
+
+func (e *Executor) aclUsers(cmd *Command) protocol.Value {
+	var names []string
+	for _, user := range e.acl.Users() {
+		names = append(names, user.Name)
+	}
+	return bulkArray(names)
+}
+
+func (e *Executor) aclWhoAmI(client *Client, cmd *Command) protocol.Value {
+	name := DefaultUser
+	if client != nil {
+		name = client.User()
+	}
+	return protocol.Value{
+		Type: protocol.BulkString,
+		Bulk: name,
+	}
+}
+
+func (e *Executor) aclCat(cmd *Command) protocol.Value {
+	switch len(cmd.Args) {
+	case 1:
+		return bulkArray(aclCategories())
+	case 2:
+	default:
+		return wrongSubcommandArgs("acl|cat")
+	}
+
+	category := strings.ToLower(cmd.Args[1])
+	if !isACLCategory(category) {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR Unknown category '" + cmd.Args[1] + "'",
+		}
+	}
+
+	var names []string
+	for name, spec := range commandTable {
+		if spec.inCategory(category) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return bulkArray(names)
+}
+
+func (e *Executor) aclLoad(cmd *Command) protocol.Value {
+	if err := e.acl.Load(); err != nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR " + err.Error(),
+		}
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+func (e *Executor) aclSave(cmd *Command) protocol.Value {
+	if err := e.acl.Save(); err != nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR There was an error trying to save the ACLs: " + err.Error(),
+		}
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+func bulkArray(items []string) protocol.Value {
+	array := make([]protocol.Value, len(items))
+	for i, item := range items {
+		array[i] = protocol.Value{
+			Type: protocol.BulkString,
+			Bulk: item,
+		}
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: array,
+	}
+}
@@ -0,0 +1,238 @@
+package command
+
+import (
+	"errors"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func execute(executor *Executor, client *Client, args ...string) protocol.Value {
+	return executor.Execute(client, &Command{Name: strings.ToUpper(args[0]), Args: args[1:]})
+}
+
+func expectOK(t *testing.T, reply protocol.Value) {
+	t.Helper()
+	if reply.Type != protocol.SimpleString || reply.Str != "OK" {
+		t.Errorf("Expected OK, got %+v", reply)
+	}
+}
+
+func expectErrorPrefix(t *testing.T, reply protocol.Value, prefix string) {
+	t.Helper()
+	if reply.Type != protocol.Error || !strings.HasPrefix(reply.Str, prefix) {
+		t.Errorf("Expected error starting with %q, got %+v", prefix, reply)
+	}
+}
+
+func TestAuthWithRequiredPassword(t *testing.T) {
+	registry := NewClientRegistry(0)
+	executor := NewExecutor(storage.NewMemoryStorage(), registry)
+
+	client, _ := registerPipeClient(t, registry)
+
+	expectErrorPrefix(t, execute(executor, client, "AUTH", "secret"), "ERR AUTH <password> called without")
+
+	executor.acl.SetUser(DefaultUser, "resetpass", ">secret")
+
+	expectErrorPrefix(t, execute(executor, client, "GET", "key"), "NOAUTH")
+	expectErrorPrefix(t, execute(executor, client, "AUTH", "wrong"), "WRONGPASS")
+	expectOK(t, execute(executor, client, "AUTH", "secret"))
+	expectNull(t, execute(executor, client, "GET", "key"))
+	expectBulk(t, execute(executor, client, "ACL", "WHOAMI"), DefaultUser)
+}
+
+func TestACLPermissions(t *testing.T) {
+	registry := NewClientRegistry(0)
+	executor := NewExecutor(storage.NewMemoryStorage(), registry)
+
+	admin, _ := registerPipeClient(t, registry)
+	client, clientConn := registerPipeClient(t, registry)
+
+	expectOK(t, execute(executor, admin, "ACL", "SETUSER", "reader", "on", ">pw", "~cache:*", "+@read", "-keys"))
+	expectOK(t, execute(executor, admin, "SET", "cache:1", "value"))
+	expectOK(t, execute(executor, client, "AUTH", "reader", "pw"))
+
+	expectBulk(t, execute(executor, client, "GET", "cache:1"), "value")
+	expectErrorPrefix(t, execute(executor, client, "GET", "other"), "NOPERM No permissions to access a key")
+	expectErrorPrefix(t, execute(executor, client, "EXISTS", "cache:1", "other"), "NOPERM")
+	expectErrorPrefix(t, execute(executor, client, "SET", "cache:1", "x"), "NOPERM User reader has no permissions to run the 'set' command")
+	expectErrorPrefix(t, execute(executor, client, "KEYS", "*"), "NOPERM")
+	expectErrorPrefix(t, execute(executor, client, "FLUSHDB"), "NOPERM")
+	expectErrorPrefix(t, execute(executor, client, "DIGEST"), "NOPERM")
+
+	// Subcommand rules override the rule for the container command.
+	expectOK(t, execute(executor, admin, "ACL", "SETUSER", "reader", "+client", "-client|kill"))
+	expectInteger(t, execute(executor, client, "CLIENT", "ID"), int(client.ID))
+	expectErrorPrefix(t, execute(executor, client, "CLIENT", "KILL", "ID", "1"), "NOPERM")
+
+	// Rule changes apply to authenticated connections immediately.
+	expectOK(t, execute(executor, admin, "ACL", "SETUSER", "reader", "allkeys"))
+	expectNull(t, execute(executor, client, "GET", "other"))
+
+	expectErrorPrefix(t, execute(executor, admin, "ACL", "SETUSER", "reader", "+nosuchcommand"), "ERR error in ACL SETUSER modifier")
+	expectErrorPrefix(t, execute(executor, client, "AUTH", "reader", "wrong"), "WRONGPASS")
+
+	expectOK(t, execute(executor, admin, "ACL", "SETUSER", "reader", "off"))
+	expectErrorPrefix(t, execute(executor, client, "AUTH", "reader", "pw"), "WRONGPASS")
+
+	expectErrorPrefix(t, execute(executor, admin, "ACL", "DELUSER", "default"), "ERR")
+	expectInteger(t, execute(executor, admin, "ACL", "DELUSER", "reader", "missing"), 1)
+	if _, err := clientConn.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected the deleted user's connection to be closed")
+	}
+}
+
+func TestACLDelUserClosesOnlyDeletedUsers(t *testing.T) {
+	registry := NewClientRegistry(0)
+	executor := NewExecutor(storage.NewMemoryStorage(), registry)
+
+	admin, _ := registerPipeClient(t, registry)
+	_, defaultConn := registerPipeClient(t, registry)
+	alice, aliceConn := registerPipeClient(t, registry)
+	expectOK(t, execute(executor, admin, "ACL", "SETUSER", "alice", "on", ">pw", "+@all"))
+	expectOK(t, execute(executor, alice, "AUTH", "alice", "pw"))
+
+	for _, name := range []string{"", "Default", "Alice", "missing"} {
+		expectInteger(t, execute(executor, admin, "ACL", "DELUSER", name), 0)
+		if connClosed(defaultConn) || connClosed(aliceConn) {
+			t.Fatalf("ACL DELUSER %q: expected no connection to be closed", name)
+		}
+	}
+
+	expectInteger(t, execute(executor, admin, "ACL", "DELUSER", "alice"), 1)
+	if !connClosed(aliceConn) {
+		t.Error("Expected alice's connection to be closed")
+	}
+	if connClosed(defaultConn) {
+		t.Error("Expected the default user's connection to stay open")
+	}
+}
+
+// connClosed reports whether the server closed the other end of conn.
+func connClosed(conn net.Conn) bool {
+	conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	_, err := conn.Read(make([]byte, 1))
+	return err != nil && !errors.Is(err, os.ErrDeadlineExceeded)
+}
+
+func TestACLChannelPatterns(t *testing.T) {
+	registry := NewClientRegistry(0)
+	executor := NewExecutor(storage.NewMemoryStorage(), registry)
+
+	client, _ := registerPipeClient(t, registry)
+	expectOK(t, execute(executor, nil, "ACL", "SETUSER", "bob", "on", ">pw", "resetchannels", "&news.?", "+@pubsub"))
+	expectOK(t, execute(executor, client, "AUTH", "bob", "pw"))
+
+	// news.* matches news.? as a channel name but also news.sports, which
+	// bob may not read.
+	expectErrorPrefix(t, execute(executor, client, "PSUBSCRIBE", "news.*"), "NOPERM No permissions to access a channel")
+	for _, args := range [][]string{{"PSUBSCRIBE", "news.?"}, {"SUBSCRIBE", "news.a"}} {
+		if reply := execute(executor, client, args...); reply.Type == protocol.Error {
+			t.Errorf("%v: unexpected error %s", args, reply.Str)
+		}
+	}
+}
+
+func TestACLGetUserAndCat(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+
+	expectOK(t, execute(executor, nil, "ACL", "SETUSER", "alice", "on", "nopass", "~a:*", "&news.*", "+@hash"))
+
+	reply := execute(executor, nil, "ACL", "GETUSER", "alice")
+	if reply.Type != protocol.Array || len(reply.Array) != 10 {
+		t.Fatalf("ACL GETUSER: unexpected reply %+v", reply)
+	}
+	if reply.Array[5].Bulk != "+@hash" || reply.Array[7].Bulk != "~a:*" || reply.Array[9].Bulk != "&news.*" {
+		t.Errorf("ACL GETUSER: unexpected fields %+v", reply.Array)
+	}
+
+	if reply := execute(executor, nil, "ACL", "GETUSER", "missing"); !reply.IsNull {
+		t.Errorf("ACL GETUSER for a missing user should be null, got %+v", reply)
+	}
+
+	categories := bulkStrings(t, execute(executor, nil, "ACL", "CAT"))
+	for _, category := range []string{"admin", "dangerous", "read", "write"} {
+		if !slices.Contains(categories, category) {
+			t.Errorf("ACL CAT: missing %s in %v", category, categories)
+		}
+	}
+
+	commands := bulkStrings(t, execute(executor, nil, "ACL", "CAT", "dangerous"))
+	if !slices.Contains(commands, "flushdb") || slices.Contains(commands, "get") {
+		t.Errorf("ACL CAT dangerous: unexpected %v", commands)
+	}
+	expectErrorPrefix(t, execute(executor, nil, "ACL", "CAT", "nosuch"), "ERR Unknown category")
+}
+
+func TestACLFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.acl")
+
+	acl := NewACL(file)
+	if err := acl.SetUser("alice", "on", ">pw", "~*", "+@all", "-flushdb"); err != nil {
+		t.Fatalf("SetUser failed: %v", err)
+	}
+	if err := acl.SetUser(DefaultUser, "resetpass", ">secret"); err != nil {
+		t.Fatalf("SetUser failed: %v", err)
+	}
+	if err := acl.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded := NewACL(file)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	alice, ok := loaded.Authenticate("alice", "pw")
+	if !ok {
+		t.Fatal("Expected alice to authenticate after loading the ACL file")
+	}
+	if !alice.CanRun("get") || alice.CanRun("flushdb") {
+		t.Errorf("Unexpected permissions after load: %s", alice.Rules())
+	}
+	if !loaded.RequiresAuth() {
+		t.Error("Expected the default user's password to be loaded")
+	}
+
+	// Patterns with spaces could not be read back from the file.
+	for _, rule := range []string{"~a b", "&news\tsport"} {
+		if err := acl.SetUser("alice", rule); err == nil {
+			t.Errorf("Expected %q to be rejected", rule)
+		}
+	}
+
+	if err := NewACL("").Save(); err != ErrNoACLFile {
+		t.Errorf("Expected ErrNoACLFile, got %v", err)
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		match      bool
+	}{
+		{"*", "anything", true},
+		{"cache:*", "cache:1", true},
+		{"cache:*", "user:1", false},
+		{"h?llo", "hello", true},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"a\\*b", "a*b", true},
+		{"a\\*b", "axb", false},
+		{"*:*:end", "a:b:end", true},
+	}
+
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.match {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.match)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"ivanSaichkin/myredis/internal/protocol"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuditRedaction selects which command arguments the audit log hides.
+type AuditRedaction int
+
+const (
+	// AuditRedactValues records key names and hides other arguments.
+	AuditRedactValues AuditRedaction = iota
+	// AuditRedactNone records every argument.
+	AuditRedactNone
+	// AuditRedactAll hides every argument.
+	AuditRedactAll
+)
+
+var auditRedactionNames = []string{"values", "none", "all"}
+
+const redactedArg = "(redacted)"
+
+func (r AuditRedaction) String() string {
+	return auditRedactionNames[r]
+}
+
+func ParseAuditRedaction(name string) (AuditRedaction, error) {
+	for i, redactionName := range auditRedactionNames {
+		if strings.EqualFold(name, redactionName) {
+			return AuditRedaction(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid audit redaction %q, must be one of %s", name, strings.Join(auditRedactionNames, ", "))
+}
+
+// auditRecord is one line of the audit log.
+type auditRecord struct {
+	Time     time.Time `json:"time"`
+	ClientID int64     `json:"client_id"`
+	Addr     string    `json:"addr"`
+	User     string    `json:"user"`
+	DB       int       `json:"db"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// AuditLog appends a JSON line for every write and admin command executed
+// to its output. It records nothing while it has no output.
+type AuditLog struct {
+	redaction atomic.Int64
+	enabled   atomic.Bool
+
+	mu  sync.Mutex
+	out io.WriteCloser
+	// failing is set after a failed write so that only the first failure
+	// of a series is reported.
+	failing bool
+}
+
+func NewAuditLog() *AuditLog {
+	return &AuditLog{}
+}
+
+// SetOutput closes the current output and starts writing to out; a nil
+// out disables the log.
+func (l *AuditLog) SetOutput(out io.WriteCloser) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var err error
+	if l.out != nil {
+		err = l.out.Close()
+	}
+	l.out = out
+	l.failing = false
+	l.enabled.Store(out != nil)
+	return err
+}
+
+func (l *AuditLog) Enabled() bool {
+	return l.enabled.Load()
+}
+
+func (l *AuditLog) SetRedaction(redaction AuditRedaction) {
+	l.redaction.Store(int64(redaction))
+}
+
+func (l *AuditLog) Redaction() AuditRedaction {
+	return AuditRedaction(l.redaction.Load())
+}
+
+// Close closes the output and disables the log.
+func (l *AuditLog) Close() error {
+	return l.SetOutput(nil)
+}
+
+// record logs cmd if it is a write or admin command. It returns the
+// error of a failed write, unless the previous write failed too.
+func (l *AuditLog) record(client *Client, cmd *Command, reply protocol.Value) error {
+	if !l.Enabled() {
+		return nil
+	}
+	spec := lookupCommand(cmd)
+	if spec == nil || spec.flags&(flagWrite|flagAdmin) == 0 {
+		return nil
+	}
+
+	args := cmd.Args
+	if spec.name != strings.ToLower(cmd.Name) {
+		// The subcommand is part of the command name.
+		args = args[1:]
+	}
+	record := auditRecord{
+		Time:    time.Now(),
+		Command: spec.name,
+		Args:    l.redact(spec, args),
+	}
+	if client != nil {
+		record.ClientID = client.ID
+		record.Addr = client.Addr
+		record.User = client.User()
+	}
+	if reply.Type == protocol.Error {
+		record.Error = reply.Str
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.out == nil {
+		return nil
+	}
+	if _, err := l.out.Write(line); err != nil {
+		reported := l.failing
+		l.failing = true
+		if reported {
+			return nil
+		}
+		return err
+	}
+	l.failing = false
+	return nil
+}
+
+// redact returns args with the arguments hidden by the redaction replaced.
+// The arguments of commands that may carry secrets are always hidden.
+func (l *AuditLog) redact(spec *commandSpec, args []string) []string {
+	redaction := l.Redaction()
+	if secretCommands[spec.name] {
+		redaction = AuditRedactAll
+	}
+	if redaction == AuditRedactNone {
+		return args
+	}
+
+	keys := make(map[int]bool)
+	if redaction == AuditRedactValues {
+		for _, i := range spec.keyIndexes(len(args)) {
+			keys[i] = true
+		}
+	}
+
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if keys[i] {
+			redacted[i] = arg
+		} else {
+			redacted[i] = redactedArg
+		}
+	}
+	return redacted
+}
+
+func (e *Executor) AuditLog() *AuditLog {
+	return e.auditLog
+}
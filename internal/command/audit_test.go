@@ -0,0 +1,87 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"ivanSaichkin/myredis/internal/storage"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type nopCloser struct {
+	bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func auditRecords(t *testing.T, out *nopCloser) []auditRecord {
+	t.Helper()
+
+	var records []auditRecord
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		var record auditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid audit line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	out.Reset()
+	return records
+}
+
+func TestAuditLog(t *testing.T) {
+	registry := NewClientRegistry(0)
+	executor := NewExecutor(storage.NewMemoryStorage(), registry)
+	client, _ := registerPipeClient(t, registry)
+
+	// Nothing is recorded without an output.
+	execute(executor, client, "SET", "a", "1")
+
+	out := &nopCloser{}
+	executor.AuditLog().SetOutput(out)
+	execute(executor, client, "SET", "a", "secret")
+	execute(executor, client, "GET", "a")
+	execute(executor, client, "HSET", "h", "f", "v")
+	execute(executor, client, "LPUSH", "a", "x")
+	execute(executor, nil, "CONFIG", "SET", "requirepass", "s3cret")
+	execute(executor, client, "FLUSHDB")
+
+	records := auditRecords(t, out)
+	if len(records) != 5 {
+		t.Fatalf("Expected 5 records, got %+v", records)
+	}
+	set := records[0]
+	if set.Command != "set" || set.ClientID != client.ID || set.Addr != client.Addr || set.User != "default" {
+		t.Errorf("Unexpected record %+v", set)
+	}
+	if !slices.Equal(set.Args, []string{"a", redactedArg}) {
+		t.Errorf("Expected the value to be redacted, got %v", set.Args)
+	}
+	if records[2].Command != "lpush" || !strings.Contains(records[2].Error, "wrong kind of value") {
+		t.Errorf("Expected the error to be recorded, got %+v", records[2])
+	}
+	if config := records[3]; config.Command != "config|set" || !slices.Equal(config.Args, []string{redactedArg, redactedArg}) {
+		t.Errorf("Expected CONFIG SET to be redacted, got %+v", config)
+	}
+	if records[4].Command != "flushdb" || len(records[4].Args) != 0 {
+		t.Errorf("Unexpected record %+v", records[4])
+	}
+
+	executor.AuditLog().SetRedaction(AuditRedactNone)
+	execute(executor, client, "SET", "a", "visible")
+	executor.AuditLog().SetRedaction(AuditRedactAll)
+	execute(executor, client, "SET", "a", "hidden")
+	records = auditRecords(t, out)
+	if !slices.Equal(records[0].Args, []string{"a", "visible"}) || !slices.Equal(records[1].Args, []string{redactedArg, redactedArg}) {
+		t.Errorf("Unexpected arguments %v and %v", records[0].Args, records[1].Args)
+	}
+
+	executor.AuditLog().Close()
+	execute(executor, client, "SET", "a", "1")
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing to be recorded after Close, got %q", out.String())
+	}
+}
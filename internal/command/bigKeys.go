@@ -0,0 +1,244 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// A BIGKEYS scan measures bigKeysBatch keys at a time and pauses for
+	// bigKeysPause in between, so that it does not compete with clients.
+	bigKeysBatch = 256
+	bigKeysPause = time.Millisecond
+	// bigKeysMaxCount bounds the keys reported per type and ranking.
+	bigKeysMaxCount = 100
+)
+
+var bigKeysTypes = []storage.ValueType{storage.StringType, storage.ListType, storage.HashType, storage.SetType}
+
+// keySizer is implemented by storages that can measure a key's value.
+type keySizer interface {
+	KeySize(key string) (storage.KeySize, bool)
+}
+
+// bigKey is a key and its size by one ranking.
+type bigKey struct {
+	key  string
+	size int64
+}
+
+// bigKeysType summarizes the keys of one type.
+type bigKeysType struct {
+	keys       int
+	elements   int64
+	memory     int64
+	byElements []bigKey
+	byMemory   []bigKey
+}
+
+// bigKeysScan is the state and report of the latest BIGKEYS scan.
+type bigKeysScan struct {
+	mu       sync.Mutex
+	running  bool
+	started  time.Time
+	finished time.Time
+	total    int
+	scanned  int
+	// count is the number of keys kept per type and ranking.
+	count int
+	types map[storage.ValueType]*bigKeysType
+}
+
+// add records the size of key; mu must be held.
+func (s *bigKeysScan) add(key string, size storage.KeySize) {
+	t := s.types[size.Type]
+	if t == nil {
+		return
+	}
+	t.keys++
+	t.elements += int64(size.Elements)
+	t.memory += size.Memory
+	t.byElements = insertBigKey(t.byElements, bigKey{key, int64(size.Elements)}, s.count)
+	t.byMemory = insertBigKey(t.byMemory, bigKey{key, size.Memory}, s.count)
+}
+
+// insertBigKey inserts key into keys, which are sorted by decreasing size,
+// keeping at most n keys.
+func insertBigKey(keys []bigKey, key bigKey, n int) []bigKey {
+	i := len(keys)
+	for i > 0 && keys[i-1].size < key.size {
+		i--
+	}
+	if i >= n {
+		return keys
+	}
+	if len(keys) < n {
+		keys = append(keys, bigKey{})
+	}
+	copy(keys[i+1:], keys[i:])
+	keys[i] = key
+	return keys
+}
+
+// scan measures every key of store in batches.
+func (s *bigKeysScan) scan(store storage.Storage, sizer keySizer) {
+	keys := store.Keys()
+
+	s.mu.Lock()
+	s.total = len(keys)
+	s.mu.Unlock()
+
+	for start := 0; start < len(keys); start += bigKeysBatch {
+		batch := keys[start:min(start+bigKeysBatch, len(keys))]
+		sizes := make([]storage.KeySize, len(batch))
+		found := make([]bool, len(batch))
+		for i, key := range batch {
+			// Keys deleted since the scan started are skipped.
+			sizes[i], found[i] = sizer.KeySize(key)
+		}
+
+		s.mu.Lock()
+		for i, key := range batch {
+			if found[i] {
+				s.add(key, sizes[i])
+			}
+		}
+		s.scanned += len(batch)
+		s.mu.Unlock()
+
+		time.Sleep(bigKeysPause)
+	}
+
+	s.mu.Lock()
+	s.running = false
+	s.finished = time.Now()
+	s.mu.Unlock()
+}
+
+func (e *Executor) bigkeysScan(cmd *Command) protocol.Value {
+	if len(cmd.Args) > 2 {
+		return wrongSubcommandArgs("bigkeys|scan")
+	}
+	count := 5
+	if len(cmd.Args) == 2 {
+		var err error
+		if count, err = strconv.Atoi(cmd.Args[1]); err != nil || count < 1 || count > bigKeysMaxCount {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR count should be between 1 and " + strconv.Itoa(bigKeysMaxCount),
+			}
+		}
+	}
+
+	sizer, ok := e.storage.(keySizer)
+	if !ok {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR BIGKEYS is not supported by this storage",
+		}
+	}
+
+	s := &e.bigKeys
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR a BIGKEYS scan is already in progress",
+		}
+	}
+	s.running = true
+	s.started = time.Now()
+	s.finished = time.Time{}
+	s.total = 0
+	s.scanned = 0
+	s.count = count
+	s.types = make(map[storage.ValueType]*bigKeysType)
+	for _, t := range bigKeysTypes {
+		s.types[t] = &bigKeysType{}
+	}
+	go s.scan(e.storage, sizer)
+
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+// bigkeysReport describes the latest scan as a flattened map, with the
+// largest keys of every type by element count and by estimated memory.
+func (e *Executor) bigkeysReport(cmd *Command) protocol.Value {
+	s := &e.bigKeys
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started.IsZero() {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR no BIGKEYS scan has been run, start one with BIGKEYS SCAN",
+		}
+	}
+
+	status, end := "done", s.finished
+	if s.running {
+		status, end = "running", time.Now()
+	}
+	types := make([]protocol.Value, 0, 2*len(bigKeysTypes))
+	for _, t := range bigKeysTypes {
+		report := s.types[t]
+		types = append(types,
+			protocol.Value{Type: protocol.BulkString, Bulk: t.String()},
+			protocol.Value{Type: protocol.Array, Array: []protocol.Value{
+				{Type: protocol.BulkString, Bulk: "keys"},
+				{Type: protocol.Integer, Num: report.keys},
+				{Type: protocol.BulkString, Bulk: "elements"},
+				{Type: protocol.Integer, Num: int(report.elements)},
+				{Type: protocol.BulkString, Bulk: "memory"},
+				{Type: protocol.Integer, Num: int(report.memory)},
+				{Type: protocol.BulkString, Bulk: "by_elements"},
+				bigKeysReply(report.byElements),
+				{Type: protocol.BulkString, Bulk: "by_memory"},
+				bigKeysReply(report.byMemory),
+			}},
+		)
+	}
+
+	return protocol.Value{
+		Type: protocol.Array,
+		Array: []protocol.Value{
+			{Type: protocol.BulkString, Bulk: "status"},
+			{Type: protocol.BulkString, Bulk: status},
+			{Type: protocol.BulkString, Bulk: "scanned_keys"},
+			{Type: protocol.Integer, Num: s.scanned},
+			{Type: protocol.BulkString, Bulk: "total_keys"},
+			{Type: protocol.Integer, Num: s.total},
+			{Type: protocol.BulkString, Bulk: "started_at"},
+			{Type: protocol.Integer, Num: int(s.started.Unix())},
+			{Type: protocol.BulkString, Bulk: "duration_ms"},
+			{Type: protocol.Integer, Num: int(end.Sub(s.started).Milliseconds())},
+			{Type: protocol.BulkString, Bulk: "types"},
+			{Type: protocol.Array, Array: types},
+		},
+	}
+}
+
+func bigKeysReply(keys []bigKey) protocol.Value {
+	result := make([]protocol.Value, len(keys))
+	for i, key := range keys {
+		result[i] = protocol.Value{
+			Type: protocol.Array,
+			Array: []protocol.Value{
+				{Type: protocol.BulkString, Bulk: key.key},
+				{Type: protocol.Integer, Num: int(key.size)},
+			},
+		}
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: result,
+	}
+}
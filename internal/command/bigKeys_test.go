@@ -0,0 +1,74 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flatMap returns the values of a flattened map reply by name.
+func flatMap(t *testing.T, reply protocol.Value) map[string]protocol.Value {
+	t.Helper()
+
+	if reply.Type != protocol.Array || len(reply.Array)%2 != 0 {
+		t.Fatalf("Expected a flattened map, got %+v", reply)
+	}
+	values := make(map[string]protocol.Value)
+	for i := 0; i < len(reply.Array); i += 2 {
+		values[reply.Array[i].Bulk] = reply.Array[i+1]
+	}
+	return values
+}
+
+func TestBigKeys(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	executor := NewExecutor(store, NewClientRegistry(0))
+
+	expectErrorPrefix(t, execute(executor, nil, "BIGKEYS", "REPORT"), "ERR no BIGKEYS scan")
+
+	for i := 0; i < 600; i++ {
+		store.Set("s:"+strconv.Itoa(i), strings.Repeat("x", i))
+	}
+	store.RPush("short", "a", "b", "c")
+	store.RPush("long", "a", "b", strings.Repeat("c", 1000))
+	store.SAdd("set", "m")
+
+	expectOK(t, execute(executor, nil, "BIGKEYS", "SCAN", "2"))
+
+	var report map[string]protocol.Value
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		report = flatMap(t, execute(executor, nil, "BIGKEYS", "REPORT"))
+		if report["status"].Bulk == "done" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("The scan did not finish: %+v", report)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	expectInteger(t, report["scanned_keys"], 603)
+	expectInteger(t, report["total_keys"], 603)
+
+	types := flatMap(t, report["types"])
+	strs := flatMap(t, types["string"])
+	expectInteger(t, strs["keys"], 600)
+	expectInteger(t, strs["elements"], 599*600/2)
+	if byElements := strs["by_elements"].Array; len(byElements) != 2 ||
+		byElements[0].Array[0].Bulk != "s:599" || byElements[1].Array[0].Bulk != "s:598" {
+		t.Errorf("Unexpected largest strings %+v", byElements)
+	}
+
+	// Both lists have 3 elements, but one holds far more data.
+	lists := flatMap(t, types["list"])
+	if byMemory := lists["by_memory"].Array; len(byMemory) != 2 || byMemory[0].Array[0].Bulk != "long" {
+		t.Errorf("Unexpected largest lists %+v", byMemory)
+	}
+	expectInteger(t, flatMap(t, types["hash"])["keys"], 0)
+	expectInteger(t, flatMap(t, types["set"])["keys"], 1)
+
+	expectErrorPrefix(t, execute(executor, nil, "BIGKEYS", "SCAN", "0"), "ERR count")
+}
@@ -0,0 +1,171 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"strconv"
+	"strings"
+)
+
+// Client commands
+
+func (e *Executor) clientID(client *Client, cmd *Command) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Integer,
+		Num:  int(client.ID),
+	}
+}
+
+func (e *Executor) clientSetName(client *Client, cmd *Command) protocol.Value {
+	name := cmd.Args[1]
+	for i := 0; i < len(name); i++ {
+		if name[i] <= ' ' || name[i] > '~' {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR Client names cannot contain spaces, newlines or special characters.",
+			}
+		}
+	}
+
+	client.SetName(name)
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+func (e *Executor) clientGetName(client *Client, cmd *Command) protocol.Value {
+	name := client.Name()
+	if name == "" {
+		return protocol.Value{
+			Type:   protocol.BulkString,
+			IsNull: true,
+		}
+	}
+
+	return protocol.Value{
+		Type: protocol.BulkString,
+		Bulk: name,
+	}
+}
+
+func (e *Executor) clientList(client *Client, cmd *Command) protocol.Value {
+	var ids map[int64]bool
+	if len(cmd.Args) > 1 {
+		if strings.ToUpper(cmd.Args[1]) != "ID" || len(cmd.Args) < 3 {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR syntax error",
+			}
+		}
+
+		ids = make(map[int64]bool)
+		for _, arg := range cmd.Args[2:] {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil || id <= 0 {
+				return protocol.Value{
+					Type: protocol.Error,
+					Str:  "ERR Invalid client ID",
+				}
+			}
+			ids[id] = true
+		}
+	}
+
+	var sb strings.Builder
+	for _, c := range e.clients.List() {
+		if ids != nil && !ids[c.ID] {
+			continue
+		}
+		sb.WriteString(c.Info())
+		sb.WriteString("\n")
+	}
+
+	return protocol.Value{
+		Type: protocol.BulkString,
+		Bulk: sb.String(),
+	}
+}
+
+func (e *Executor) clientInfo(client *Client, cmd *Command) protocol.Value {
+	return protocol.Value{
+		Type: protocol.BulkString,
+		Bulk: client.Info() + "\n",
+	}
+}
+
+// clientKill supports both the legacy CLIENT KILL addr form, which replies
+// OK or an error, and the filter form, which replies with the number of
+// clients killed.
+func (e *Executor) clientKill(client *Client, cmd *Command) protocol.Value {
+	if len(cmd.Args) == 2 {
+		killed := e.clients.Kill(ClientFilter{Addr: cmd.Args[1]}, client)
+		if killed == 0 {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR No such client",
+			}
+		}
+		return protocol.Value{
+			Type: protocol.SimpleString,
+			Str:  "OK",
+		}
+	}
+
+	if len(cmd.Args) < 3 || len(cmd.Args)%2 != 1 {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR syntax error",
+		}
+	}
+
+	filter := ClientFilter{Skip: client}
+	for i := 1; i < len(cmd.Args); i += 2 {
+		value := cmd.Args[i+1]
+		switch strings.ToUpper(cmd.Args[i]) {
+		case "ID":
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				return protocol.Value{
+					Type: protocol.Error,
+					Str:  "ERR client-id should be greater than 0",
+				}
+			}
+			filter.ID = id
+		case "ADDR":
+			filter.Addr = value
+		case "LADDR":
+			filter.LocalAddr = value
+		case "USER":
+			filter.User = value
+		case "SKIPME":
+			switch strings.ToLower(value) {
+			case "yes":
+				filter.Skip = client
+			case "no":
+				filter.Skip = nil
+			default:
+				return protocol.Value{
+					Type: protocol.Error,
+					Str:  "ERR syntax error",
+				}
+			}
+		default:
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR syntax error",
+			}
+		}
+	}
+
+	return protocol.Value{
+		Type: protocol.Integer,
+		Num:  e.clients.Kill(filter, client),
+	}
+}
+
+func wrongSubcommandArgs(name string) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Error,
+		Str:  "ERR wrong number of arguments for '" + name + "' command",
+	}
+}
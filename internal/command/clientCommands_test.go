@@ -0,0 +1,115 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"net"
+	"strings"
+	"testing"
+)
+
+func registerPipeClient(t *testing.T, registry *ClientRegistry) (*Client, net.Conn) {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	t.Cleanup(func() {
+		serverConn.Close()
+		clientConn.Close()
+	})
+
+	client, err := registry.Register(serverConn)
+	if err != nil {
+		t.Fatalf("Failed to register client: %v", err)
+	}
+	return client, clientConn
+}
+
+func TestClientCommands(t *testing.T) {
+	registry := NewClientRegistry(0)
+	executor := NewExecutor(storage.NewMemoryStorage(), registry)
+
+	first, _ := registerPipeClient(t, registry)
+	second, _ := registerPipeClient(t, registry)
+
+	expectInteger(t, executor.Execute(first, &Command{Name: "CLIENT", Args: []string{"ID"}}), int(first.ID))
+
+	expectNull(t, executor.Execute(first, &Command{Name: "CLIENT", Args: []string{"GETNAME"}}))
+
+	reply := executor.Execute(first, &Command{Name: "CLIENT", Args: []string{"SETNAME", "worker-1"}})
+	if reply.Type != protocol.SimpleString || reply.Str != "OK" {
+		t.Errorf("CLIENT SETNAME: expected OK, got %+v", reply)
+	}
+	expectBulk(t, executor.Execute(first, &Command{Name: "CLIENT", Args: []string{"GETNAME"}}), "worker-1")
+
+	reply = executor.Execute(first, &Command{Name: "CLIENT", Args: []string{"SETNAME", "bad name"}})
+	if reply.Type != protocol.Error {
+		t.Errorf("CLIENT SETNAME with a space should fail, got %+v", reply)
+	}
+
+	reply = executor.Execute(second, &Command{Name: "CLIENT", Args: []string{"LIST"}})
+	lines := strings.Split(strings.TrimSuffix(reply.Bulk, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("CLIENT LIST: expected 2 clients, got %q", reply.Bulk)
+	}
+	if !strings.Contains(lines[0], "name=worker-1") || !strings.Contains(lines[0], "cmd=client|setname") {
+		t.Errorf("CLIENT LIST: unexpected first entry %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "cmd=client|list") {
+		t.Errorf("CLIENT LIST: unexpected second entry %q", lines[1])
+	}
+
+	reply = executor.Execute(second, &Command{Name: "CLIENT", Args: []string{"INFO"}})
+	if !strings.HasPrefix(reply.Bulk, "id=") || !strings.Contains(reply.Bulk, "cmd=client|info") {
+		t.Errorf("CLIENT INFO: unexpected %q", reply.Bulk)
+	}
+}
+
+func TestClientKill(t *testing.T) {
+	registry := NewClientRegistry(0)
+	executor := NewExecutor(storage.NewMemoryStorage(), registry)
+
+	caller, _ := registerPipeClient(t, registry)
+	target, targetConn := registerPipeClient(t, registry)
+
+	// SKIPME defaults to yes, so only the target is killed.
+	expectInteger(t, executor.Execute(caller, &Command{Name: "CLIENT", Args: []string{"KILL", "USER", "default"}}), 1)
+	if caller.ShouldClose() {
+		t.Error("Caller should not be killed when SKIPME is yes")
+	}
+
+	if _, err := targetConn.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected the killed client's connection to be closed")
+	}
+	registry.Unregister(target)
+
+	reply := executor.Execute(caller, &Command{Name: "CLIENT", Args: []string{"KILL", "127.0.0.1:1"}})
+	if reply.Type != protocol.Error || reply.Str != "ERR No such client" {
+		t.Errorf("CLIENT KILL unknown addr: unexpected %+v", reply)
+	}
+
+	expectInteger(t, executor.Execute(caller, &Command{Name: "CLIENT", Args: []string{"KILL", "ID", "999"}}), 0)
+
+	expectInteger(t, executor.Execute(caller, &Command{Name: "CLIENT", Args: []string{"KILL", "SKIPME", "no"}}), 1)
+	if !caller.ShouldClose() {
+		t.Error("Caller should be closed after killing itself")
+	}
+}
+
+func TestClientRegistryMaxClients(t *testing.T) {
+	registry := NewClientRegistry(1)
+
+	first, _ := registerPipeClient(t, registry)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	if _, err := registry.Register(serverConn); err != ErrMaxClients {
+		t.Errorf("Expected ErrMaxClients, got %v", err)
+	}
+
+	registry.Unregister(first)
+	if _, err := registry.Register(serverConn); err != nil {
+		t.Errorf("Expected registration to succeed after unregister, got %v", err)
+	}
+}
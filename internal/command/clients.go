@@ -0,0 +1,444 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"ivanSaichkin/myredis/internal/protocol"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrMaxClients = errors.New("max number of clients reached")
+
+// Client holds the per-connection state shared between the connection
+// handler and the commands that inspect or manage connections.
+type Client struct {
+	ID        int64
+	Addr      string
+	LocalAddr string
+	CreatedAt time.Time
+
+	conn     net.Conn
+	registry *ClientRegistry
+
+	// outputBytes counts the bytes of replies reserved with ReserveOutput
+	// and not yet written.
+	outputBytes atomic.Int64
+
+	mu              sync.Mutex
+	name            string
+	user            string
+	db              int
+	lastCmd         string
+	lastInteraction time.Time
+	closeAfterReply bool
+	closeReason     string
+	// softLimitSince is when the output buffer went above the soft limit.
+	softLimitSince time.Time
+
+	// channels and patterns are the client's Pub/Sub subscriptions.
+	// messages is created on the first subscription; from then on every
+	// reply is delivered through it so replies and messages stay ordered.
+	channels map[string]bool
+	patterns map[string]bool
+	messages chan protocol.Value
+
+	// monitor is set by MONITOR; the feed is delivered through messages.
+	monitor bool
+}
+
+func newClient(id int64, conn net.Conn) *Client {
+	now := time.Now()
+	c := &Client{
+		ID:              id,
+		CreatedAt:       now,
+		conn:            conn,
+		lastInteraction: now,
+	}
+	if conn != nil {
+		c.Addr = conn.RemoteAddr().String()
+		c.LocalAddr = conn.LocalAddr().String()
+		// Unix socket peers are unnamed; report them as <socket path>:0.
+		if c.Addr == "" || c.Addr == "@" {
+			c.Addr = c.LocalAddr + ":0"
+		}
+	}
+	return c
+}
+
+func (c *Client) Name() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.name
+}
+
+func (c *Client) SetName(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.name = name
+}
+
+func (c *Client) DB() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.db
+}
+
+// User returns the name of the user the connection is authenticated as.
+// Connections that have not authenticated run as the default user.
+func (c *Client) User() string {
+	if user := c.authUser(); user != "" {
+		return user
+	}
+	return DefaultUser
+}
+
+// authUser returns the user set by AUTH, empty if the client has not
+// authenticated.
+func (c *Client) authUser() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.user
+}
+
+func (c *Client) setUser(user string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.user = user
+}
+
+func (c *Client) Idle() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Since(c.lastInteraction)
+}
+
+// touch records that cmdName is being executed on behalf of the client.
+func (c *Client) touch(cmdName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastCmd = cmdName
+	c.lastInteraction = time.Now()
+}
+
+// ShouldClose reports whether the connection must be closed once the
+// pending reply has been written, e.g. after CLIENT KILL targeted itself.
+func (c *Client) ShouldClose() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeAfterReply
+}
+
+func (c *Client) markCloseAfterReply() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeAfterReply = true
+}
+
+func (c *Client) addSubscription(name string, pattern bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.messages == nil {
+		c.messages = make(chan protocol.Value, messageQueueSize)
+	}
+	if pattern {
+		if c.patterns == nil {
+			c.patterns = make(map[string]bool)
+		}
+		c.patterns[name] = true
+	} else {
+		if c.channels == nil {
+			c.channels = make(map[string]bool)
+		}
+		c.channels[name] = true
+	}
+}
+
+func (c *Client) removeSubscription(name string, pattern bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pattern {
+		delete(c.patterns, name)
+	} else {
+		delete(c.channels, name)
+	}
+}
+
+func (c *Client) subscriptions() (channels, patterns []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for channel := range c.channels {
+		channels = append(channels, channel)
+	}
+	for pattern := range c.patterns {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(channels)
+	sort.Strings(patterns)
+	return channels, patterns
+}
+
+// SubscriptionCount returns the number of subscribed channels and
+// patterns. A client with subscriptions is in subscriber mode.
+func (c *Client) SubscriptionCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.channels) + len(c.patterns)
+}
+
+// Messages returns the queue of replies, Pub/Sub messages and MONITOR
+// feed lines waiting to be written to the connection, or nil if the
+// client never subscribed or ran MONITOR.
+func (c *Client) Messages() <-chan protocol.Value {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.messages
+}
+
+// Push queues v for delivery through Messages; the delivery releases its
+// output bytes once written. A client whose queue is full or whose output
+// buffer limit is exceeded is disconnected and false is returned.
+func (c *Client) Push(v protocol.Value) bool {
+	c.mu.Lock()
+	if c.messages == nil {
+		c.messages = make(chan protocol.Value, messageQueueSize)
+	}
+	messages := c.messages
+	c.mu.Unlock()
+
+	size := int64(protocol.EncodedLen(v))
+	if !c.ReserveOutput(size) {
+		return false
+	}
+	select {
+	case messages <- v:
+		return true
+	default:
+		c.ReleaseOutput(size)
+		c.DisconnectForOutputLimit(fmt.Sprintf("output queue of %d messages is full", messageQueueSize))
+		return false
+	}
+}
+
+// IsMonitor reports whether the client receives the MONITOR feed.
+func (c *Client) IsMonitor() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.monitor
+}
+
+func (c *Client) setMonitor() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.monitor = true
+}
+
+// InterruptRead unblocks a pending read on the connection so the handler
+// can observe a server shutdown.
+func (c *Client) InterruptRead() {
+	if c.conn != nil {
+		c.conn.SetReadDeadline(time.Now())
+	}
+}
+
+// Close closes the underlying connection; the connection handler notices
+// the failed read and unregisters the client.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Info formats the client the way CLIENT LIST and CLIENT INFO report it.
+func (c *Client) Info() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	user := c.user
+	if user == "" {
+		user = DefaultUser
+	}
+
+	now := time.Now()
+	return fmt.Sprintf("id=%d addr=%s laddr=%s name=%s age=%d idle=%d db=%d sub=%d psub=%d omem=%d cmd=%s user=%s",
+		c.ID,
+		c.Addr,
+		c.LocalAddr,
+		c.name,
+		int(now.Sub(c.CreatedAt)/time.Second),
+		int(now.Sub(c.lastInteraction)/time.Second),
+		c.db,
+		len(c.channels),
+		len(c.patterns),
+		c.outputBytes.Load(),
+		c.lastCmd,
+		user,
+	)
+}
+
+type ClientRegistry struct {
+	mu         sync.RWMutex
+	clients    map[int64]*Client
+	nextID     int64
+	maxClients int
+	rejected   int64
+	// statsBase is nextID when the statistics were last reset.
+	statsBase int64
+
+	outputLimits              [ClientReplica + 1]OutputBufferLimit
+	outputLimitDisconnections atomic.Int64
+}
+
+// NewClientRegistry creates a registry accepting at most maxClients
+// connections; zero means no limit.
+func NewClientRegistry(maxClients int) *ClientRegistry {
+	return &ClientRegistry{
+		clients:    make(map[int64]*Client),
+		maxClients: maxClients,
+	}
+}
+
+func (r *ClientRegistry) Register(conn net.Conn) (*Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxClients > 0 && len(r.clients) >= r.maxClients {
+		r.rejected++
+		return nil, ErrMaxClients
+	}
+
+	r.nextID++
+	client := newClient(r.nextID, conn)
+	client.registry = r
+	r.clients[client.ID] = client
+	return client, nil
+}
+
+func (r *ClientRegistry) Unregister(client *Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, client.ID)
+}
+
+func (r *ClientRegistry) Get(id int64) (*Client, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	client, ok := r.clients[id]
+	return client, ok
+}
+
+// List returns the connected clients ordered by id.
+func (r *ClientRegistry) List() []*Client {
+	r.mu.RLock()
+	clients := make([]*Client, 0, len(r.clients))
+	for _, client := range r.clients {
+		clients = append(clients, client)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+	return clients
+}
+
+func (r *ClientRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.clients)
+}
+
+func (r *ClientRegistry) MaxClients() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.maxClients
+}
+
+// SetMaxClients changes the client limit; connected clients above the new
+// limit are not disconnected.
+func (r *ClientRegistry) SetMaxClients(maxClients int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxClients = maxClients
+}
+
+// TotalConnections returns the number of connections registered since the
+// registry was created or its statistics were reset.
+func (r *ClientRegistry) TotalConnections() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nextID - r.statsBase
+}
+
+// RejectedConnections returns the number of connections refused because
+// the client limit was reached.
+func (r *ClientRegistry) RejectedConnections() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rejected
+}
+
+// ResetStats resets the connection counters.
+func (r *ClientRegistry) ResetStats() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rejected = 0
+	r.statsBase = r.nextID
+	r.outputLimitDisconnections.Store(0)
+}
+
+// ClientFilter selects clients for CLIENT KILL. Empty fields match any
+// client.
+type ClientFilter struct {
+	ID        int64
+	Addr      string
+	LocalAddr string
+	User      string
+	// Skip is never matched, used to implement SKIPME.
+	Skip *Client
+}
+
+func (f ClientFilter) matches(c *Client) bool {
+	if c == f.Skip {
+		return false
+	}
+	if f.ID != 0 && c.ID != f.ID {
+		return false
+	}
+	if f.Addr != "" && c.Addr != f.Addr {
+		return false
+	}
+	if f.LocalAddr != "" && c.LocalAddr != f.LocalAddr {
+		return false
+	}
+	if f.User != "" && c.User() != f.User {
+		return false
+	}
+	return true
+}
+
+// Kill closes every client matching filter and returns how many were
+// closed. The caller's own connection is closed after its reply is sent.
+func (r *ClientRegistry) Kill(filter ClientFilter, caller *Client) int {
+	killed := 0
+	for _, client := range r.List() {
+		if !filter.matches(client) {
+			continue
+		}
+
+		if client == caller {
+			client.markCloseAfterReply()
+		} else {
+			client.Close()
+		}
+		killed++
+	}
+	return killed
+}
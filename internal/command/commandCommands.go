@@ -0,0 +1,187 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"sort"
+	"strings"
+)
+
+// sortedCommands returns the top-level commands ordered by name.
+func sortedCommands() []*commandSpec {
+	var specs []*commandSpec
+	for name, spec := range commandTable {
+		if !strings.Contains(name, "|") {
+			specs = append(specs, spec)
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].name < specs[j].name
+	})
+	return specs
+}
+
+// namedCommands returns the specs named by names, nil for unknown ones, or
+// all top-level commands when names is empty.
+func namedCommands(names []string) []*commandSpec {
+	if len(names) == 0 {
+		return sortedCommands()
+	}
+
+	specs := make([]*commandSpec, len(names))
+	for i, name := range names {
+		specs[i] = commandTable[strings.ToLower(name)]
+	}
+	return specs
+}
+
+// infoReply describes spec in the format of Redis' COMMAND INFO.
+func (s *commandSpec) infoReply() protocol.Value {
+	categories := make([]string, len(s.categories))
+	for i, category := range s.categories {
+		categories[i] = "@" + category
+	}
+
+	subcommands := make([]protocol.Value, len(s.subcommands))
+	for i, sub := range s.subcommands {
+		subcommands[i] = sub.infoReply()
+	}
+
+	return protocol.Value{
+		Type: protocol.Array,
+		Array: []protocol.Value{
+			{Type: protocol.BulkString, Bulk: s.name},
+			{Type: protocol.Integer, Num: s.arity},
+			statusArray(s.flagNames()),
+			{Type: protocol.Integer, Num: s.firstKey},
+			{Type: protocol.Integer, Num: s.lastKey},
+			{Type: protocol.Integer, Num: s.keyStep},
+			bulkArray(categories),
+			{Type: protocol.Array, Array: []protocol.Value{}},
+			{Type: protocol.Array, Array: []protocol.Value{}},
+			{Type: protocol.Array, Array: subcommands},
+		},
+	}
+}
+
+// docsReply describes spec as a flattened map in the format of Redis'
+// COMMAND DOCS.
+func (s *commandSpec) docsReply() protocol.Value {
+	docs := []protocol.Value{
+		{Type: protocol.BulkString, Bulk: "summary"},
+		{Type: protocol.BulkString, Bulk: s.summary},
+		{Type: protocol.BulkString, Bulk: "group"},
+		{Type: protocol.BulkString, Bulk: s.group},
+	}
+
+	if len(s.subcommands) > 0 {
+		subcommands := make([]protocol.Value, 0, 2*len(s.subcommands))
+		for _, sub := range s.subcommands {
+			subcommands = append(subcommands,
+				protocol.Value{Type: protocol.BulkString, Bulk: sub.name},
+				sub.docsReply(),
+			)
+		}
+		docs = append(docs,
+			protocol.Value{Type: protocol.BulkString, Bulk: "subcommands"},
+			protocol.Value{Type: protocol.Array, Array: subcommands},
+		)
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: docs,
+	}
+}
+
+func statusArray(items []string) protocol.Value {
+	array := make([]protocol.Value, len(items))
+	for i, item := range items {
+		array[i] = protocol.Value{
+			Type: protocol.SimpleString,
+			Str:  item,
+		}
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: array,
+	}
+}
+
+// Server commands
+
+func (e *Executor) commandInfoAll(cmd *Command) protocol.Value {
+	return e.commandInfo(&Command{Name: cmd.Name, Args: []string{"INFO"}})
+}
+
+func (e *Executor) commandInfo(cmd *Command) protocol.Value {
+	specs := namedCommands(cmd.Args[1:])
+	result := make([]protocol.Value, len(specs))
+	for i, spec := range specs {
+		if spec == nil {
+			result[i] = protocol.Value{
+				Type:   protocol.Array,
+				IsNull: true,
+			}
+			continue
+		}
+		result[i] = spec.infoReply()
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: result,
+	}
+}
+
+func (e *Executor) commandCount(cmd *Command) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Integer,
+		Num:  len(sortedCommands()),
+	}
+}
+
+// commandDocs omits unknown commands, like Redis does.
+func (e *Executor) commandDocs(cmd *Command) protocol.Value {
+	var result []protocol.Value
+	for _, spec := range namedCommands(cmd.Args[1:]) {
+		if spec == nil {
+			continue
+		}
+		result = append(result,
+			protocol.Value{Type: protocol.BulkString, Bulk: spec.name},
+			spec.docsReply(),
+		)
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: result,
+	}
+}
+
+func (e *Executor) commandGetKeys(cmd *Command) protocol.Value {
+	target := &Command{
+		Name: strings.ToUpper(cmd.Args[1]),
+		Args: cmd.Args[2:],
+	}
+
+	spec := lookupCommand(target)
+	if spec == nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR Invalid command specified",
+		}
+	}
+	if !spec.checkArity(target.Args) {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR Invalid number of arguments specified for command",
+		}
+	}
+
+	keys := spec.keys(target.Args)
+	if len(keys) == 0 {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR The command has no key arguments",
+		}
+	}
+	return bulkArray(keys)
+}
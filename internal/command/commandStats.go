@@ -0,0 +1,139 @@
+package command
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the command latency histogram.
+// Calls slower than the last bound are counted in an extra overflow
+// bucket.
+var LatencyBuckets = []time.Duration{
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// CommandStats is the call count and latency distribution of a command.
+type CommandStats struct {
+	Name        string
+	Calls       int64
+	FailedCalls int64
+	Duration    time.Duration
+	// Buckets holds the number of calls per LatencyBuckets bound, not
+	// cumulative, followed by the overflow bucket.
+	Buckets []int64
+}
+
+type commandMetrics struct {
+	calls    atomic.Int64
+	failed   atomic.Int64
+	duration atomic.Int64
+	buckets  []atomic.Int64
+}
+
+// commandStatsTable records command calls by command name.
+type commandStatsTable struct {
+	mu       sync.RWMutex
+	commands map[string]*commandMetrics
+}
+
+func newCommandStatsTable() *commandStatsTable {
+	return &commandStatsTable{
+		commands: make(map[string]*commandMetrics),
+	}
+}
+
+func (t *commandStatsTable) record(name string, duration time.Duration, failed bool) {
+	t.mu.RLock()
+	metrics, ok := t.commands[name]
+	t.mu.RUnlock()
+
+	if !ok {
+		t.mu.Lock()
+		if metrics, ok = t.commands[name]; !ok {
+			metrics = &commandMetrics{buckets: make([]atomic.Int64, len(LatencyBuckets)+1)}
+			t.commands[name] = metrics
+		}
+		t.mu.Unlock()
+	}
+
+	metrics.calls.Add(1)
+	if failed {
+		metrics.failed.Add(1)
+	}
+	metrics.duration.Add(int64(duration))
+	bucket := sort.Search(len(LatencyBuckets), func(i int) bool {
+		return duration <= LatencyBuckets[i]
+	})
+	metrics.buckets[bucket].Add(1)
+}
+
+func (t *commandStatsTable) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.commands = make(map[string]*commandMetrics)
+}
+
+func (t *commandStatsTable) snapshot() []CommandStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	stats := make([]CommandStats, 0, len(t.commands))
+	for name, metrics := range t.commands {
+		buckets := make([]int64, len(metrics.buckets))
+		for i := range metrics.buckets {
+			buckets[i] = metrics.buckets[i].Load()
+		}
+		stats = append(stats, CommandStats{
+			Name:        name,
+			Calls:       metrics.calls.Load(),
+			FailedCalls: metrics.failed.Load(),
+			Duration:    time.Duration(metrics.duration.Load()),
+			Buckets:     buckets,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// CommandStats returns the statistics of every command called so far,
+// ordered by name.
+func (e *Executor) CommandStats() []CommandStats {
+	return e.commandStats.snapshot()
+}
+
+// ExpiredKeys returns the number of keys removed because they expired.
+func (e *Executor) ExpiredKeys() int64 {
+	return e.stats.expiredKeys.Load()
+}
+
+func (e *Executor) StartTime() time.Time {
+	return e.startTime
+}
+
+// statsName returns the name cmd is recorded under: its subcommand name if
+// that is a known command, so that arbitrary subcommands cannot create
+// unbounded numbers of entries.
+func statsName(cmd *Command) string {
+	if name := cmd.FullName(); name != strings.ToLower(cmd.Name) {
+		if _, known := commandTable[name]; known {
+			return name
+		}
+	}
+	return strings.ToLower(cmd.Name)
+}
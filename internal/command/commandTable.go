@@ -0,0 +1,453 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"sort"
+	"strings"
+)
+
+type commandFlag int
+
+const (
+	flagWrite commandFlag = 1 << iota
+	flagReadOnly
+	flagAdmin
+	flagFast
+	flagBlocking
+)
+
+var commandFlagNames = []struct {
+	flag commandFlag
+	name string
+}{
+	{flagWrite, "write"},
+	{flagReadOnly, "readonly"},
+	{flagAdmin, "admin"},
+	{flagFast, "fast"},
+	{flagBlocking, "blocking"},
+}
+
+type commandHandler func(e *Executor, client *Client, cmd *Command) protocol.Value
+
+// commandSpec describes a command: how it is validated and dispatched,
+// the ACL categories it belongs to and the positions of its key arguments.
+//
+// arity counts the command name and follows Redis: a positive arity is
+// the exact number of arguments, a negative one the minimum. For
+// subcommands the name is "container|subcommand" and the arity counts both.
+//
+// Key positions are 1-based indexes into the arguments following the
+// command name; a lastKey of -1 means the last argument and a zero
+// firstKey means the command takes no keys.
+type commandSpec struct {
+	name     string
+	arity    int
+	flags    commandFlag
+	group    string
+	summary  string
+	firstKey int
+	lastKey  int
+	keyStep  int
+
+	// categories are the ACL categories the flags do not imply.
+	categories []string
+
+	handler commandHandler
+	// validate checks the arguments beyond the arity.
+	validate func(v *Validator, cmd *Command) error
+
+	subcommands []*commandSpec
+
+	// protected commands are refused unless enable-debug-command allows
+	// the client. exclusive commands run while no other command does.
+	protected bool
+	exclusive bool
+}
+
+// commandTable is keyed by the lowercase command name, or by
+// "container|subcommand" for subcommands.
+var commandTable = make(map[string]*commandSpec)
+
+var commands = []*commandSpec{
+	// String commands
+	{name: "set", arity: -3, flags: flagWrite, group: "string", summary: "Sets the string value of a key, optionally with a time to live.",
+		categories: []string{"string"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).set)},
+	{name: "get", arity: 2, flags: flagReadOnly | flagFast, group: "string", summary: "Returns the string value of a key.",
+		categories: []string{"string"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).get)},
+
+	// Generic commands
+	{name: "del", arity: -2, flags: flagWrite, group: "generic", summary: "Deletes one or more keys.",
+		categories: []string{"keyspace"}, firstKey: 1, lastKey: -1, keyStep: 1, handler: withCmd((*Executor).del)},
+	{name: "unlink", arity: -2, flags: flagWrite | flagFast, group: "generic", summary: "Deletes one or more keys. An alias of DEL.",
+		categories: []string{"keyspace"}, firstKey: 1, lastKey: -1, keyStep: 1, handler: withCmd((*Executor).unlink)},
+	{name: "exists", arity: -2, flags: flagReadOnly | flagFast, group: "generic", summary: "Counts the given keys that exist.",
+		categories: []string{"keyspace"}, firstKey: 1, lastKey: -1, keyStep: 1, handler: withCmd((*Executor).exists)},
+	{name: "expire", arity: 3, flags: flagWrite | flagFast, group: "generic", summary: "Sets the time to live of a key in seconds.",
+		categories: []string{"keyspace"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).expire), validate: (*Validator).validateExpire},
+	{name: "ttl", arity: 2, flags: flagReadOnly | flagFast, group: "generic", summary: "Returns the time to live of a key in seconds.",
+		categories: []string{"keyspace"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).ttl)},
+	{name: "type", arity: 2, flags: flagReadOnly | flagFast, group: "generic", summary: "Returns the type of the value stored at a key.",
+		categories: []string{"keyspace"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).keyType)},
+	{name: "keys", arity: 2, flags: flagReadOnly, group: "generic", summary: "Returns the keys matching a pattern.",
+		categories: []string{"keyspace", "dangerous"}, handler: withCmd((*Executor).keys)},
+
+	// Hash commands
+	{name: "hset", arity: -4, flags: flagWrite | flagFast, group: "hash", summary: "Sets fields of a hash.",
+		categories: []string{"hash"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).hset), validate: (*Validator).validateHSet},
+	{name: "hget", arity: 3, flags: flagReadOnly | flagFast, group: "hash", summary: "Returns the value of a hash field.",
+		categories: []string{"hash"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).hget)},
+	{name: "hdel", arity: -3, flags: flagWrite | flagFast, group: "hash", summary: "Deletes fields of a hash.",
+		categories: []string{"hash"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).hdel)},
+	{name: "hexists", arity: 3, flags: flagReadOnly | flagFast, group: "hash", summary: "Determines whether a hash field exists.",
+		categories: []string{"hash"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).hexists)},
+	{name: "hgetall", arity: 2, flags: flagReadOnly, group: "hash", summary: "Returns all fields and values of a hash.",
+		categories: []string{"hash"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).hgetall)},
+	{name: "hkeys", arity: 2, flags: flagReadOnly, group: "hash", summary: "Returns all fields of a hash.",
+		categories: []string{"hash"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).hkeys)},
+	{name: "hlen", arity: 2, flags: flagReadOnly | flagFast, group: "hash", summary: "Returns the number of fields in a hash.",
+		categories: []string{"hash"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).hlen)},
+
+	// List commands
+	{name: "lpush", arity: -3, flags: flagWrite | flagFast, group: "list", summary: "Prepends elements to a list.",
+		categories: []string{"list"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).lpush)},
+	{name: "rpush", arity: -3, flags: flagWrite | flagFast, group: "list", summary: "Appends elements to a list.",
+		categories: []string{"list"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).rpush)},
+	{name: "lpop", arity: 2, flags: flagWrite | flagFast, group: "list", summary: "Removes and returns the first element of a list.",
+		categories: []string{"list"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).lpop)},
+	{name: "rpop", arity: 2, flags: flagWrite | flagFast, group: "list", summary: "Removes and returns the last element of a list.",
+		categories: []string{"list"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).rpop)},
+	{name: "llen", arity: 2, flags: flagReadOnly | flagFast, group: "list", summary: "Returns the length of a list.",
+		categories: []string{"list"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).llen)},
+	{name: "lrange", arity: 4, flags: flagReadOnly, group: "list", summary: "Returns a range of elements from a list.",
+		categories: []string{"list"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).lrange), validate: (*Validator).validateLRange},
+
+	// Set commands
+	{name: "sadd", arity: -3, flags: flagWrite | flagFast, group: "set", summary: "Adds members to a set.",
+		categories: []string{"set"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).sadd)},
+	{name: "srem", arity: -3, flags: flagWrite | flagFast, group: "set", summary: "Removes members from a set.",
+		categories: []string{"set"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).srem)},
+	{name: "sismember", arity: 3, flags: flagReadOnly | flagFast, group: "set", summary: "Determines whether a member belongs to a set.",
+		categories: []string{"set"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).sismember)},
+	{name: "smembers", arity: 2, flags: flagReadOnly, group: "set", summary: "Returns all members of a set.",
+		categories: []string{"set"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).smembers)},
+	{name: "scard", arity: 2, flags: flagReadOnly | flagFast, group: "set", summary: "Returns the number of members in a set.",
+		categories: []string{"set"}, firstKey: 1, lastKey: 1, keyStep: 1, handler: withCmd((*Executor).scard)},
+	{name: "sinter", arity: -2, flags: flagReadOnly, group: "set", summary: "Returns the intersection of sets.",
+		categories: []string{"set"}, firstKey: 1, lastKey: -1, keyStep: 1, handler: withCmd((*Executor).sinter)},
+
+	// Connection commands
+	{name: "ping", arity: -1, flags: flagFast, group: "connection", summary: "Returns PONG, or the given message.",
+		categories: []string{"connection"}, handler: (*Executor).ping, validate: (*Validator).validatePing},
+	{name: "auth", arity: -2, flags: flagFast, group: "connection", summary: "Authenticates the connection.",
+		categories: []string{"connection"}, handler: (*Executor).auth, validate: (*Validator).validateAuth},
+	{name: "client", arity: -2, group: "connection", summary: "A container for client connection commands.",
+		categories: []string{"connection"}, subcommands: []*commandSpec{
+			{name: "client|id", arity: 2, group: "connection", summary: "Returns the unique ID of the connection.",
+				categories: []string{"connection"}, handler: withConnection((*Executor).clientID)},
+			{name: "client|setname", arity: 3, group: "connection", summary: "Sets the connection name.",
+				categories: []string{"connection"}, handler: withConnection((*Executor).clientSetName)},
+			{name: "client|getname", arity: 2, group: "connection", summary: "Returns the connection name.",
+				categories: []string{"connection"}, handler: withConnection((*Executor).clientGetName)},
+			{name: "client|list", arity: -2, flags: flagAdmin, group: "connection", summary: "Lists the open connections.",
+				categories: []string{"connection"}, handler: withConnection((*Executor).clientList)},
+			{name: "client|info", arity: 2, group: "connection", summary: "Returns information about the connection.",
+				categories: []string{"connection"}, handler: withConnection((*Executor).clientInfo)},
+			{name: "client|kill", arity: -3, flags: flagAdmin, group: "connection", summary: "Terminates open connections.",
+				categories: []string{"connection"}, handler: withConnection((*Executor).clientKill)},
+		}},
+
+	// Pub/Sub commands
+	{name: "subscribe", arity: -2, group: "pubsub", summary: "Listens for messages published to channels.",
+		categories: []string{"pubsub"}, handler: (*Executor).subscribe},
+	{name: "unsubscribe", arity: -1, group: "pubsub", summary: "Stops listening to messages posted to channels.",
+		categories: []string{"pubsub"}, handler: (*Executor).unsubscribe},
+	{name: "psubscribe", arity: -2, group: "pubsub", summary: "Listens for messages published to channels matching patterns.",
+		categories: []string{"pubsub"}, handler: (*Executor).psubscribe},
+	{name: "punsubscribe", arity: -1, group: "pubsub", summary: "Stops listening to messages published to channels matching patterns.",
+		categories: []string{"pubsub"}, handler: (*Executor).punsubscribe},
+	{name: "publish", arity: 3, flags: flagFast, group: "pubsub", summary: "Posts a message to a channel.",
+		categories: []string{"pubsub"}, handler: withCmd((*Executor).publish)},
+	{name: "pubsub", arity: -2, group: "pubsub", summary: "A container for Pub/Sub introspection commands.",
+		categories: []string{"pubsub"}, subcommands: []*commandSpec{
+			{name: "pubsub|channels", arity: -2, group: "pubsub", summary: "Returns the active channels.",
+				categories: []string{"pubsub"}, handler: withCmd((*Executor).pubsubChannels)},
+			{name: "pubsub|numsub", arity: -2, group: "pubsub", summary: "Returns the subscriber counts of channels.",
+				categories: []string{"pubsub"}, handler: withCmd((*Executor).pubsubNumSub)},
+			{name: "pubsub|numpat", arity: 2, group: "pubsub", summary: "Returns the number of pattern subscriptions.",
+				categories: []string{"pubsub"}, handler: withCmd((*Executor).pubsubNumPat)},
+		}},
+
+	// Transaction commands
+	{name: "multi", arity: 1, flags: flagFast, group: "transactions", summary: "Starts a transaction.",
+		categories: []string{"transaction"}, handler: withCmd((*Executor).handledByConnection)},
+	{name: "exec", arity: 1, group: "transactions", summary: "Executes all commands in a transaction.",
+		categories: []string{"transaction"}, handler: withCmd((*Executor).handledByConnection)},
+	{name: "discard", arity: 1, flags: flagFast, group: "transactions", summary: "Discards a transaction.",
+		categories: []string{"transaction"}, handler: withCmd((*Executor).handledByConnection)},
+	{name: "watch", arity: -2, flags: flagFast, group: "transactions", summary: "Monitors changes to keys to determine the execution of a transaction.",
+		categories: []string{"transaction"}, firstKey: 1, lastKey: -1, keyStep: 1, handler: (*Executor).watch},
+	{name: "unwatch", arity: 1, flags: flagFast, group: "transactions", summary: "Forgets about watched keys of a transaction.",
+		categories: []string{"transaction"}, handler: (*Executor).unwatch},
+
+	// Server commands
+	{name: "flushdb", arity: -1, flags: flagWrite, group: "server", summary: "Removes all keys. ASYNC and SYNC are accepted and behave alike.",
+		categories: []string{"keyspace", "dangerous"}, handler: withCmd((*Executor).flush), validate: (*Validator).validateFlush},
+	{name: "flushall", arity: -1, flags: flagWrite, group: "server", summary: "Removes all keys from all databases. The same as FLUSHDB, as there is one database.",
+		categories: []string{"keyspace", "dangerous"}, handler: withCmd((*Executor).flush), validate: (*Validator).validateFlush},
+	{name: "clear", arity: 1, flags: flagWrite, group: "server", summary: "Removes all keys. An alias of FLUSHDB.",
+		categories: []string{"keyspace", "dangerous"}, handler: withCmd((*Executor).flush)},
+	{name: "save", arity: 1, flags: flagAdmin, group: "server", summary: "Synchronously saves a snapshot to disk.",
+		handler: withCmd((*Executor).save)},
+	{name: "bgsave", arity: 1, flags: flagAdmin, group: "server", summary: "Saves a snapshot to disk in the background.",
+		handler: withCmd((*Executor).bgsave)},
+	{name: "lastsave", arity: 1, flags: flagAdmin | flagFast, group: "server", summary: "Returns the Unix time of the last successful save.",
+		handler: withCmd((*Executor).lastsave)},
+	{name: "shutdown", arity: -1, flags: flagAdmin, group: "server", summary: "Saves a snapshot and shuts down the server.",
+		handler: (*Executor).shutdown, validate: (*Validator).validateShutdown},
+	{name: "info", arity: -1, group: "server", summary: "Returns information and statistics about the server.",
+		categories: []string{"dangerous"}, handler: withCmd((*Executor).info)},
+	{name: "monitor", arity: 1, flags: flagAdmin, group: "server", summary: "Streams the commands the server executes.",
+		handler: (*Executor).monitor},
+	{name: "slowlog", arity: -2, group: "server", summary: "A container for slow log commands.",
+		subcommands: []*commandSpec{
+			{name: "slowlog|get", arity: -2, flags: flagAdmin, group: "server", summary: "Returns the slow log's entries.",
+				handler: withCmd((*Executor).slowlogGet)},
+			{name: "slowlog|len", arity: 2, flags: flagAdmin, group: "server", summary: "Returns the number of entries in the slow log.",
+				handler: withCmd((*Executor).slowlogLen)},
+			{name: "slowlog|reset", arity: 2, flags: flagAdmin, group: "server", summary: "Clears all entries from the slow log.",
+				handler: withCmd((*Executor).slowlogReset)},
+		}},
+	{name: "hotkeys", arity: -2, group: "server", summary: "A container for hot key commands.",
+		subcommands: []*commandSpec{
+			{name: "hotkeys|get", arity: -2, flags: flagAdmin, group: "server", summary: "Returns the most accessed keys and their estimated access counts.",
+				handler: withCmd((*Executor).hotkeysGet)},
+			{name: "hotkeys|reset", arity: 2, flags: flagAdmin, group: "server", summary: "Forgets the sampled key accesses.",
+				handler: withCmd((*Executor).hotkeysReset)},
+		}},
+	{name: "bigkeys", arity: -2, group: "server", summary: "A container for big key commands.",
+		subcommands: []*commandSpec{
+			{name: "bigkeys|scan", arity: -2, flags: flagAdmin, group: "server", summary: "Starts measuring every key in the background.",
+				handler: withCmd((*Executor).bigkeysScan)},
+			{name: "bigkeys|report", arity: 2, flags: flagAdmin, group: "server", summary: "Returns the largest keys per type found by the latest scan.",
+				handler: withCmd((*Executor).bigkeysReport)},
+		}},
+	{name: "debug", arity: -2, group: "server", summary: "A container for debugging commands.",
+		protected: true, subcommands: []*commandSpec{
+			{name: "debug|populate", arity: -3, flags: flagWrite | flagAdmin, group: "server", summary: "Creates keys with generated values.",
+				handler: withCmd((*Executor).debugPopulate)},
+			{name: "debug|reload", arity: 2, flags: flagAdmin, group: "server", summary: "Saves a snapshot and loads it back.",
+				exclusive: true, handler: withCmd((*Executor).debugReload)},
+			{name: "debug|sleep", arity: 3, flags: flagAdmin, group: "server", summary: "Blocks the server for a number of seconds.",
+				exclusive: true, handler: withCmd((*Executor).debugSleep)},
+			{name: "debug|object", arity: 3, flags: flagAdmin, group: "server", summary: "Describes how the value of a key is stored.",
+				firstKey: 2, lastKey: 2, keyStep: 1, handler: withCmd((*Executor).debugObject)},
+			{name: "debug|set-active-expire", arity: 3, flags: flagAdmin, group: "server", summary: "Pauses or resumes the removal of expired keys in the background.",
+				handler: withCmd((*Executor).debugSetActiveExpire)},
+			{name: "debug|structsize", arity: 2, flags: flagAdmin, group: "server", summary: "Returns the sizes of internal structures.",
+				handler: withCmd((*Executor).debugStructSize)},
+			{name: "debug|digest", arity: 2, flags: flagAdmin, group: "server", summary: "Returns a digest of the whole keyspace.",
+				handler: withCmd((*Executor).digest)},
+			{name: "debug|digest-value", arity: -3, flags: flagAdmin, group: "server", summary: "Returns a digest of the value of each key.",
+				firstKey: 2, lastKey: -1, keyStep: 1, handler: withCmd((*Executor).debugDigestValue)},
+		}},
+	{name: "digest", arity: 1, flags: flagAdmin, group: "server", summary: "Returns a digest of the whole keyspace.",
+		categories: []string{"keyspace"}, handler: withCmd((*Executor).digest)},
+	{name: "acl", arity: -2, group: "server", summary: "A container for access list commands.",
+		subcommands: []*commandSpec{
+			{name: "acl|setuser", arity: -3, flags: flagAdmin, group: "server", summary: "Creates or modifies a user's rules.",
+				handler: withCmd((*Executor).aclSetUser)},
+			{name: "acl|getuser", arity: 3, flags: flagAdmin, group: "server", summary: "Returns the rules of a user.",
+				handler: withCmd((*Executor).aclGetUser)},
+			{name: "acl|deluser", arity: -3, flags: flagAdmin, group: "server", summary: "Deletes users and terminates their connections.",
+				handler: (*Executor).aclDelUser},
+			{name: "acl|list", arity: 2, flags: flagAdmin, group: "server", summary: "Dumps the effective rules in ACL file format.",
+				handler: withCmd((*Executor).aclList)},
+			{name: "acl|users", arity: 2, flags: flagAdmin, group: "server", summary: "Lists all users.",
+				handler: withCmd((*Executor).aclUsers)},
+			{name: "acl|whoami", arity: 2, group: "server", summary: "Returns the authenticated username of the connection.",
+				handler: (*Executor).aclWhoAmI},
+			{name: "acl|cat", arity: -2, group: "server", summary: "Lists the ACL categories, or the commands inside a category.",
+				handler: withCmd((*Executor).aclCat)},
+			{name: "acl|load", arity: 2, flags: flagAdmin, group: "server", summary: "Reloads the rules from the ACL file.",
+				handler: withCmd((*Executor).aclLoad)},
+			{name: "acl|save", arity: 2, flags: flagAdmin, group: "server", summary: "Saves the effective rules to the ACL file.",
+				handler: withCmd((*Executor).aclSave)},
+		}},
+	{name: "config", arity: -2, group: "server", summary: "A container for server configuration commands.",
+		subcommands: []*commandSpec{
+			{name: "config|get", arity: -3, flags: flagAdmin, group: "server", summary: "Returns the values of configuration parameters matching glob patterns.",
+				handler: withCmd((*Executor).configGet)},
+			{name: "config|set", arity: -4, flags: flagAdmin, group: "server", summary: "Sets configuration parameters at runtime.",
+				handler: withCmd((*Executor).configSet)},
+			{name: "config|rewrite", arity: 2, flags: flagAdmin, group: "server", summary: "Persists the running configuration to the config file.",
+				handler: withCmd((*Executor).configRewrite)},
+			{name: "config|resetstat", arity: 2, flags: flagAdmin, group: "server", summary: "Resets the server's statistics.",
+				handler: withCmd((*Executor).configResetStat)},
+		}},
+	{name: "command", arity: -1, group: "server", summary: "Returns detailed information about all commands.",
+		categories: []string{"connection"}, handler: withCmd((*Executor).commandInfoAll), subcommands: []*commandSpec{
+			{name: "command|count", arity: 2, group: "server", summary: "Returns a count of commands.",
+				categories: []string{"connection"}, handler: withCmd((*Executor).commandCount)},
+			{name: "command|info", arity: -2, group: "server", summary: "Returns information about one, multiple or all commands.",
+				categories: []string{"connection"}, handler: withCmd((*Executor).commandInfo)},
+			{name: "command|docs", arity: -2, group: "server", summary: "Returns documentary information about one, multiple or all commands.",
+				categories: []string{"connection"}, handler: withCmd((*Executor).commandDocs)},
+			{name: "command|getkeys", arity: -3, group: "server", summary: "Extracts the key names from an arbitrary command.",
+				categories: []string{"connection"}, handler: withCmd((*Executor).commandGetKeys)},
+		}},
+}
+
+func init() {
+	for _, spec := range commands {
+		registerCommand(spec)
+		for _, sub := range spec.subcommands {
+			registerCommand(sub)
+		}
+	}
+}
+
+// registerCommand adds spec to the command table together with the ACL
+// categories its flags imply.
+func registerCommand(spec *commandSpec) {
+	var implied []string
+	if spec.flags&flagWrite != 0 {
+		implied = append(implied, "write")
+	}
+	if spec.flags&flagReadOnly != 0 {
+		implied = append(implied, "read")
+	}
+	if spec.flags&flagAdmin != 0 {
+		implied = append(implied, "admin", "dangerous")
+	}
+	if spec.flags&flagBlocking != 0 {
+		implied = append(implied, "blocking")
+	}
+	// Containers are only categorized through their subcommands.
+	if spec.flags&flagFast != 0 {
+		implied = append(implied, "fast")
+	} else if spec.subcommands == nil {
+		implied = append(implied, "slow")
+	}
+
+	for _, category := range spec.categories {
+		if !inList(implied, category) {
+			implied = append(implied, category)
+		}
+	}
+	spec.categories = implied
+	commandTable[spec.name] = spec
+}
+
+// withCmd adapts a handler that does not need the calling client.
+func withCmd(handler func(*Executor, *Command) protocol.Value) commandHandler {
+	return func(e *Executor, _ *Client, cmd *Command) protocol.Value {
+		return handler(e, cmd)
+	}
+}
+
+// withConnection adapts a handler that cannot run without a client.
+func withConnection(handler commandHandler) commandHandler {
+	return func(e *Executor, client *Client, cmd *Command) protocol.Value {
+		if client == nil {
+			return connectionRequired(cmd)
+		}
+		return handler(e, client, cmd)
+	}
+}
+
+// lookupCommand returns the spec of cmd, or of its subcommand for
+// container commands. It returns nil for unknown commands and
+// subcommands.
+func lookupCommand(cmd *Command) *commandSpec {
+	spec := commandTable[strings.ToLower(cmd.Name)]
+	if spec == nil || spec.subcommands == nil || len(cmd.Args) == 0 {
+		return spec
+	}
+	return commandTable[cmd.FullName()]
+}
+
+// checkArity reports whether args, the arguments following the command
+// name, satisfy the arity of s. The subcommand name of a container command
+// is the first of args.
+func (s *commandSpec) checkArity(args []string) bool {
+	n := len(args) + 1
+	if s.arity < 0 {
+		return n >= -s.arity
+	}
+	return n == s.arity
+}
+
+func (s *commandSpec) flagNames() []string {
+	var names []string
+	for _, f := range commandFlagNames {
+		if s.flags&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+func (s *commandSpec) inCategory(category string) bool {
+	if category == "all" {
+		return true
+	}
+	return inList(s.categories, category)
+}
+
+// keys returns the key arguments of a command described by s.
+func (s *commandSpec) keys(args []string) []string {
+	var keys []string
+	for _, i := range s.keyIndexes(len(args)) {
+		keys = append(keys, args[i])
+	}
+	return keys
+}
+
+// keyIndexes returns the 0-based indexes of the key arguments among n
+// arguments following the command name.
+func (s *commandSpec) keyIndexes(n int) []int {
+	if s.firstKey == 0 || s.firstKey > n {
+		return nil
+	}
+
+	last := s.lastKey
+	if last < 0 || last > n {
+		last = n
+	}
+	step := s.keyStep
+	if step < 1 {
+		step = 1
+	}
+
+	var indexes []int
+	for i := s.firstKey; i <= last; i += step {
+		indexes = append(indexes, i-1)
+	}
+	return indexes
+}
+
+func inList(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// aclCategories returns the sorted names of all ACL categories.
+func aclCategories() []string {
+	seen := make(map[string]bool)
+	for _, spec := range commandTable {
+		for _, category := range spec.categories {
+			seen[category] = true
+		}
+	}
+
+	categories := make([]string, 0, len(seen))
+	for category := range seen {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
@@ -0,0 +1,103 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCommandTableConsistency(t *testing.T) {
+	for name, spec := range commandTable {
+		if name != strings.ToLower(name) || name != spec.name {
+			t.Errorf("%s: registered as %q", spec.name, name)
+		}
+		if spec.arity == 0 {
+			t.Errorf("%s: missing arity", name)
+		}
+		if spec.handler == nil && spec.subcommands == nil {
+			t.Errorf("%s: missing handler", name)
+		}
+		if spec.summary == "" || spec.group == "" {
+			t.Errorf("%s: missing docs", name)
+		}
+		for _, sub := range spec.subcommands {
+			if !strings.HasPrefix(sub.name, name+"|") {
+				t.Errorf("%s: subcommand %s is not qualified with its container", name, sub.name)
+			}
+		}
+	}
+}
+
+func TestCommandValidation(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+
+	expectErrorPrefix(t, execute(executor, nil, "GET"), "ERR wrong number of arguments for 'get' command")
+	expectErrorPrefix(t, execute(executor, nil, "SAVE", "now"), "ERR wrong number of arguments for 'save' command")
+	expectErrorPrefix(t, execute(executor, nil, "HSET", "h", "f", "v", "g"), "ERR wrong number of arguments for 'hset' command")
+	expectErrorPrefix(t, execute(executor, nil, "PING", "a", "b"), "ERR wrong number of arguments for 'ping' command")
+	expectErrorPrefix(t, execute(executor, nil, "EXPIRE", "k", "soon"), "ERR invalid integer")
+	expectErrorPrefix(t, execute(executor, nil, "SLOWLOG", "LEN", "x"), "ERR wrong number of arguments for 'slowlog|len' command")
+	expectErrorPrefix(t, execute(executor, nil, "SLOWLOG", "NOSUCH"), "ERR unknown subcommand 'NOSUCH'. Try SLOWLOG HELP.")
+	expectErrorPrefix(t, execute(executor, nil, "NOSUCH"), "ERR unknown command 'NOSUCH'")
+}
+
+func TestCommandCommand(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+
+	count := execute(executor, nil, "COMMAND", "COUNT")
+	all := execute(executor, nil, "COMMAND")
+	if count.Type != protocol.Integer || count.Num != len(all.Array) || count.Num < 50 {
+		t.Fatalf("COMMAND COUNT %+v does not match COMMAND's %d entries", count, len(all.Array))
+	}
+
+	reply := execute(executor, nil, "COMMAND", "INFO", "get", "nosuch", "client|kill")
+	if len(reply.Array) != 3 || !reply.Array[1].IsNull {
+		t.Fatalf("COMMAND INFO: unexpected reply %+v", reply)
+	}
+	get := reply.Array[0].Array
+	if get[0].Bulk != "get" || get[1].Num != 2 || get[3].Num != 1 || get[4].Num != 1 || get[5].Num != 1 {
+		t.Errorf("COMMAND INFO get: unexpected %+v", get)
+	}
+	if get[2].Array[0].Str != "readonly" || get[2].Array[1].Str != "fast" {
+		t.Errorf("COMMAND INFO get: unexpected flags %+v", get[2].Array)
+	}
+	if categories := bulkStrings(t, get[6]); !slices.Contains(categories, "@read") || !slices.Contains(categories, "@string") {
+		t.Errorf("COMMAND INFO get: unexpected categories %v", categories)
+	}
+	if kill := reply.Array[2].Array; kill[0].Bulk != "client|kill" || kill[1].Num != -3 {
+		t.Errorf("COMMAND INFO client|kill: unexpected %+v", kill)
+	}
+
+	reply = execute(executor, nil, "COMMAND", "INFO", "client")
+	if subcommands := reply.Array[0].Array[9].Array; len(subcommands) != 6 {
+		t.Errorf("COMMAND INFO client: expected 6 subcommands, got %d", len(subcommands))
+	}
+
+	docs := execute(executor, nil, "COMMAND", "DOCS", "set", "nosuch")
+	if len(docs.Array) != 2 || docs.Array[0].Bulk != "set" {
+		t.Fatalf("COMMAND DOCS: unexpected reply %+v", docs)
+	}
+	fields := bulkStrings(t, docs.Array[1])
+	if fields[0] != "summary" || fields[2] != "group" || fields[3] != "string" {
+		t.Errorf("COMMAND DOCS set: unexpected %v", fields)
+	}
+}
+
+func TestCommandGetKeys(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+
+	keys := bulkStrings(t, execute(executor, nil, "COMMAND", "GETKEYS", "SET", "k", "v", "EX", "10"))
+	if !slices.Equal(keys, []string{"k"}) {
+		t.Errorf("GETKEYS SET: got %v", keys)
+	}
+	keys = bulkStrings(t, execute(executor, nil, "COMMAND", "GETKEYS", "del", "a", "b", "c"))
+	if !slices.Equal(keys, []string{"a", "b", "c"}) {
+		t.Errorf("GETKEYS DEL: got %v", keys)
+	}
+
+	expectErrorPrefix(t, execute(executor, nil, "COMMAND", "GETKEYS", "PING", "x"), "ERR The command has no key arguments")
+	expectErrorPrefix(t, execute(executor, nil, "COMMAND", "GETKEYS", "GET", "a", "b"), "ERR Invalid number of arguments")
+	expectErrorPrefix(t, execute(executor, nil, "COMMAND", "GETKEYS", "NOSUCH", "a"), "ERR Invalid command specified")
+}
@@ -0,0 +1,114 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"strings"
+)
+
+// ConfigParam is a configuration parameter and its current value.
+type ConfigParam struct {
+	Name  string
+	Value string
+}
+
+// ConfigController is implemented by the server to let CONFIG read and
+// change the running configuration.
+type ConfigController interface {
+	// Config returns every parameter, ordered by name.
+	Config() []ConfigParam
+	// SetConfig validates and applies params; either all of them take
+	// effect or none does.
+	SetConfig(params []ConfigParam) error
+	// RewriteConfig writes the running configuration to the config file.
+	RewriteConfig() error
+}
+
+func (e *Executor) SetConfigController(controller ConfigController) {
+	e.configController = controller
+}
+
+// ResetStats resets the statistics reported by INFO and the metrics
+// endpoint.
+func (e *Executor) ResetStats() {
+	e.stats.reset()
+	e.commandStats.reset()
+	e.clients.ResetStats()
+}
+
+func configUnavailable() protocol.Value {
+	return protocol.Value{
+		Type: protocol.Error,
+		Str:  "ERR CONFIG is not available",
+	}
+}
+
+// Server commands
+
+func (e *Executor) configGet(cmd *Command) protocol.Value {
+	if e.configController == nil {
+		return configUnavailable()
+	}
+
+	var result []string
+	for _, param := range e.configController.Config() {
+		for _, pattern := range cmd.Args[1:] {
+			if globMatch(strings.ToLower(pattern), param.Name) {
+				result = append(result, param.Name, param.Value)
+				break
+			}
+		}
+	}
+	return bulkArray(result)
+}
+
+func (e *Executor) configSet(cmd *Command) protocol.Value {
+	if e.configController == nil {
+		return configUnavailable()
+	}
+	if len(cmd.Args)%2 != 1 {
+		return wrongSubcommandArgs("config|set")
+	}
+
+	params := make([]ConfigParam, 0, len(cmd.Args)/2)
+	for i := 1; i < len(cmd.Args); i += 2 {
+		params = append(params, ConfigParam{
+			Name:  strings.ToLower(cmd.Args[i]),
+			Value: cmd.Args[i+1],
+		})
+	}
+	if err := e.configController.SetConfig(params); err != nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR CONFIG SET failed - " + err.Error(),
+		}
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+func (e *Executor) configRewrite(cmd *Command) protocol.Value {
+	if e.configController == nil {
+		return configUnavailable()
+	}
+
+	if err := e.configController.RewriteConfig(); err != nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR Rewriting config file: " + err.Error(),
+		}
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+func (e *Executor) configResetStat(cmd *Command) protocol.Value {
+	e.ResetStats()
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
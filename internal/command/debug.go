@@ -0,0 +1,271 @@
+package command
+
+import (
+	"fmt"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// DebugCommandMode selects the clients allowed to run DEBUG.
+type DebugCommandMode int
+
+const (
+	DebugCommandNo DebugCommandMode = iota
+	DebugCommandYes
+	// DebugCommandLocal allows loopback and Unix socket clients.
+	DebugCommandLocal
+)
+
+var debugCommandModeNames = []string{"no", "yes", "local"}
+
+func (m DebugCommandMode) String() string {
+	return debugCommandModeNames[m]
+}
+
+func ParseDebugCommandMode(name string) (DebugCommandMode, error) {
+	for i, modeName := range debugCommandModeNames {
+		if strings.EqualFold(name, modeName) {
+			return DebugCommandMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid debug command mode %q, must be one of %s", name, strings.Join(debugCommandModeNames, ", "))
+}
+
+// SetDebugCommand selects the clients allowed to run DEBUG. Internal
+// callers without a client are always allowed.
+func (e *Executor) SetDebugCommand(mode DebugCommandMode) {
+	e.debugCommand.Store(int64(mode))
+}
+
+func (e *Executor) debugAllowed(client *Client) bool {
+	switch DebugCommandMode(e.debugCommand.Load()) {
+	case DebugCommandYes:
+		return true
+	case DebugCommandLocal:
+		return client == nil || client.isLocal()
+	}
+	return client == nil
+}
+
+func debugNotAllowed() protocol.Value {
+	return protocol.Value{
+		Type: protocol.Error,
+		Str: "ERR DEBUG command not allowed. If the enable-debug-command option is set to \"local\", " +
+			"you can run it from a local connection, otherwise you need to set this option in the configuration file, " +
+			"and then restart the server.",
+	}
+}
+
+// isLocal reports whether the client is connected over loopback or a Unix
+// socket.
+func (c *Client) isLocal() bool {
+	if c.conn == nil {
+		return true
+	}
+	switch addr := c.conn.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		return addr.IP.IsLoopback()
+	case *net.UnixAddr:
+		return true
+	}
+	return false
+}
+
+// debugPopulate creates the keys prefix:0 to prefix:count-1 holding
+// value:0 and so on, padded with zero bytes or truncated to size when
+// given. Existing keys are left alone.
+func (e *Executor) debugPopulate(cmd *Command) protocol.Value {
+	count, err := strconv.Atoi(cmd.Args[1])
+	if err != nil || count < 0 {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR count must be a non-negative integer",
+		}
+	}
+	prefix := "key"
+	if len(cmd.Args) > 2 {
+		prefix = cmd.Args[2]
+	}
+	size := -1
+	if len(cmd.Args) > 3 {
+		size, err = strconv.Atoi(cmd.Args[3])
+		if err != nil || size < 0 {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR size must be a non-negative integer",
+			}
+		}
+	}
+	if len(cmd.Args) > 4 {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR syntax error",
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		key := prefix + ":" + strconv.Itoa(i)
+		if e.storage.Exists(key) {
+			continue
+		}
+		value := "value:" + strconv.Itoa(i)
+		if size >= 0 {
+			if len(value) < size {
+				value += strings.Repeat("\x00", size-len(value))
+			} else {
+				value = value[:size]
+			}
+		}
+		if err := e.storage.Set(key, value); err != nil {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR " + err.Error(),
+			}
+		}
+		e.signalModifiedKey(key)
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+// debugReload saves a snapshot and loads it back. It runs exclusively so
+// that no write slips in between.
+func (e *Executor) debugReload(cmd *Command) protocol.Value {
+	reloader, ok := e.storage.(interface{ Reload() error })
+	if !ok {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR persistence not enabled",
+		}
+	}
+	if err := reloader.Reload(); err != nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR " + err.Error(),
+		}
+	}
+	e.watches.touchAll()
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+// debugSleep blocks every client for the given number of seconds, which
+// may be fractional. It runs exclusively, so commands sent meanwhile wait.
+func (e *Executor) debugSleep(cmd *Command) protocol.Value {
+	seconds, err := strconv.ParseFloat(cmd.Args[1], 64)
+	if err != nil || seconds < 0 || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR value is not a valid float",
+		}
+	}
+	time.Sleep(time.Duration(seconds * float64(time.Second)))
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+// debugObject describes how the value of a key is stored. Memory is the
+// estimate also used by BIGKEYS.
+func (e *Executor) debugObject(cmd *Command) protocol.Value {
+	key := cmd.Args[1]
+	value, err := e.storage.Get(key)
+	if err != nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR no such key",
+		}
+	}
+
+	var size storage.KeySize
+	if sizer, ok := e.storage.(keySizer); ok {
+		size, _ = sizer.KeySize(key)
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str: fmt.Sprintf("Value at:%p refcount:1 type:%s encoding:%s elements:%d memory:%d",
+			value, value.Type, objectEncoding(value), size.Elements, size.Memory),
+	}
+}
+
+// objectEncoding names the Go representation of a value.
+func objectEncoding(value *storage.StorageValue) string {
+	switch data := value.Data.(type) {
+	case string:
+		if _, err := strconv.ParseInt(data, 10, 64); err == nil {
+			return "int"
+		}
+		return "raw"
+	case *storage.ListData:
+		return "array"
+	case *storage.HashData, *storage.SetData:
+		return "hashtable"
+	}
+	return "unknown"
+}
+
+func (e *Executor) debugSetActiveExpire(cmd *Command) protocol.Value {
+	var enabled bool
+	switch cmd.Args[1] {
+	case "0":
+	case "1":
+		enabled = true
+	default:
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR syntax error",
+		}
+	}
+
+	s, ok := e.storage.(interface{ SetActiveExpire(bool) })
+	if !ok {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR active expiration is not supported",
+		}
+	}
+	s.SetActiveExpire(enabled)
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+// debugStructSize reports the sizes in bytes of the structures values are
+// built from, without the data they point to.
+func (e *Executor) debugStructSize(cmd *Command) protocol.Value {
+	sizes := []struct {
+		name string
+		size uintptr
+	}{
+		{"storage_value", unsafe.Sizeof(storage.StorageValue{})},
+		{"hash_data", unsafe.Sizeof(storage.HashData{})},
+		{"list_data", unsafe.Sizeof(storage.ListData{})},
+		{"set_data", unsafe.Sizeof(storage.SetData{})},
+		{"string", unsafe.Sizeof("")},
+		{"slice", unsafe.Sizeof([]string(nil))},
+		{"interface", unsafe.Sizeof(interface{}(nil))},
+		{"client", unsafe.Sizeof(Client{})},
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "bits:%d", strconv.IntSize)
+	for _, s := range sizes {
+		fmt.Fprintf(&b, " %s:%d", s.name, s.size)
+	}
+	return protocol.Value{
+		Type: protocol.BulkString,
+		Bulk: b.String(),
+	}
+}
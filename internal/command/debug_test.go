@@ -0,0 +1,150 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugCommandMode(t *testing.T) {
+	registry := NewClientRegistry(0)
+	executor := NewExecutor(storage.NewMemoryStorage(), registry)
+	client, _ := registerPipeClient(t, registry)
+
+	// Pipe clients are neither loopback nor Unix socket clients.
+	for _, mode := range []DebugCommandMode{DebugCommandNo, DebugCommandLocal} {
+		executor.SetDebugCommand(mode)
+		expectErrorPrefix(t, execute(executor, client, "DEBUG", "STRUCTSIZE"), "ERR DEBUG command not allowed")
+	}
+	expectBulkPrefix(t, execute(executor, nil, "DEBUG", "STRUCTSIZE"), "bits:")
+
+	executor.SetDebugCommand(DebugCommandYes)
+	expectBulkPrefix(t, execute(executor, client, "DEBUG", "STRUCTSIZE"), "bits:")
+
+	if _, err := ParseDebugCommandMode("sometimes"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
+
+func TestDebugCommands(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	executor := NewExecutor(store, NewClientRegistry(0))
+
+	expectOK(t, execute(executor, nil, "DEBUG", "POPULATE", "3"))
+	if store.Size() != 3 {
+		t.Fatalf("Expected 3 keys, got %d", store.Size())
+	}
+	expectBulk(t, execute(executor, nil, "GET", "key:2"), "value:2")
+
+	expectOK(t, execute(executor, nil, "DEBUG", "POPULATE", "2", "padded", "10"))
+	expectBulk(t, execute(executor, nil, "GET", "padded:1"), "value:1\x00\x00\x00")
+	// Existing keys are left alone.
+	expectOK(t, execute(executor, nil, "DEBUG", "POPULATE", "3", "padded", "3"))
+	expectBulk(t, execute(executor, nil, "GET", "padded:0"), "value:0\x00\x00\x00")
+	expectBulk(t, execute(executor, nil, "GET", "padded:2"), "val")
+	expectErrorPrefix(t, execute(executor, nil, "DEBUG", "POPULATE", "-1"), "ERR count")
+
+	execute(executor, nil, "SET", "number", "42")
+	execute(executor, nil, "SADD", "set", "a", "b")
+	for key, expected := range map[string]string{
+		"key:0":  "type:string encoding:raw elements:7",
+		"number": "type:string encoding:int elements:2",
+		"set":    "type:set encoding:hashtable elements:2",
+	} {
+		reply := execute(executor, nil, "DEBUG", "OBJECT", key)
+		if reply.Type != protocol.SimpleString || !strings.Contains(reply.Str, expected) {
+			t.Errorf("DEBUG OBJECT %s: expected %q, got %+v", key, expected, reply)
+		}
+	}
+	expectErrorPrefix(t, execute(executor, nil, "DEBUG", "OBJECT", "missing"), "ERR no such key")
+
+	start := time.Now()
+	expectOK(t, execute(executor, nil, "DEBUG", "SLEEP", "0.05"))
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected DEBUG SLEEP to block for 50ms, returned after %v", elapsed)
+	}
+	expectErrorPrefix(t, execute(executor, nil, "DEBUG", "SLEEP", "-1"), "ERR value is not a valid float")
+
+	expectErrorPrefix(t, execute(executor, nil, "DEBUG", "RELOAD"), "ERR persistence not enabled")
+	expectErrorPrefix(t, execute(executor, nil, "DEBUG", "NOPE"), "ERR unknown subcommand")
+}
+
+func TestDebugSetActiveExpire(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	executor := NewExecutor(store, NewClientRegistry(0))
+
+	expectOK(t, execute(executor, nil, "DEBUG", "SET-ACTIVE-EXPIRE", "0"))
+	store.StartExpirationChecker(time.Millisecond)
+	store.SetWithTTL("short", "v", time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if expired := executor.stats.expiredKeys.Load(); expired != 0 {
+		t.Fatalf("Expected no key to be removed while active expiration is paused, got %d", expired)
+	}
+
+	expectOK(t, execute(executor, nil, "DEBUG", "SET-ACTIVE-EXPIRE", "1"))
+	deadline := time.Now().Add(time.Second)
+	for executor.stats.expiredKeys.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the expired key to be removed once active expiration resumed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	expectErrorPrefix(t, execute(executor, nil, "DEBUG", "SET-ACTIVE-EXPIRE", "yes"), "ERR syntax error")
+}
+
+func TestDebugReload(t *testing.T) {
+	store := storage.NewMemoryStorageWithPersistence(&config.PersistenceConfig{
+		Enabled:  true,
+		DataDir:  t.TempDir(),
+		Filename: "test.bin",
+	})
+	executor := NewExecutor(store, NewClientRegistry(0))
+
+	execute(executor, nil, "SET", "a", "1")
+	execute(executor, nil, "RPUSH", "list", "x", "y")
+	digest := execute(executor, nil, "DIGEST")
+	expectOK(t, execute(executor, nil, "DEBUG", "RELOAD"))
+	if reloaded := execute(executor, nil, "DEBUG", "DIGEST"); reloaded.Str != digest.Str {
+		t.Errorf("Expected the digest %s to survive the reload, got %+v", digest.Str, reloaded)
+	}
+
+	expectBulk(t, execute(executor, nil, "GET", "a"), "1")
+	if got := bulkStrings(t, execute(executor, nil, "LRANGE", "list", "0", "-1")); strings.Join(got, ",") != "x,y" {
+		t.Errorf("Expected the list to survive the reload, got %v", got)
+	}
+	if stats, _ := store.PersistenceStats(); stats.Saves != 1 {
+		t.Errorf("Expected DEBUG RELOAD to save a snapshot, got %d saves", stats.Saves)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+	zeros := strings.Repeat("0", 40)
+
+	if reply := execute(executor, nil, "DIGEST"); reply.Type != protocol.SimpleString || reply.Str != zeros {
+		t.Errorf("Expected an empty keyspace to digest to zeros, got %+v", reply)
+	}
+	execute(executor, nil, "SET", "a", "1")
+	digest := execute(executor, nil, "DIGEST").Str
+	if len(digest) != 40 || digest == zeros {
+		t.Errorf("Expected a 40 digit digest, got %q", digest)
+	}
+
+	reply := execute(executor, nil, "DEBUG", "DIGEST-VALUE", "a", "missing")
+	if reply.Type != protocol.Array || len(reply.Array) != 2 {
+		t.Fatalf("Expected two digests, got %+v", reply)
+	}
+	if reply.Array[0].Str == zeros || reply.Array[1].Str != zeros {
+		t.Errorf("Expected zeros only for the missing key, got %+v", reply.Array)
+	}
+}
+
+func expectBulkPrefix(t *testing.T, reply protocol.Value, prefix string) {
+	t.Helper()
+	if reply.Type != protocol.BulkString || !strings.HasPrefix(reply.Bulk, prefix) {
+		t.Errorf("Expected a bulk string starting with %q, got %+v", prefix, reply)
+	}
+}
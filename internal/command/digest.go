@@ -0,0 +1,54 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+)
+
+// digester is implemented by storages that can digest their contents.
+type digester interface {
+	Digest() storage.Digest
+	ValueDigest(key string) (storage.Digest, bool)
+}
+
+func digestNotSupported() protocol.Value {
+	return protocol.Value{
+		Type: protocol.Error,
+		Str:  "ERR DIGEST is not supported by the storage",
+	}
+}
+
+// digest implements DIGEST and DEBUG DIGEST, replying with the digest of
+// the whole keyspace.
+func (e *Executor) digest(cmd *Command) protocol.Value {
+	d, ok := e.storage.(digester)
+	if !ok {
+		return digestNotSupported()
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  d.Digest().String(),
+	}
+}
+
+// debugDigestValue replies with the digest of every given key's value,
+// all zeros for missing keys.
+func (e *Executor) debugDigestValue(cmd *Command) protocol.Value {
+	d, ok := e.storage.(digester)
+	if !ok {
+		return digestNotSupported()
+	}
+
+	result := make([]protocol.Value, 0, len(cmd.Args)-1)
+	for _, key := range cmd.Args[1:] {
+		digest, _ := d.ValueDigest(key)
+		result = append(result, protocol.Value{
+			Type: protocol.SimpleString,
+			Str:  digest.String(),
+		})
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: result,
+	}
+}
@@ -4,118 +4,193 @@ import (
 	"fmt"
 	"ivanSaichkin/myredis/internal/protocol"
 	"ivanSaichkin/myredis/internal/storage"
+	"log/slog"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Executor struct {
 	storage   storage.Storage
 	validator *Validator
+	clients   *ClientRegistry
+	acl       *ACL
+	pubsub    *PubSub
+	watches   *watchedKeys
+	logger    *slog.Logger
+
+	commandStats *commandStatsTable
+	slowLog      *SlowLog
+	monitors     *monitorFeed
+	auditLog     *AuditLog
+	hotKeys      *HotKeys
+	bigKeys      bigKeysScan
+
+	// mu is held for reading while a command runs and for writing while
+	// EXEC runs a transaction, so transactions are not interleaved with
+	// other commands.
+	mu sync.RWMutex
+
+	notifyFlags atomic.Int64
+	stats       serverStats
+	startTime   time.Time
+
+	debugCommand atomic.Int64
+
+	shutdownController ShutdownController
+	configController   ConfigController
 }
 
-func NewExecutor(store storage.Storage) *Executor {
-	return &Executor{
+func NewExecutor(store storage.Storage, clients *ClientRegistry) *Executor {
+	e := &Executor{
 		storage:   store,
 		validator: NewValidator(store),
+		clients:   clients,
+		acl:       NewACL(""),
+		pubsub:    NewPubSub(),
+		watches:   newWatchedKeys(),
+		logger:    slog.Default(),
+		startTime: time.Now(),
+
+		commandStats: newCommandStatsTable(),
+		slowLog:      NewSlowLog(10*time.Millisecond, 128),
+		monitors:     newMonitorFeed(),
+		auditLog:     NewAuditLog(),
+		hotKeys:      NewHotKeys(10),
+	}
+
+	if notifier, ok := store.(interface{ OnExpire(func(key string)) }); ok {
+		notifier.OnExpire(func(key string) {
+			e.stats.expiredKeys.Add(1)
+			e.signalModifiedKey(key)
+			e.notifyKeyspaceEvent(notifyExpired, "expired", key)
+		})
+	}
+	return e
+}
+
+func (e *Executor) SetLogger(logger *slog.Logger) {
+	e.logger = logger
+}
+
+// ClientClosed releases the state a disconnected client holds in the
+// executor, such as its Pub/Sub subscriptions and watched keys.
+func (e *Executor) ClientClosed(client *Client) {
+	e.pubsub.UnsubscribeAll(client)
+	e.watches.unwatch(client)
+	e.monitors.remove(client)
+}
+
+// Execute runs cmd on behalf of client after checking the client's ACL
+// permissions. A nil client denotes an internal caller without a
+// connection and is not subject to ACL checks.
+func (e *Executor) Execute(client *Client, cmd *Command) protocol.Value {
+	if reply, ok := e.Admit(client, cmd); !ok {
+		return reply
+	}
+
+	if spec := lookupCommand(cmd); spec != nil && spec.exclusive {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+	} else {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
 	}
+	return e.call(client, cmd)
 }
 
-func (e *Executor) Execute(cmd *Command) protocol.Value {
+// Admit runs the checks every command received from client goes through
+// before it is executed or queued in a transaction: ACL permissions, the
+// subscriber mode restriction and argument validation. It returns the
+// error reply and false when the command must be rejected.
+func (e *Executor) Admit(client *Client, cmd *Command) (protocol.Value, bool) {
+	if client != nil {
+		client.touch(cmd.FullName())
+
+		if reply, ok := e.authorize(client, cmd); !ok {
+			return reply, false
+		}
+
+		if client.SubscriptionCount() > 0 && !subscriberCommands[cmd.Name] {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR Can't execute '" + cmd.FullName() + "': only (P)SUBSCRIBE / (P)UNSUBSCRIBE / PING are allowed in this context",
+			}, false
+		}
+	}
+
+	spec := commandTable[strings.ToLower(cmd.Name)]
+	if spec == nil {
+		return unknownCommand(cmd), false
+	}
+	if spec.subcommands != nil && len(cmd.Args) > 0 && lookupCommand(cmd) == nil {
+		return unknownSubcommand(cmd), false
+	}
+	if spec.protected && !e.debugAllowed(client) {
+		return debugNotAllowed(), false
+	}
+
 	if err := e.validator.ValidateCommand(cmd); err != nil {
 		return protocol.Value{
 			Type: protocol.Error,
 			Str:  "ERR " + err.Error(),
-		}
+		}, false
 	}
+	return protocol.Value{}, true
+}
 
-	switch cmd.Name {
-	// String commands
-	case "PING":
-		return e.ping(cmd)
-	case "SET":
-		return e.set(cmd)
-	case "GET":
-		return e.get(cmd)
-	case "DEL":
-		return e.del(cmd)
-	case "EXISTS":
-		return e.exists(cmd)
-	case "EXPIRE":
-		return e.expire(cmd)
-	case "TTL":
-		return e.ttl(cmd)
-	case "TYPE":
-		return e.keyType(cmd)
-
-	// Hash commands
-	case "HSET":
-		return e.hset(cmd)
-	case "HGET":
-		return e.hget(cmd)
-	case "HDEL":
-		return e.hdel(cmd)
-	case "HEXISTS":
-		return e.hexists(cmd)
-	case "HGETALL":
-		return e.hgetall(cmd)
-	case "HKEYS":
-		return e.hkeys(cmd)
-	case "HLEN":
-		return e.hlen(cmd)
-
-	// List commands
-	case "LPUSH":
-		return e.lpush(cmd)
-	case "RPUSH":
-		return e.rpush(cmd)
-	case "LPOP":
-		return e.lpop(cmd)
-	case "RPOP":
-		return e.rpop(cmd)
-	case "LLEN":
-		return e.llen(cmd)
-	case "LRANGE":
-		return e.lrange(cmd)
-
-	// Set commands
-	case "SADD":
-		return e.sadd(cmd)
-	case "SREM":
-		return e.srem(cmd)
-	case "SISMEMBER":
-		return e.sismember(cmd)
-	case "SMEMBERS":
-		return e.smembers(cmd)
-	case "SCARD":
-		return e.scard(cmd)
-	case "SINTER":
-		return e.sinter(cmd)
-
-	// Utility commands
-	case "KEYS":
-		return e.keys(cmd)
-	case "FLUSHDB", "CLEAR":
-		return e.clear(cmd)
-
-	case "SAVE":
-		return e.save(cmd)
-	case "BGSAVE":
-		return e.bgsave(cmd)
-	case "LASTSAVE":
-		return e.lastsave(cmd)
-
-	default:
-		return protocol.Value{
-			Type: protocol.Error,
-			Str:  "ERR unknown command '" + cmd.Name + "'",
-		}
+// call dispatches cmd, feeds it to monitors, samples its keys unless it
+// failed, records its statistics and audits it.
+func (e *Executor) call(client *Client, cmd *Command) protocol.Value {
+	e.monitors.feed(client, cmd)
+
+	start := time.Now()
+	reply := e.dispatch(client, cmd)
+	duration := time.Since(start)
+
+	if reply.Type != protocol.Error {
+		e.hotKeys.record(cmd)
+	}
+	e.stats.commands.Add(1)
+	e.commandStats.record(statsName(cmd), duration, reply.Type == protocol.Error)
+	e.slowLog.record(client, cmd, duration)
+	if err := e.auditLog.record(client, cmd, reply); err != nil {
+		e.logger.Warn("Failed to write audit log", "error", err)
+	}
+	return reply
+}
+
+func (e *Executor) dispatch(client *Client, cmd *Command) protocol.Value {
+	spec := lookupCommand(cmd)
+	if spec == nil || spec.handler == nil {
+		return unknownCommand(cmd)
+	}
+	return spec.handler(e, client, cmd)
+}
+
+func unknownCommand(cmd *Command) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Error,
+		Str:  "ERR unknown command '" + cmd.Name + "'",
+	}
+}
+
+func unknownSubcommand(cmd *Command) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Error,
+		Str:  "ERR unknown subcommand '" + cmd.Args[0] + "'. Try " + cmd.Name + " HELP.",
 	}
 }
 
 // String commands
 
-func (e *Executor) ping(cmd *Command) protocol.Value {
+func (e *Executor) ping(client *Client, cmd *Command) protocol.Value {
+	if client != nil && client.SubscriptionCount() > 0 {
+		return e.subscriberPing(cmd)
+	}
+
 	if len(cmd.Args) > 0 {
 		return protocol.Value{
 			Type: protocol.BulkString,
@@ -161,6 +236,12 @@ func (e *Executor) set(cmd *Command) protocol.Value {
 		}
 	}
 
+	e.signalModifiedKey(key)
+	e.notifyKeyspaceEvent(notifyString, "set", key)
+	if ttl > 0 {
+		e.notifyKeyspaceEvent(notifyGeneric, "expire", key)
+	}
+
 	return protocol.Value{
 		Type: protocol.SimpleString,
 		Str:  "OK",
@@ -169,6 +250,7 @@ func (e *Executor) set(cmd *Command) protocol.Value {
 
 func (e *Executor) get(cmd *Command) protocol.Value {
 	val, err := e.storage.Get(cmd.Args[0])
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound || err == storage.ErrKeyExpired {
 			return protocol.Value{
@@ -201,16 +283,9 @@ func (e *Executor) get(cmd *Command) protocol.Value {
 }
 
 func (e *Executor) del(cmd *Command) protocol.Value {
-	deletedCount := 0
-	for _, key := range cmd.Args {
-		if e.storage.Delete(key) {
-			deletedCount++
-		}
-	}
-
 	return protocol.Value{
 		Type: protocol.Integer,
-		Num:  deletedCount,
+		Num:  e.deleteKeys(cmd.Args),
 	}
 }
 
@@ -242,6 +317,8 @@ func (e *Executor) expire(cmd *Command) protocol.Value {
 	result := 0
 	if success {
 		result = 1
+		e.signalModifiedKey(key)
+		e.notifyKeyspaceEvent(notifyGeneric, "expire", key)
 	}
 
 	return protocol.Value{
@@ -335,14 +412,6 @@ func (e *Executor) keys(cmd *Command) protocol.Value {
 	}
 }
 
-func (e *Executor) clear(cmd *Command) protocol.Value {
-	e.storage.Clear()
-	return protocol.Value{
-		Type: protocol.SimpleString,
-		Str:  "OK",
-	}
-}
-
 // Helper functions
 
 // simpleMatch does basic pattern matching with * wildcard
@@ -362,13 +431,6 @@ func simpleMatch(s, pattern string) bool {
 }
 
 func (e *Executor) save(cmd *Command) protocol.Value {
-	if len(cmd.Args) != 0 {
-		return protocol.Value{
-			Type: protocol.Error,
-			Str:  "ERR wrong number of arguments for 'save' command",
-		}
-	}
-
 	if storage, ok := e.storage.(interface{ SaveSnapshot() error }); ok {
 		if err := storage.SaveSnapshot(); err != nil {
 			return protocol.Value{
@@ -389,19 +451,12 @@ func (e *Executor) save(cmd *Command) protocol.Value {
 }
 
 func (e *Executor) bgsave(cmd *Command) protocol.Value {
-	if len(cmd.Args) != 0 {
-		return protocol.Value{
-			Type: protocol.Error,
-			Str:  "ERR wrong number of arguments for 'bgsave' command",
-		}
-	}
-
 	if storage, ok := e.storage.(interface{ SaveSnapshot() error }); ok {
 		go func() {
 			if err := storage.SaveSnapshot(); err != nil {
-				fmt.Printf("BGSAVE failed: %v\n", err)
+				e.logger.Warn("BGSAVE failed", "error", err)
 			} else {
-				fmt.Println("BGSAVE completed successfully")
+				e.logger.Info("BGSAVE completed successfully")
 			}
 		}()
 
@@ -418,15 +473,9 @@ func (e *Executor) bgsave(cmd *Command) protocol.Value {
 }
 
 func (e *Executor) lastsave(cmd *Command) protocol.Value {
-	if len(cmd.Args) != 0 {
-		return protocol.Value{
-			Type: protocol.Error,
-			Str:  "ERR wrong number of arguments for 'lastsave' command",
-		}
-	}
-
+	stats, _ := e.persistenceStats()
 	return protocol.Value{
 		Type: protocol.Integer,
-		Num:  int(time.Now().Unix()),
+		Num:  int(stats.LastSave.Unix()),
 	}
 }
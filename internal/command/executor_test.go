@@ -0,0 +1,182 @@
+package command
+
+import (
+	"bytes"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"slices"
+	"testing"
+)
+
+const binaryValue = "bin\r\n\x00\xff$-1\r\n"
+
+// roundTrip encodes args as a RESP command, parses and executes it, and
+// decodes the reply exactly as a client would see it on the wire.
+func roundTrip(t *testing.T, executor *Executor, args ...string) protocol.Value {
+	t.Helper()
+
+	request := make([]protocol.Value, len(args))
+	for i, arg := range args {
+		request[i] = protocol.Value{Type: protocol.BulkString, Bulk: arg}
+	}
+
+	var in bytes.Buffer
+	writer := protocol.NewRESPWriter(&in)
+	if err := writer.Write(protocol.Value{Type: protocol.Array, Array: request}); err != nil {
+		t.Fatalf("Failed to encode command: %v", err)
+	}
+	writer.Flush()
+
+	cmd, err := NewParser(protocol.NewRESPReader(&in)).ParseCommand()
+	if err != nil {
+		t.Fatalf("Failed to parse command: %v", err)
+	}
+
+	var out bytes.Buffer
+	writer = protocol.NewRESPWriter(&out)
+	if err := writer.Write(executor.Execute(nil, cmd)); err != nil {
+		t.Fatalf("Failed to encode reply: %v", err)
+	}
+	writer.Flush()
+
+	reply, err := protocol.NewRESPReader(&out).Read()
+	if err != nil {
+		t.Fatalf("Failed to decode reply: %v", err)
+	}
+	return reply
+}
+
+func expectBulk(t *testing.T, reply protocol.Value, expected string) {
+	t.Helper()
+	if reply.Type != protocol.BulkString || reply.IsNull {
+		t.Fatalf("Expected bulk string %q, got %+v", expected, reply)
+	}
+	if reply.Bulk != expected {
+		t.Errorf("Expected %q, got %q", expected, reply.Bulk)
+	}
+}
+
+func expectNull(t *testing.T, reply protocol.Value) {
+	t.Helper()
+	if reply.Type != protocol.BulkString || !reply.IsNull {
+		t.Errorf("Expected null bulk string, got %+v", reply)
+	}
+}
+
+func expectInteger(t *testing.T, reply protocol.Value, expected int) {
+	t.Helper()
+	if reply.Type != protocol.Integer || reply.Num != expected {
+		t.Errorf("Expected integer %d, got %+v", expected, reply)
+	}
+}
+
+func bulkStrings(t *testing.T, reply protocol.Value) []string {
+	t.Helper()
+	if reply.Type != protocol.Array {
+		t.Fatalf("Expected array, got %+v", reply)
+	}
+	result := make([]string, len(reply.Array))
+	for i, item := range reply.Array {
+		if item.Type != protocol.BulkString || item.IsNull {
+			t.Fatalf("Expected bulk string element, got %+v", item)
+		}
+		result[i] = item.Bulk
+	}
+	return result
+}
+
+func TestStringCommandsRoundTrip(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+
+	roundTrip(t, executor, "SET", "empty", "")
+	expectBulk(t, roundTrip(t, executor, "GET", "empty"), "")
+	expectNull(t, roundTrip(t, executor, "GET", "missing"))
+
+	roundTrip(t, executor, "SET", binaryValue, binaryValue)
+	expectBulk(t, roundTrip(t, executor, "GET", binaryValue), binaryValue)
+	expectInteger(t, roundTrip(t, executor, "EXISTS", binaryValue, "empty", "missing"), 2)
+
+	expectBulk(t, roundTrip(t, executor, "PING", ""), "")
+	expectBulk(t, roundTrip(t, executor, "PING", binaryValue), binaryValue)
+
+	expectInteger(t, roundTrip(t, executor, "DEL", binaryValue), 1)
+	expectNull(t, roundTrip(t, executor, "GET", binaryValue))
+}
+
+func TestHashCommandsRoundTrip(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+
+	expectInteger(t, roundTrip(t, executor, "HSET", "hash", "empty", "", binaryValue, binaryValue), 2)
+	expectBulk(t, roundTrip(t, executor, "HGET", "hash", "empty"), "")
+	expectBulk(t, roundTrip(t, executor, "HGET", "hash", binaryValue), binaryValue)
+	expectNull(t, roundTrip(t, executor, "HGET", "hash", "missing"))
+
+	pairs := bulkStrings(t, roundTrip(t, executor, "HGETALL", "hash"))
+	fields := make(map[string]string)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fields[pairs[i]] = pairs[i+1]
+	}
+	if len(fields) != 2 || fields["empty"] != "" || fields[binaryValue] != binaryValue {
+		t.Errorf("Unexpected HGETALL result: %q", pairs)
+	}
+
+	keys := bulkStrings(t, roundTrip(t, executor, "HKEYS", "hash"))
+	if !slices.Contains(keys, binaryValue) || !slices.Contains(keys, "empty") {
+		t.Errorf("Unexpected HKEYS result: %q", keys)
+	}
+}
+
+func TestListCommandsRoundTrip(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+
+	expectInteger(t, roundTrip(t, executor, "RPUSH", "list", "", binaryValue, "tail"), 3)
+
+	elements := bulkStrings(t, roundTrip(t, executor, "LRANGE", "list", "0", "-1"))
+	if !slices.Equal(elements, []string{"", binaryValue, "tail"}) {
+		t.Errorf("Unexpected LRANGE result: %q", elements)
+	}
+
+	expectBulk(t, roundTrip(t, executor, "LPOP", "list"), "")
+	expectBulk(t, roundTrip(t, executor, "LPOP", "list"), binaryValue)
+	expectBulk(t, roundTrip(t, executor, "RPOP", "list"), "tail")
+	expectNull(t, roundTrip(t, executor, "RPOP", "list"))
+}
+
+func TestSetCommandsRoundTrip(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+
+	expectInteger(t, roundTrip(t, executor, "SADD", "set1", "", binaryValue, "a"), 3)
+	expectInteger(t, roundTrip(t, executor, "SADD", "set2", "", binaryValue, "b"), 3)
+	expectInteger(t, roundTrip(t, executor, "SISMEMBER", "set1", ""), 1)
+	expectInteger(t, roundTrip(t, executor, "SISMEMBER", "set1", binaryValue), 1)
+
+	members := bulkStrings(t, roundTrip(t, executor, "SINTER", "set1", "set2"))
+	slices.Sort(members)
+	expected := []string{"", binaryValue}
+	slices.Sort(expected)
+	if !slices.Equal(members, expected) {
+		t.Errorf("Unexpected SINTER result: %q", members)
+	}
+
+	expectInteger(t, roundTrip(t, executor, "SREM", "set1", ""), 1)
+	expectInteger(t, roundTrip(t, executor, "SCARD", "set1"), 2)
+}
+
+func TestKeyCommandsRoundTrip(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+
+	roundTrip(t, executor, "SET", binaryValue, "")
+	roundTrip(t, executor, "SET", "", "empty key")
+
+	keys := bulkStrings(t, roundTrip(t, executor, "KEYS", "*"))
+	if len(keys) != 2 || !slices.Contains(keys, binaryValue) || !slices.Contains(keys, "") {
+		t.Errorf("Unexpected KEYS result: %q", keys)
+	}
+
+	expectBulk(t, roundTrip(t, executor, "GET", ""), "empty key")
+
+	reply := roundTrip(t, executor, "TYPE", binaryValue)
+	if reply.Type != protocol.SimpleString || reply.Str != "string" {
+		t.Errorf("Expected type string, got %+v", reply)
+	}
+}
@@ -31,6 +31,9 @@ func (e *Executor) hset(cmd *Command) protocol.Value {
 		}
 	}
 
+	e.signalModifiedKey(key)
+	e.notifyKeyspaceEvent(notifyHash, "hset", key)
+
 	return protocol.Value{
 		Type: protocol.Integer,
 		Num:  totalFields,
@@ -39,6 +42,7 @@ func (e *Executor) hset(cmd *Command) protocol.Value {
 
 func (e *Executor) hget(cmd *Command) protocol.Value {
 	value, err := e.storage.HGet(cmd.Args[0], cmd.Args[1])
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound || err == storage.ErrFieldNotFound {
 			return protocol.Value{
@@ -79,6 +83,11 @@ func (e *Executor) hdel(cmd *Command) protocol.Value {
 		}
 	}
 
+	if deletedCount > 0 {
+		e.signalModifiedKey(key)
+		e.notifyKeyspaceEvent(notifyHash, "hdel", key)
+	}
+
 	return protocol.Value{
 		Type: protocol.Integer,
 		Num:  deletedCount,
@@ -87,6 +96,7 @@ func (e *Executor) hdel(cmd *Command) protocol.Value {
 
 func (e *Executor) hexists(cmd *Command) protocol.Value {
 	exists, err := e.storage.HExists(cmd.Args[0], cmd.Args[1])
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -113,6 +123,7 @@ func (e *Executor) hexists(cmd *Command) protocol.Value {
 
 func (e *Executor) hgetall(cmd *Command) protocol.Value {
 	fields, err := e.storage.HGetAll(cmd.Args[0])
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -146,6 +157,7 @@ func (e *Executor) hgetall(cmd *Command) protocol.Value {
 
 func (e *Executor) hkeys(cmd *Command) protocol.Value {
 	keys, err := e.storage.HKeys(cmd.Args[0])
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -175,6 +187,7 @@ func (e *Executor) hkeys(cmd *Command) protocol.Value {
 
 func (e *Executor) hlen(cmd *Command) protocol.Value {
 	length, err := e.storage.HLen(cmd.Args[0])
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -0,0 +1,228 @@
+package command
+
+import (
+	"cmp"
+	"container/heap"
+	"hash/maphash"
+	"ivanSaichkin/myredis/internal/protocol"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// The count-min sketch has hotKeysDepth rows of hotKeysWidth counters.
+	hotKeysWidth = 2048
+	hotKeysDepth = 4
+	// hotKeysCapacity is the number of top keys tracked.
+	hotKeysCapacity = 64
+	// Every hotKeysDecayInterval all counts are halved, so that keys that
+	// are no longer accessed cool down.
+	hotKeysDecayInterval = time.Minute
+)
+
+// HotKey is a key and its estimated number of accesses.
+type HotKey struct {
+	Key   string
+	Count int64
+}
+
+// HotKeys estimates the most accessed keys from a sample of the commands
+// that succeed; commands replying with an error, such as WRONGTYPE, are
+// not counted. Sampled keys are counted in a count-min sketch, which may
+// overestimate but never underestimates a count, and the keys with the
+// highest estimates are kept in a min-heap.
+type HotKeys struct {
+	// ratio samples one in ratio commands; zero disables sampling.
+	ratio atomic.Int64
+
+	mu        sync.Mutex
+	seed      maphash.Seed
+	sketch    [hotKeysDepth][hotKeysWidth]int64
+	top       hotKeyHeap
+	lastDecay time.Time
+}
+
+func NewHotKeys(ratio int) *HotKeys {
+	h := &HotKeys{
+		seed:      maphash.MakeSeed(),
+		top:       hotKeyHeap{index: make(map[string]int)},
+		lastDecay: time.Now(),
+	}
+	h.SetSampleRatio(ratio)
+	return h
+}
+
+// SetSampleRatio samples one in ratio commands; zero disables sampling.
+func (h *HotKeys) SetSampleRatio(ratio int) {
+	h.ratio.Store(int64(max(ratio, 0)))
+}
+
+func (h *HotKeys) SampleRatio() int {
+	return int(h.ratio.Load())
+}
+
+// record counts the keys of cmd if the command is sampled. Each sampled
+// access counts ratio times, so that counts estimate all accesses.
+func (h *HotKeys) record(cmd *Command) {
+	ratio := h.ratio.Load()
+	if ratio <= 0 || (ratio > 1 && rand.Int64N(ratio) != 0) {
+		return
+	}
+	spec := lookupCommand(cmd)
+	if spec == nil || spec.firstKey == 0 {
+		return
+	}
+	keys := spec.keys(cmd.Args)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if now := time.Now(); now.Sub(h.lastDecay) >= hotKeysDecayInterval {
+		h.decay()
+		h.lastDecay = now
+	}
+	for _, key := range keys {
+		h.add(key, ratio)
+	}
+}
+
+// add counts n accesses to key; mu must be held.
+func (h *HotKeys) add(key string, n int64) {
+	sum := maphash.String(h.seed, key)
+	// Double hashing derives the row indexes from one hash.
+	h1, h2 := sum&0xffffffff, sum>>32|1
+	estimate := int64(-1)
+	for row := range h.sketch {
+		counter := &h.sketch[row][(h1+uint64(row)*h2)%hotKeysWidth]
+		*counter += n
+		if estimate < 0 || *counter < estimate {
+			estimate = *counter
+		}
+	}
+
+	if i, ok := h.top.index[key]; ok {
+		h.top.keys[i].Count = estimate
+		heap.Fix(&h.top, i)
+	} else if len(h.top.keys) < hotKeysCapacity {
+		heap.Push(&h.top, HotKey{Key: key, Count: estimate})
+	} else if estimate > h.top.keys[0].Count {
+		delete(h.top.index, h.top.keys[0].Key)
+		h.top.keys[0] = HotKey{Key: key, Count: estimate}
+		h.top.index[key] = 0
+		heap.Fix(&h.top, 0)
+	}
+}
+
+// decay halves every count; mu must be held.
+func (h *HotKeys) decay() {
+	for row := range h.sketch {
+		for i := range h.sketch[row] {
+			h.sketch[row][i] /= 2
+		}
+	}
+	for i := range h.top.keys {
+		h.top.keys[i].Count /= 2
+	}
+}
+
+// Top returns up to n keys with the highest estimated counts, hottest
+// first; a negative n returns all tracked keys.
+func (h *HotKeys) Top(n int) []HotKey {
+	h.mu.Lock()
+	keys := slices.Clone(h.top.keys)
+	h.mu.Unlock()
+
+	slices.SortFunc(keys, func(a, b HotKey) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Key, b.Key))
+	})
+	if n >= 0 && n < len(keys) {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+func (h *HotKeys) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.sketch = [hotKeysDepth][hotKeysWidth]int64{}
+	h.top = hotKeyHeap{index: make(map[string]int)}
+	h.lastDecay = time.Now()
+}
+
+// hotKeyHeap is a min-heap of keys by count that tracks the position of
+// every key.
+type hotKeyHeap struct {
+	keys  []HotKey
+	index map[string]int
+}
+
+func (h *hotKeyHeap) Len() int           { return len(h.keys) }
+func (h *hotKeyHeap) Less(i, j int) bool { return h.keys[i].Count < h.keys[j].Count }
+
+func (h *hotKeyHeap) Swap(i, j int) {
+	h.keys[i], h.keys[j] = h.keys[j], h.keys[i]
+	h.index[h.keys[i].Key] = i
+	h.index[h.keys[j].Key] = j
+}
+
+func (h *hotKeyHeap) Push(x any) {
+	key := x.(HotKey)
+	h.index[key.Key] = len(h.keys)
+	h.keys = append(h.keys, key)
+}
+
+func (h *hotKeyHeap) Pop() any {
+	key := h.keys[len(h.keys)-1]
+	h.keys = h.keys[:len(h.keys)-1]
+	delete(h.index, key.Key)
+	return key
+}
+
+func (e *Executor) HotKeys() *HotKeys {
+	return e.hotKeys
+}
+
+func (e *Executor) hotkeysGet(cmd *Command) protocol.Value {
+	if len(cmd.Args) > 2 {
+		return wrongSubcommandArgs("hotkeys|get")
+	}
+	n := 10
+	if len(cmd.Args) == 2 {
+		var err error
+		if n, err = strconv.Atoi(cmd.Args[1]); err != nil || n < -1 {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR count should be greater than or equal to -1",
+			}
+		}
+	}
+
+	keys := e.hotKeys.Top(n)
+	result := make([]protocol.Value, len(keys))
+	for i, key := range keys {
+		result[i] = protocol.Value{
+			Type: protocol.Array,
+			Array: []protocol.Value{
+				{Type: protocol.BulkString, Bulk: key.Key},
+				{Type: protocol.Integer, Num: int(key.Count)},
+			},
+		}
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: result,
+	}
+}
+
+func (e *Executor) hotkeysReset(cmd *Command) protocol.Value {
+	e.hotKeys.Reset()
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
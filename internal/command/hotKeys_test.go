@@ -0,0 +1,66 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHotKeys(t *testing.T) {
+	executor := NewExecutor(storage.NewMemoryStorage(), NewClientRegistry(0))
+	executor.HotKeys().SetSampleRatio(1)
+
+	// key:i is accessed i times.
+	for i := 1; i <= 100; i++ {
+		for range i {
+			execute(executor, nil, "GET", "key:"+strconv.Itoa(i))
+		}
+	}
+	execute(executor, nil, "SINTER", "key:1", "key:2")
+	execute(executor, nil, "PING")
+	// Commands that fail are not counted.
+	execute(executor, nil, "SADD", "set", "a")
+	for range 200 {
+		expectErrorPrefix(t, execute(executor, nil, "GET", "set"), "WRONGTYPE")
+	}
+
+	top := executor.HotKeys().Top(3)
+	if len(top) != 3 || top[0].Key != "key:100" || top[1].Key != "key:99" || top[2].Key != "key:98" {
+		t.Fatalf("Unexpected hot keys %+v", top)
+	}
+	// The sketch never underestimates.
+	if top[0].Count < 100 {
+		t.Errorf("Expected at least 100 accesses, got %d", top[0].Count)
+	}
+	if n := len(executor.HotKeys().Top(-1)); n != hotKeysCapacity {
+		t.Errorf("Expected %d tracked keys, got %d", hotKeysCapacity, n)
+	}
+
+	reply := execute(executor, nil, "HOTKEYS", "GET", "2")
+	if reply.Type != protocol.Array || len(reply.Array) != 2 {
+		t.Fatalf("HOTKEYS GET: unexpected reply %+v", reply)
+	}
+	expectBulk(t, reply.Array[0].Array[0], "key:100")
+	expectInteger(t, reply.Array[0].Array[1], int(top[0].Count))
+	expectErrorPrefix(t, execute(executor, nil, "HOTKEYS", "GET", "-2"), "ERR count")
+
+	// Counts halve once per decay interval.
+	executor.hotKeys.lastDecay = time.Now().Add(-hotKeysDecayInterval)
+	execute(executor, nil, "GET", "other")
+	if count := executor.HotKeys().Top(1)[0].Count; count != top[0].Count/2 {
+		t.Errorf("Expected the count to decay to %d, got %d", top[0].Count/2, count)
+	}
+
+	expectOK(t, execute(executor, nil, "HOTKEYS", "RESET"))
+	if top := executor.HotKeys().Top(-1); len(top) != 0 {
+		t.Errorf("Expected no hot keys after a reset, got %+v", top)
+	}
+
+	executor.HotKeys().SetSampleRatio(0)
+	execute(executor, nil, "GET", "key:1")
+	if top := executor.HotKeys().Top(-1); len(top) != 0 {
+		t.Errorf("Expected no sampling with a zero ratio, got %+v", top)
+	}
+}
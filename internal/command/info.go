@@ -0,0 +1,195 @@
+package command
+
+import (
+	"fmt"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Version is the server version reported by INFO.
+const Version = "0.1.0"
+
+// serverStats holds the counters reported in the INFO stats section.
+type serverStats struct {
+	commands       atomic.Int64
+	keyspaceHits   atomic.Int64
+	keyspaceMisses atomic.Int64
+	expiredKeys    atomic.Int64
+}
+
+// recordLookup counts a key read by a command as a keyspace hit or miss
+// from the error returned by the storage.
+func (s *serverStats) recordLookup(err error) {
+	if err == storage.ErrKeyNotFound || err == storage.ErrKeyExpired {
+		s.keyspaceMisses.Add(1)
+		return
+	}
+	s.keyspaceHits.Add(1)
+}
+
+func (s *serverStats) reset() {
+	s.commands.Store(0)
+	s.keyspaceHits.Store(0)
+	s.keyspaceMisses.Store(0)
+	s.expiredKeys.Store(0)
+}
+
+// infoSections lists the INFO sections in the order they are reported.
+var infoSections = []string{"server", "clients", "memory", "persistence", "stats", "keyspace"}
+
+// Server commands
+
+func (e *Executor) info(cmd *Command) protocol.Value {
+	selected := make(map[string]bool)
+	for _, arg := range cmd.Args {
+		section := strings.ToLower(arg)
+		if section == "all" || section == "everything" || section == "default" {
+			for _, name := range infoSections {
+				selected[name] = true
+			}
+			continue
+		}
+		selected[section] = true
+	}
+	if len(cmd.Args) == 0 {
+		for _, name := range infoSections {
+			selected[name] = true
+		}
+	}
+
+	var sections []string
+	for _, name := range infoSections {
+		if selected[name] {
+			sections = append(sections, e.infoSection(name))
+		}
+	}
+
+	return protocol.Value{
+		Type: protocol.BulkString,
+		Bulk: strings.Join(sections, "\r\n"),
+	}
+}
+
+func (e *Executor) infoSection(name string) string {
+	var b strings.Builder
+	field := func(key string, value interface{}) {
+		fmt.Fprintf(&b, "%s:%v\r\n", key, value)
+	}
+
+	switch name {
+	case "server":
+		b.WriteString("# Server\r\n")
+		uptime := time.Since(e.startTime)
+		field("redis_version", Version)
+		field("redis_mode", "standalone")
+		field("os", runtime.GOOS+" "+runtime.GOARCH)
+		field("arch_bits", strconv.IntSize)
+		field("go_version", runtime.Version())
+		field("process_id", os.Getpid())
+		field("uptime_in_seconds", int64(uptime/time.Second))
+		field("uptime_in_days", int64(uptime/(24*time.Hour)))
+
+	case "clients":
+		b.WriteString("# Clients\r\n")
+		field("connected_clients", e.clients.Len())
+		field("maxclients", e.clients.MaxClients())
+		field("pubsub_clients", e.pubsub.NumClients())
+
+	case "memory":
+		// The Go runtime does not track memory per key, so the heap
+		// figures are the best available estimate of the dataset size.
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		b.WriteString("# Memory\r\n")
+		field("used_memory", mem.HeapAlloc)
+		field("used_memory_human", humanBytes(mem.HeapAlloc))
+		field("used_memory_rss", mem.Sys)
+		field("used_memory_rss_human", humanBytes(mem.Sys))
+		field("mem_allocator", "go")
+		field("gc_cycles", mem.NumGC)
+		// Removed values are left to the garbage collector.
+		field("lazyfree_pending_objects", 0)
+
+	case "persistence":
+		b.WriteString("# Persistence\r\n")
+		stats, enabled := e.persistenceStats()
+		status := "ok"
+		if !stats.LastSaveOK {
+			status = "err"
+		}
+		field("loading", 0)
+		field("rdb_enabled", boolToInt(enabled))
+		field("rdb_changes_since_last_save", stats.ChangesSinceSave)
+		field("rdb_bgsave_in_progress", boolToInt(stats.InProgress))
+		field("rdb_last_save_time", stats.LastSave.Unix())
+		field("rdb_last_bgsave_status", status)
+		field("rdb_last_bgsave_time_sec", int64(stats.LastSaveDuration.Seconds()))
+		field("rdb_saves", stats.Saves)
+
+	case "stats":
+		b.WriteString("# Stats\r\n")
+		field("total_connections_received", e.clients.TotalConnections())
+		field("total_commands_processed", e.stats.commands.Load())
+		field("rejected_connections", e.clients.RejectedConnections())
+		field("client_output_buffer_limit_disconnections", e.clients.OutputLimitDisconnections())
+		field("expired_keys", e.stats.expiredKeys.Load())
+		field("lazyfreed_objects", 0)
+		// Keys are never evicted: there is no memory limit.
+		field("evicted_keys", 0)
+		field("keyspace_hits", e.stats.keyspaceHits.Load())
+		field("keyspace_misses", e.stats.keyspaceMisses.Load())
+		field("pubsub_channels", len(e.pubsub.Channels("")))
+		field("pubsub_patterns", e.pubsub.NumPat())
+
+	case "keyspace":
+		b.WriteString("# Keyspace\r\n")
+		if stats, ok := e.storage.(interface{ KeyspaceStats() storage.KeyspaceStats }); ok {
+			keyspace := stats.KeyspaceStats()
+			if keyspace.Keys > 0 {
+				fmt.Fprintf(&b, "db0:keys=%d,expires=%d,avg_ttl=0\r\n", keyspace.Keys, keyspace.Expires)
+			}
+		}
+	}
+	return b.String()
+}
+
+// persistenceStats returns the snapshot statistics of the storage. When
+// persistence is disabled the last save time is the server start time.
+func (e *Executor) persistenceStats() (storage.PersistenceStats, bool) {
+	if persistence, ok := e.storage.(interface {
+		PersistenceStats() (storage.PersistenceStats, bool)
+	}); ok {
+		if stats, enabled := persistence.PersistenceStats(); enabled {
+			return stats, true
+		}
+	}
+	return storage.PersistenceStats{LastSave: e.startTime, LastSaveOK: true}, false
+}
+
+func humanBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value, suffix := float64(n)/unit, "K"
+	for _, s := range []string{"M", "G", "T"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, s
+	}
+	return fmt.Sprintf("%.2f%s", value, suffix)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+// infoFields parses an INFO reply into its sections' fields.
+func infoFields(t *testing.T, executor *Executor, args ...string) map[string]string {
+	t.Helper()
+
+	reply := roundTrip(t, executor, append([]string{"INFO"}, args...)...)
+	fields := make(map[string]string)
+	for _, line := range strings.Split(reply.Bulk, "\r\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			t.Fatalf("Malformed INFO line %q", line)
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+func TestInfo(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	executor := NewExecutor(store, NewClientRegistry(100))
+
+	roundTrip(t, executor, "SET", "a", "1")
+	roundTrip(t, executor, "SET", "b", "2", "EX", "100")
+	roundTrip(t, executor, "HSET", "h", "f", "v")
+	roundTrip(t, executor, "GET", "a")
+	roundTrip(t, executor, "GET", "missing")
+	roundTrip(t, executor, "HGET", "h", "other")
+
+	store.SetWithTTL("short", "x", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	store.CleanupExpired()
+
+	fields := infoFields(t, executor)
+	expected := map[string]string{
+		"redis_version":     Version,
+		"maxclients":        "100",
+		"keyspace_hits":     "2",
+		"keyspace_misses":   "1",
+		"expired_keys":      "1",
+		"rdb_enabled":       "0",
+		"db0":               "keys=3,expires=1,avg_ttl=0",
+		"pubsub_patterns":   "0",
+		"connected_clients": "0",
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("INFO %s: expected %q, got %q", key, value, fields[key])
+		}
+	}
+	// The INFO command itself is counted once it completes.
+	if fields["total_commands_processed"] != "6" {
+		t.Errorf("Expected 6 commands processed, got %q", fields["total_commands_processed"])
+	}
+
+	// Sections can be selected by name.
+	fields = infoFields(t, executor, "keyspace", "CLIENTS")
+	if _, ok := fields["db0"]; !ok {
+		t.Error("INFO keyspace clients: missing keyspace section")
+	}
+	if _, ok := fields["connected_clients"]; !ok {
+		t.Error("INFO keyspace clients: missing clients section")
+	}
+	if _, ok := fields["redis_version"]; ok {
+		t.Error("INFO keyspace clients: unexpected server section")
+	}
+}
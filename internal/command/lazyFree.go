@@ -0,0 +1,40 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+)
+
+// deleteKeys removes keys and returns the number removed.
+func (e *Executor) deleteKeys(keys []string) int {
+	deleted := 0
+	for _, key := range keys {
+		if e.storage.Delete(key) {
+			deleted++
+			e.signalModifiedKey(key)
+			e.notifyKeyspaceEvent(notifyGeneric, "del", key)
+		}
+	}
+	return deleted
+}
+
+// unlink implements UNLINK as an alias of DEL. Removing a key only drops
+// the keyspace's reference to the value, which the garbage collector
+// frees in the background anyway.
+func (e *Executor) unlink(cmd *Command) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Integer,
+		Num:  e.deleteKeys(cmd.Args),
+	}
+}
+
+// flush implements FLUSHDB and FLUSHALL, which are the same with a single
+// database. ASYNC and SYNC are accepted and behave alike, as replacing the
+// keyspace takes constant time.
+func (e *Executor) flush(cmd *Command) protocol.Value {
+	e.storage.Clear()
+	e.watches.touchAll()
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
@@ -0,0 +1,25 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/storage"
+	"testing"
+)
+
+func TestUnlinkAndFlush(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	executor := NewExecutor(store, NewClientRegistry(0))
+
+	execute(executor, nil, "SET", "a", "1")
+	execute(executor, nil, "SADD", "b", "x")
+	expectInteger(t, execute(executor, nil, "UNLINK", "a", "b", "missing"), 2)
+
+	for _, args := range [][]string{{"FLUSHDB"}, {"FLUSHDB", "async"}, {"FLUSHALL", "SYNC"}, {"FLUSHALL", "ASYNC"}} {
+		execute(executor, nil, "SET", "a", "1")
+		expectOK(t, execute(executor, nil, args...))
+		if store.Size() != 0 {
+			t.Errorf("%v: expected an empty keyspace", args)
+		}
+	}
+	expectErrorPrefix(t, execute(executor, nil, "FLUSHDB", "LATER"), "ERR syntax error")
+	expectErrorPrefix(t, execute(executor, nil, "FLUSHALL", "ASYNC", "SYNC"), "ERR syntax error")
+}
@@ -20,6 +20,9 @@ func (e *Executor) lpush(cmd *Command) protocol.Value {
 		}
 	}
 
+	e.signalModifiedKey(key)
+	e.notifyKeyspaceEvent(notifyList, "lpush", key)
+
 	return protocol.Value{
 		Type: protocol.Integer,
 		Num:  length,
@@ -38,6 +41,9 @@ func (e *Executor) rpush(cmd *Command) protocol.Value {
 		}
 	}
 
+	e.signalModifiedKey(key)
+	e.notifyKeyspaceEvent(notifyList, "rpush", key)
+
 	return protocol.Value{
 		Type: protocol.Integer,
 		Num:  length,
@@ -45,7 +51,8 @@ func (e *Executor) rpush(cmd *Command) protocol.Value {
 }
 
 func (e *Executor) lpop(cmd *Command) protocol.Value {
-	value, err := e.storage.LPop(cmd.Args[0])
+	key := cmd.Args[0]
+	value, err := e.storage.LPop(key)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -59,6 +66,9 @@ func (e *Executor) lpop(cmd *Command) protocol.Value {
 		}
 	}
 
+	e.signalModifiedKey(key)
+	e.notifyKeyspaceEvent(notifyList, "lpop", key)
+
 	return protocol.Value{
 		Type: protocol.BulkString,
 		Bulk: value,
@@ -66,7 +76,8 @@ func (e *Executor) lpop(cmd *Command) protocol.Value {
 }
 
 func (e *Executor) rpop(cmd *Command) protocol.Value {
-	value, err := e.storage.RPop(cmd.Args[0])
+	key := cmd.Args[0]
+	value, err := e.storage.RPop(key)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -80,6 +91,9 @@ func (e *Executor) rpop(cmd *Command) protocol.Value {
 		}
 	}
 
+	e.signalModifiedKey(key)
+	e.notifyKeyspaceEvent(notifyList, "rpop", key)
+
 	return protocol.Value{
 		Type: protocol.BulkString,
 		Bulk: value,
@@ -88,6 +102,7 @@ func (e *Executor) rpop(cmd *Command) protocol.Value {
 
 func (e *Executor) llen(cmd *Command) protocol.Value {
 	length, err := e.storage.LLen(cmd.Args[0])
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -125,6 +140,7 @@ func (e *Executor) lrange(cmd *Command) protocol.Value {
 	}
 
 	elements, err := e.storage.LRange(cmd.Args[0], start, stop)
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -0,0 +1,142 @@
+package command
+
+import (
+	"fmt"
+	"ivanSaichkin/myredis/internal/protocol"
+	"strings"
+	"sync"
+	"time"
+)
+
+// monitorFeed sends every executed command to the clients that ran
+// MONITOR. Lines are queued with Client.Push, so a monitor that cannot keep
+// up is disconnected instead of slowing down command execution.
+type monitorFeed struct {
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+}
+
+func newMonitorFeed() *monitorFeed {
+	return &monitorFeed{
+		clients: make(map[*Client]struct{}),
+	}
+}
+
+func (m *monitorFeed) add(client *Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clients[client] = struct{}{}
+}
+
+func (m *monitorFeed) remove(client *Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.clients, client)
+}
+
+// feed sends cmd, run by client, to every monitor but client itself.
+// Admin commands and commands carrying secrets are not sent.
+func (m *monitorFeed) feed(client *Client, cmd *Command) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.clients) == 0 {
+		return
+	}
+	if secretCommands[cmd.FullName()] || isAdminCommand(cmd) {
+		return
+	}
+
+	var line protocol.Value
+	for monitor := range m.clients {
+		if monitor == client {
+			continue
+		}
+		if line.Type == 0 {
+			line = protocol.Value{
+				Type: protocol.SimpleString,
+				Str:  monitorLine(client, cmd, time.Now()),
+			}
+		}
+		monitor.Push(line)
+	}
+}
+
+// isAdminCommand reports whether cmd, or the subcommand it runs, is an
+// admin command.
+func isAdminCommand(cmd *Command) bool {
+	spec := lookupCommand(cmd)
+	return spec != nil && spec.flags&flagAdmin != 0
+}
+
+// monitorLine formats cmd the way Redis' MONITOR does, e.g.
+// 1339518083.107412 [0 127.0.0.1:60866] "set" "key" "value".
+func monitorLine(client *Client, cmd *Command, now time.Time) string {
+	var b strings.Builder
+
+	db, addr := 0, "internal"
+	if client != nil {
+		db, addr = client.DB(), client.Addr
+	}
+	fmt.Fprintf(&b, "%d.%06d [%d %s]", now.Unix(), now.Nanosecond()/1000, db, addr)
+
+	b.WriteString(" ")
+	b.WriteString(quoteArg(strings.ToLower(cmd.Name)))
+	for _, arg := range cmd.Args {
+		b.WriteString(" ")
+		b.WriteString(quoteArg(arg))
+	}
+	return b.String()
+}
+
+// quoteArg quotes s with C-style escapes for non-printable bytes, so that
+// binary arguments fit on one line.
+func quoteArg(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				fmt.Fprintf(&b, `\x%02x`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// Server commands
+
+func (e *Executor) monitor(client *Client, cmd *Command) protocol.Value {
+	if client == nil {
+		return connectionRequired(cmd)
+	}
+
+	if !client.IsMonitor() {
+		client.setMonitor()
+		e.monitors.add(client)
+	}
+	// From now on replies share the queue with the feed.
+	client.Push(protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	})
+	return NoReply
+}
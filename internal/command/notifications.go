@@ -0,0 +1,113 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keyspace notification classes, selected with the same flag characters
+// as Redis' notify-keyspace-events setting.
+const (
+	notifyKeyspace = 1 << iota // K: __keyspace@<db>__:<key> channels
+	notifyKeyevent             // E: __keyevent@<db>__:<event> channels
+	notifyGeneric              // g: del, expire
+	notifyString               // $: set
+	notifyList                 // l: lpush, rpush, lpop, rpop
+	notifySet                  // s: sadd, srem
+	notifyHash                 // h: hset, hdel
+	notifyExpired              // x: expired
+	notifyEvicted              // e: evicted
+
+	notifyAll = notifyGeneric | notifyString | notifyList | notifySet | notifyHash | notifyExpired | notifyEvicted
+)
+
+var notifyFlagChars = []struct {
+	char byte
+	flag int
+}{
+	{'g', notifyGeneric},
+	{'$', notifyString},
+	{'l', notifyList},
+	{'s', notifySet},
+	{'h', notifyHash},
+	{'x', notifyExpired},
+	{'e', notifyEvicted},
+	{'K', notifyKeyspace},
+	{'E', notifyKeyevent},
+}
+
+func parseNotifyFlags(s string) (int, error) {
+	flags := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == 'A' {
+			flags |= notifyAll
+			continue
+		}
+
+		found := false
+		for _, fc := range notifyFlagChars {
+			if fc.char == s[i] {
+				flags |= fc.flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid keyspace event class %q", s[i])
+		}
+	}
+	return flags, nil
+}
+
+func formatNotifyFlags(flags int) string {
+	var b strings.Builder
+	if flags&notifyAll == notifyAll {
+		b.WriteByte('A')
+	}
+	for _, fc := range notifyFlagChars {
+		if fc.flag&notifyAll != 0 && flags&notifyAll == notifyAll {
+			continue
+		}
+		if flags&fc.flag != 0 {
+			b.WriteByte(fc.char)
+		}
+	}
+	return b.String()
+}
+
+// SetNotifyKeyspaceEvents selects the keyspace notifications to publish,
+// e.g. "KEA" for all events or "Ex" for key expirations only. Notifications
+// are only published if K or E is given together with an event class.
+func (e *Executor) SetNotifyKeyspaceEvents(classes string) error {
+	flags, err := parseNotifyFlags(classes)
+	if err != nil {
+		return err
+	}
+	e.notifyFlags.Store(int64(flags))
+	return nil
+}
+
+// ValidateNotifyKeyspaceEvents checks classes without applying them.
+func ValidateNotifyKeyspaceEvents(classes string) error {
+	_, err := parseNotifyFlags(classes)
+	return err
+}
+
+func (e *Executor) NotifyKeyspaceEvents() string {
+	return formatNotifyFlags(int(e.notifyFlags.Load()))
+}
+
+// notifyKeyspaceEvent publishes event for key if the class is enabled.
+func (e *Executor) notifyKeyspaceEvent(class int, event, key string) {
+	flags := int(e.notifyFlags.Load())
+	if flags&class == 0 {
+		return
+	}
+
+	if flags&notifyKeyspace != 0 {
+		e.pubsub.Publish("__keyspace@0__:"+key, event)
+	}
+	if flags&notifyKeyevent != 0 {
+		e.pubsub.Publish("__keyevent@0__:"+event, key)
+	}
+}
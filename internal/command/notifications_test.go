@@ -0,0 +1,31 @@
+package command
+
+import "testing"
+
+func TestNotifyFlags(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"KEA", "AKE"},
+		{"Ex", "xE"},
+		{"Kg$lshxe", "AK"},
+		{"K$h", "$hK"},
+	}
+
+	for _, tt := range tests {
+		flags, err := parseNotifyFlags(tt.input)
+		if err != nil {
+			t.Errorf("parseNotifyFlags(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got := formatNotifyFlags(flags); got != tt.expected {
+			t.Errorf("formatNotifyFlags(parseNotifyFlags(%q)) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+
+	if _, err := parseNotifyFlags("KZ"); err == nil {
+		t.Error("Expected an error for an unknown event class")
+	}
+}
@@ -0,0 +1,147 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClientClass groups clients for output buffer limits.
+type ClientClass int
+
+const (
+	ClientNormal ClientClass = iota
+	ClientPubSub
+	// ClientReplica is reserved for replicas; the server has none yet.
+	ClientReplica
+)
+
+var clientClassNames = []string{"normal", "pubsub", "replica"}
+
+func (c ClientClass) String() string {
+	return clientClassNames[c]
+}
+
+func ParseClientClass(name string) (ClientClass, error) {
+	for i, className := range clientClassNames {
+		if strings.EqualFold(name, className) {
+			return ClientClass(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid client class %q, must be one of %s", name, strings.Join(clientClassNames, ", "))
+}
+
+// OutputBufferLimit bounds the bytes of replies waiting to be written to a
+// client. A client is disconnected as soon as it exceeds Hard, or once it
+// has stayed above Soft for SoftDuration. Zero disables a limit.
+type OutputBufferLimit struct {
+	Hard         int64
+	Soft         int64
+	SoftDuration time.Duration
+}
+
+// SetOutputBufferLimit sets the limit of a client class.
+func (r *ClientRegistry) SetOutputBufferLimit(class ClientClass, limit OutputBufferLimit) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outputLimits[class] = limit
+}
+
+func (r *ClientRegistry) OutputBufferLimit(class ClientClass) OutputBufferLimit {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.outputLimits[class]
+}
+
+// OutputLimitDisconnections returns the number of clients disconnected
+// for exceeding an output buffer limit.
+func (r *ClientRegistry) OutputLimitDisconnections() int64 {
+	return r.outputLimitDisconnections.Load()
+}
+
+// Class returns the class whose output buffer limit applies to the client.
+func (c *Client) Class() ClientClass {
+	if c.SubscriptionCount() > 0 {
+		return ClientPubSub
+	}
+	return ClientNormal
+}
+
+// OutputLimit returns the output buffer limit that applies to the client.
+func (c *Client) OutputLimit() OutputBufferLimit {
+	if c.registry == nil {
+		return OutputBufferLimit{}
+	}
+	return c.registry.OutputBufferLimit(c.Class())
+}
+
+// OutputBytes returns the bytes of replies waiting to be written.
+func (c *Client) OutputBytes() int64 {
+	return c.outputBytes.Load()
+}
+
+// ReserveOutput accounts for n bytes about to be written to the client.
+// If that exceeds the client's output buffer limit, the client is
+// disconnected and false is returned.
+func (c *Client) ReserveOutput(n int64) bool {
+	pending := c.outputBytes.Add(n)
+	limit := c.OutputLimit()
+
+	if limit.Hard > 0 && pending > limit.Hard {
+		c.outputBytes.Add(-n)
+		c.DisconnectForOutputLimit(fmt.Sprintf("output buffer of %d bytes exceeds the hard limit of %d bytes", pending, limit.Hard))
+		return false
+	}
+
+	c.mu.Lock()
+	if limit.Soft <= 0 || pending <= limit.Soft {
+		c.softLimitSince = time.Time{}
+		c.mu.Unlock()
+		return true
+	}
+	if c.softLimitSince.IsZero() {
+		c.softLimitSince = time.Now()
+	}
+	over := time.Since(c.softLimitSince)
+	c.mu.Unlock()
+
+	if over >= limit.SoftDuration {
+		c.outputBytes.Add(-n)
+		c.DisconnectForOutputLimit(fmt.Sprintf("output buffer above the soft limit of %d bytes for %v", limit.Soft, over.Round(time.Millisecond)))
+		return false
+	}
+	return true
+}
+
+// ReleaseOutput accounts for n reserved bytes that have been written.
+func (c *Client) ReleaseOutput(n int64) {
+	c.outputBytes.Add(-n)
+}
+
+// DisconnectForOutputLimit closes the connection of a client that exceeded
+// its output buffer limit and counts it.
+func (c *Client) DisconnectForOutputLimit(reason string) {
+	if c.registry != nil {
+		c.registry.outputLimitDisconnections.Add(1)
+	}
+	c.CloseWithReason(reason)
+}
+
+// CloseWithReason closes the connection and records why, so that the
+// connection handler can log it.
+func (c *Client) CloseWithReason(reason string) {
+	c.mu.Lock()
+	if c.closeReason == "" {
+		c.closeReason = reason
+	}
+	c.mu.Unlock()
+	c.Close()
+}
+
+// CloseReason returns why the server closed the connection, empty if it
+// did not or gave no reason.
+func (c *Client) CloseReason() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeReason
+}
@@ -0,0 +1,73 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputBufferLimits(t *testing.T) {
+	registry := NewClientRegistry(0)
+	registry.SetOutputBufferLimit(ClientNormal, OutputBufferLimit{Hard: 1000, Soft: 500, SoftDuration: 50 * time.Millisecond})
+	message := protocol.Value{Type: protocol.BulkString, Bulk: strings.Repeat("x", 192)}
+
+	// The hard limit disconnects at once.
+	client, _ := registerPipeClient(t, registry)
+	for i := 0; i < 4; i++ {
+		if !client.Push(message) {
+			t.Fatalf("Push %d: expected the message to be queued", i)
+		}
+	}
+	if client.OutputBytes() != 800 {
+		t.Errorf("Expected 800 pending bytes, got %d", client.OutputBytes())
+	}
+	if !client.Push(message) || client.Push(message) {
+		t.Error("Expected the sixth message to exceed the hard limit")
+	}
+	if reason := client.CloseReason(); !strings.Contains(reason, "hard limit of 1000 bytes") {
+		t.Errorf("Unexpected close reason %q", reason)
+	}
+
+	// The soft limit disconnects once exceeded for long enough, and is
+	// reset when the buffer drains.
+	client, _ = registerPipeClient(t, registry)
+	for i := 0; i < 3; i++ {
+		client.Push(message)
+	}
+	time.Sleep(60 * time.Millisecond)
+	client.ReleaseOutput(400)
+	if !client.Push(message) {
+		t.Fatal("Expected the soft limit timer to restart after draining")
+	}
+	if !client.Push(message) {
+		t.Fatal("Expected the soft limit to allow a short burst")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if client.Push(message) {
+		t.Error("Expected the soft limit to disconnect the client")
+	}
+	if reason := client.CloseReason(); !strings.Contains(reason, "soft limit of 500 bytes") {
+		t.Errorf("Unexpected close reason %q", reason)
+	}
+
+	if n := registry.OutputLimitDisconnections(); n != 2 {
+		t.Errorf("Expected 2 disconnections, got %d", n)
+	}
+	if !strings.Contains(client.Info(), " omem=") {
+		t.Errorf("Expected CLIENT LIST to report omem, got %q", client.Info())
+	}
+}
+
+func TestOutputQueueFull(t *testing.T) {
+	client, _ := registerPipeClient(t, NewClientRegistry(0))
+	for i := 0; i < messageQueueSize; i++ {
+		client.Push(protocol.Value{Type: protocol.Integer, Num: i})
+	}
+	if client.Push(protocol.Value{Type: protocol.Integer}) {
+		t.Error("Expected a full queue to disconnect the client")
+	}
+	if reason := client.CloseReason(); !strings.Contains(reason, "output queue") {
+		t.Errorf("Unexpected close reason %q", reason)
+	}
+}
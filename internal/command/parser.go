@@ -10,6 +10,16 @@ type Command struct {
 	Args []string
 }
 
+// FullName returns the lowercase command name, qualified with the
+// subcommand for container commands such as CLIENT and ACL, e.g. "client|list".
+func (c *Command) FullName() string {
+	name := strings.ToLower(c.Name)
+	if spec := commandTable[name]; spec != nil && spec.subcommands != nil && len(c.Args) > 0 {
+		name += "|" + strings.ToLower(c.Args[0])
+	}
+	return name
+}
+
 type Parser struct {
 	reader *protocol.RESPReader
 }
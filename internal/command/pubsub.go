@@ -0,0 +1,170 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"sort"
+	"sync"
+)
+
+// messageQueueSize bounds the replies and messages waiting to be written
+// to a client in subscriber mode. A client that falls this far behind is
+// disconnected rather than slowing down publishers.
+const messageQueueSize = 1024
+
+// NoReply is returned by Execute when the command's replies have been
+// queued on the client, e.g. one confirmation per channel for SUBSCRIBE.
+var NoReply = protocol.Value{}
+
+func IsNoReply(v protocol.Value) bool {
+	return v.Type == 0
+}
+
+// PubSub routes published messages to the clients subscribed to a channel
+// or to a pattern matching it.
+type PubSub struct {
+	mu       sync.RWMutex
+	channels map[string]map[*Client]struct{}
+	patterns map[string]map[*Client]struct{}
+}
+
+func NewPubSub() *PubSub {
+	return &PubSub{
+		channels: make(map[string]map[*Client]struct{}),
+		patterns: make(map[string]map[*Client]struct{}),
+	}
+}
+
+func (p *PubSub) Subscribe(client *Client, channel string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	addSubscriber(p.channels, channel, client)
+	client.addSubscription(channel, false)
+}
+
+func (p *PubSub) Unsubscribe(client *Client, channel string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	removeSubscriber(p.channels, channel, client)
+	client.removeSubscription(channel, false)
+}
+
+func (p *PubSub) PSubscribe(client *Client, pattern string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	addSubscriber(p.patterns, pattern, client)
+	client.addSubscription(pattern, true)
+}
+
+func (p *PubSub) PUnsubscribe(client *Client, pattern string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	removeSubscriber(p.patterns, pattern, client)
+	client.removeSubscription(pattern, true)
+}
+
+// UnsubscribeAll removes every subscription of a disconnecting client.
+func (p *PubSub) UnsubscribeAll(client *Client) {
+	channels, patterns := client.subscriptions()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, channel := range channels {
+		removeSubscriber(p.channels, channel, client)
+		client.removeSubscription(channel, false)
+	}
+	for _, pattern := range patterns {
+		removeSubscriber(p.patterns, pattern, client)
+		client.removeSubscription(pattern, true)
+	}
+}
+
+// Publish queues message for every subscriber of channel and returns the
+// number of clients that received it. Subscribers whose queue is full are
+// disconnected.
+func (p *PubSub) Publish(channel, message string) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	receivers := 0
+	for client := range p.channels[channel] {
+		if client.Push(bulkArray([]string{"message", channel, message})) {
+			receivers++
+		}
+	}
+	for pattern, clients := range p.patterns {
+		if !globMatch(pattern, channel) {
+			continue
+		}
+		for client := range clients {
+			if client.Push(bulkArray([]string{"pmessage", pattern, channel, message})) {
+				receivers++
+			}
+		}
+	}
+	return receivers
+}
+
+// Channels returns the sorted channels with at least one subscriber that
+// match pattern; an empty pattern matches all channels.
+func (p *PubSub) Channels(pattern string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var channels []string
+	for channel := range p.channels {
+		if pattern == "" || globMatch(pattern, channel) {
+			channels = append(channels, channel)
+		}
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+func (p *PubSub) NumSub(channel string) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.channels[channel])
+}
+
+// NumPat returns the number of distinct subscribed patterns.
+func (p *PubSub) NumPat() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.patterns)
+}
+
+// NumClients returns the number of clients with at least one subscription.
+func (p *PubSub) NumClients() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	clients := make(map[*Client]struct{})
+	for _, subscriptions := range []map[string]map[*Client]struct{}{p.channels, p.patterns} {
+		for _, subscribers := range subscriptions {
+			for client := range subscribers {
+				clients[client] = struct{}{}
+			}
+		}
+	}
+	return len(clients)
+}
+
+func addSubscriber(subscriptions map[string]map[*Client]struct{}, name string, client *Client) {
+	clients, ok := subscriptions[name]
+	if !ok {
+		clients = make(map[*Client]struct{})
+		subscriptions[name] = clients
+	}
+	clients[client] = struct{}{}
+}
+
+func removeSubscriber(subscriptions map[string]map[*Client]struct{}, name string, client *Client) {
+	clients, ok := subscriptions[name]
+	if !ok {
+		return
+	}
+	delete(clients, client)
+	if len(clients) == 0 {
+		delete(subscriptions, name)
+	}
+}
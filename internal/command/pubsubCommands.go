@@ -0,0 +1,173 @@
+package command
+
+import "ivanSaichkin/myredis/internal/protocol"
+
+// subscriberCommands may be run while a client has subscriptions.
+var subscriberCommands = map[string]bool{
+	"SUBSCRIBE":    true,
+	"UNSUBSCRIBE":  true,
+	"PSUBSCRIBE":   true,
+	"PUNSUBSCRIBE": true,
+	"PING":         true,
+}
+
+// Pub/Sub commands
+
+func (e *Executor) subscribe(client *Client, cmd *Command) protocol.Value {
+	if client == nil {
+		return connectionRequired(cmd)
+	}
+
+	for _, channel := range cmd.Args {
+		e.pubsub.Subscribe(client, channel)
+		client.Push(subscriptionReply("subscribe", channel, client.SubscriptionCount()))
+	}
+	return NoReply
+}
+
+func (e *Executor) unsubscribe(client *Client, cmd *Command) protocol.Value {
+	if client == nil {
+		return connectionRequired(cmd)
+	}
+
+	channels := cmd.Args
+	if len(channels) == 0 {
+		channels, _ = client.subscriptions()
+	}
+	return e.unsubscribeReplies(client, "unsubscribe", channels, e.pubsub.Unsubscribe)
+}
+
+func (e *Executor) psubscribe(client *Client, cmd *Command) protocol.Value {
+	if client == nil {
+		return connectionRequired(cmd)
+	}
+
+	for _, pattern := range cmd.Args {
+		e.pubsub.PSubscribe(client, pattern)
+		client.Push(subscriptionReply("psubscribe", pattern, client.SubscriptionCount()))
+	}
+	return NoReply
+}
+
+func (e *Executor) punsubscribe(client *Client, cmd *Command) protocol.Value {
+	if client == nil {
+		return connectionRequired(cmd)
+	}
+
+	patterns := cmd.Args
+	if len(patterns) == 0 {
+		_, patterns = client.subscriptions()
+	}
+	return e.unsubscribeReplies(client, "punsubscribe", patterns, e.pubsub.PUnsubscribe)
+}
+
+// unsubscribeReplies confirms every removed subscription. Unsubscribing
+// without any subscriptions still gets a single confirmation.
+func (e *Executor) unsubscribeReplies(client *Client, kind string, names []string, unsubscribe func(*Client, string)) protocol.Value {
+	if len(names) == 0 {
+		reply := protocol.Value{
+			Type: protocol.Array,
+			Array: []protocol.Value{
+				{Type: protocol.BulkString, Bulk: kind},
+				{Type: protocol.BulkString, IsNull: true},
+				{Type: protocol.Integer, Num: client.SubscriptionCount()},
+			},
+		}
+		if client.Messages() == nil {
+			return reply
+		}
+		client.Push(reply)
+		return NoReply
+	}
+
+	for _, name := range names {
+		unsubscribe(client, name)
+		client.Push(subscriptionReply(kind, name, client.SubscriptionCount()))
+	}
+	return NoReply
+}
+
+func (e *Executor) publish(cmd *Command) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Integer,
+		Num:  e.pubsub.Publish(cmd.Args[0], cmd.Args[1]),
+	}
+}
+
+func (e *Executor) pubsubChannels(cmd *Command) protocol.Value {
+	if len(cmd.Args) > 2 {
+		return wrongSubcommandArgs("pubsub|channels")
+	}
+	pattern := ""
+	if len(cmd.Args) == 2 {
+		pattern = cmd.Args[1]
+	}
+	return bulkArray(e.pubsub.Channels(pattern))
+}
+
+func (e *Executor) pubsubNumSub(cmd *Command) protocol.Value {
+	result := make([]protocol.Value, 0, 2*(len(cmd.Args)-1))
+	for _, channel := range cmd.Args[1:] {
+		result = append(result,
+			protocol.Value{Type: protocol.BulkString, Bulk: channel},
+			protocol.Value{Type: protocol.Integer, Num: e.pubsub.NumSub(channel)},
+		)
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: result,
+	}
+}
+
+func (e *Executor) pubsubNumPat(cmd *Command) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Integer,
+		Num:  e.pubsub.NumPat(),
+	}
+}
+
+// subscriberPing is PING in subscriber mode, which replies with an array
+// so it can be told apart from messages.
+func (e *Executor) subscriberPing(cmd *Command) protocol.Value {
+	message := ""
+	if len(cmd.Args) > 0 {
+		message = cmd.Args[0]
+	}
+	return bulkArray([]string{"pong", message})
+}
+
+func subscriptionReply(kind, name string, count int) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Array,
+		Array: []protocol.Value{
+			{Type: protocol.BulkString, Bulk: kind},
+			{Type: protocol.BulkString, Bulk: name},
+			{Type: protocol.Integer, Num: count},
+		},
+	}
+}
+
+func connectionRequired(cmd *Command) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Error,
+		Str:  "ERR " + cmd.Name + " is not available without a connection",
+	}
+}
+
+// channelsToAuthorize returns the channels or, when patterns is set, the
+// patterns cmd accesses for ACL channel checks.
+func channelsToAuthorize(cmd *Command) (names []string, patterns bool) {
+	switch cmd.Name {
+	case "SUBSCRIBE":
+		return cmd.Args, false
+	case "PSUBSCRIBE":
+		return cmd.Args, true
+	case "PUBLISH":
+		if len(cmd.Args) > 0 {
+			return cmd.Args[:1], false
+		}
+		return nil, false
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"strings"
+)
+
+type ShutdownMode int
+
+const (
+	// ShutdownDefault saves a final snapshot when persistence is enabled.
+	ShutdownDefault ShutdownMode = iota
+	ShutdownSave
+	ShutdownNoSave
+)
+
+// ShutdownController is implemented by the server to let SHUTDOWN start or
+// abort a graceful shutdown.
+type ShutdownController interface {
+	RequestShutdown(mode ShutdownMode) error
+	AbortShutdown() error
+}
+
+func (e *Executor) SetShutdownController(controller ShutdownController) {
+	e.shutdownController = controller
+}
+
+// Server commands
+
+func (e *Executor) shutdown(client *Client, cmd *Command) protocol.Value {
+	if e.shutdownController == nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR SHUTDOWN is not available",
+		}
+	}
+
+	mode := ShutdownDefault
+	abort := false
+	for _, arg := range cmd.Args {
+		switch strings.ToUpper(arg) {
+		case "SAVE":
+			mode = ShutdownSave
+		case "NOSAVE":
+			mode = ShutdownNoSave
+		case "ABORT":
+			abort = true
+		default:
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR syntax error",
+			}
+		}
+	}
+
+	if abort {
+		if len(cmd.Args) > 1 {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR syntax error",
+			}
+		}
+		if err := e.shutdownController.AbortShutdown(); err != nil {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR " + err.Error(),
+			}
+		}
+		return protocol.Value{
+			Type: protocol.SimpleString,
+			Str:  "OK",
+		}
+	}
+
+	if err := e.shutdownController.RequestShutdown(mode); err != nil {
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR Errors trying to SHUTDOWN: " + err.Error(),
+		}
+	}
+
+	if client != nil {
+		client.markCloseAfterReply()
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
@@ -19,6 +19,11 @@ func (e *Executor) sadd(cmd *Command) protocol.Value {
 		}
 	}
 
+	if added > 0 {
+		e.signalModifiedKey(key)
+		e.notifyKeyspaceEvent(notifySet, "sadd", key)
+	}
+
 	return protocol.Value{
 		Type: protocol.Integer,
 		Num:  added,
@@ -43,6 +48,11 @@ func (e *Executor) srem(cmd *Command) protocol.Value {
 		}
 	}
 
+	if removed > 0 {
+		e.signalModifiedKey(key)
+		e.notifyKeyspaceEvent(notifySet, "srem", key)
+	}
+
 	return protocol.Value{
 		Type: protocol.Integer,
 		Num:  removed,
@@ -51,6 +61,7 @@ func (e *Executor) srem(cmd *Command) protocol.Value {
 
 func (e *Executor) sismember(cmd *Command) protocol.Value {
 	isMember, err := e.storage.SIsMember(cmd.Args[0], cmd.Args[1])
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -77,6 +88,7 @@ func (e *Executor) sismember(cmd *Command) protocol.Value {
 
 func (e *Executor) smembers(cmd *Command) protocol.Value {
 	members, err := e.storage.SMembers(cmd.Args[0])
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -106,6 +118,7 @@ func (e *Executor) smembers(cmd *Command) protocol.Value {
 
 func (e *Executor) scard(cmd *Command) protocol.Value {
 	count, err := e.storage.SCard(cmd.Args[0])
+	e.stats.recordLookup(err)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
 			return protocol.Value{
@@ -0,0 +1,233 @@
+package command
+
+import (
+	"fmt"
+	"ivanSaichkin/myredis/internal/protocol"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	slowLogMaxArgs   = 32
+	slowLogMaxArgLen = 128
+)
+
+// secretCommands lists commands whose arguments may hold secrets. They
+// are never recorded in the slow log or sent to monitors.
+var secretCommands = map[string]bool{
+	"auth":        true,
+	"acl|setuser": true,
+	"config|set":  true,
+}
+
+// SlowLogEntry is a command that took longer than the slow log threshold.
+type SlowLogEntry struct {
+	ID         int64
+	Time       time.Time
+	Duration   time.Duration
+	Args       []string
+	ClientAddr string
+	ClientName string
+}
+
+// SlowLog keeps the most recent slow commands in a bounded ring buffer.
+type SlowLog struct {
+	// threshold is a time.Duration; negative disables the log and zero
+	// records every command.
+	threshold atomic.Int64
+
+	mu      sync.Mutex
+	entries []SlowLogEntry
+	next    int
+	count   int
+	nextID  int64
+}
+
+func NewSlowLog(threshold time.Duration, maxLen int) *SlowLog {
+	l := &SlowLog{}
+	l.SetThreshold(threshold)
+	l.SetMaxLen(maxLen)
+	return l
+}
+
+func (l *SlowLog) SetThreshold(threshold time.Duration) {
+	l.threshold.Store(int64(threshold))
+}
+
+func (l *SlowLog) Threshold() time.Duration {
+	return time.Duration(l.threshold.Load())
+}
+
+// SetMaxLen resizes the buffer, keeping the most recent entries.
+func (l *SlowLog) SetMaxLen(maxLen int) {
+	if maxLen < 0 {
+		maxLen = 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := l.list(maxLen)
+	l.entries = make([]SlowLogEntry, maxLen)
+	l.count = len(entries)
+	l.next = l.count % max(maxLen, 1)
+	// entries are newest first; store them oldest first.
+	for i, entry := range entries {
+		l.entries[len(entries)-1-i] = entry
+	}
+}
+
+func (l *SlowLog) MaxLen() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.entries)
+}
+
+// record adds cmd if duration exceeds the threshold.
+func (l *SlowLog) record(client *Client, cmd *Command, duration time.Duration) {
+	threshold := l.Threshold()
+	if threshold < 0 || duration < threshold || secretCommands[cmd.FullName()] {
+		return
+	}
+
+	entry := SlowLogEntry{
+		Time:     time.Now(),
+		Duration: duration,
+		Args:     slowLogArgs(cmd),
+	}
+	if client != nil {
+		entry.ClientAddr = client.Addr
+		entry.ClientName = client.Name()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) == 0 {
+		return
+	}
+	l.nextID++
+	entry.ID = l.nextID - 1
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.count < len(l.entries) {
+		l.count++
+	}
+}
+
+// Entries returns up to n entries, newest first; a negative n returns all
+// of them.
+func (l *SlowLog) Entries(n int) []SlowLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.list(n)
+}
+
+func (l *SlowLog) list(n int) []SlowLogEntry {
+	if n < 0 || n > l.count {
+		n = l.count
+	}
+
+	entries := make([]SlowLogEntry, n)
+	for i := range entries {
+		entries[i] = l.entries[(l.next-1-i+2*len(l.entries))%len(l.entries)]
+	}
+	return entries
+}
+
+func (l *SlowLog) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+func (l *SlowLog) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	clear(l.entries)
+	l.next = 0
+	l.count = 0
+}
+
+// slowLogArgs returns the command and its arguments, truncated like Redis
+// does so that huge commands do not bloat the log.
+func slowLogArgs(cmd *Command) []string {
+	all := append([]string{cmd.Name}, cmd.Args...)
+
+	n := len(all)
+	if n > slowLogMaxArgs {
+		n = slowLogMaxArgs - 1
+	}
+
+	args := make([]string, 0, min(len(all), slowLogMaxArgs))
+	for _, arg := range all[:n] {
+		if len(arg) > slowLogMaxArgLen {
+			arg = fmt.Sprintf("%s... (%d more bytes)", arg[:slowLogMaxArgLen], len(arg)-slowLogMaxArgLen)
+		}
+		args = append(args, arg)
+	}
+	if n < len(all) {
+		args = append(args, fmt.Sprintf("... (%d more arguments)", len(all)-n))
+	}
+	return args
+}
+
+func (e *Executor) SlowLog() *SlowLog {
+	return e.slowLog
+}
+
+// Server commands
+
+func (e *Executor) slowlogGet(cmd *Command) protocol.Value {
+	if len(cmd.Args) > 2 {
+		return wrongSubcommandArgs("slowlog|get")
+	}
+	n := 10
+	if len(cmd.Args) == 2 {
+		var err error
+		if n, err = strconv.Atoi(cmd.Args[1]); err != nil || n < -1 {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR count should be greater than or equal to -1",
+			}
+		}
+	}
+
+	entries := e.slowLog.Entries(n)
+	result := make([]protocol.Value, len(entries))
+	for i, entry := range entries {
+		result[i] = protocol.Value{
+			Type: protocol.Array,
+			Array: []protocol.Value{
+				{Type: protocol.Integer, Num: int(entry.ID)},
+				{Type: protocol.Integer, Num: int(entry.Time.Unix())},
+				{Type: protocol.Integer, Num: int(entry.Duration / time.Microsecond)},
+				bulkArray(entry.Args),
+				{Type: protocol.BulkString, Bulk: entry.ClientAddr},
+				{Type: protocol.BulkString, Bulk: entry.ClientName},
+			},
+		}
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: result,
+	}
+}
+
+func (e *Executor) slowlogLen(cmd *Command) protocol.Value {
+	return protocol.Value{
+		Type: protocol.Integer,
+		Num:  e.slowLog.Len(),
+	}
+}
+
+func (e *Executor) slowlogReset(cmd *Command) protocol.Value {
+	e.slowLog.Reset()
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowLog(t *testing.T) {
+	registry := NewClientRegistry(0)
+	executor := NewExecutor(storage.NewMemoryStorage(), registry)
+	executor.SlowLog().SetThreshold(0)
+	executor.SlowLog().SetMaxLen(3)
+
+	client, _ := registerPipeClient(t, registry)
+	client.SetName("worker")
+
+	execute(executor, client, "SET", "a", "1")
+	execute(executor, client, "AUTH", "secret")
+	execute(executor, client, "GET", "a")
+	execute(executor, client, "SET", strings.Repeat("k", 200), "v")
+	execute(executor, nil, "HSET", "h", "f", "v")
+
+	// The log keeps the 3 most recent entries and skips AUTH.
+	expectInteger(t, execute(executor, nil, "SLOWLOG", "LEN"), 3)
+
+	reply := execute(executor, nil, "SLOWLOG", "GET")
+	if reply.Type != protocol.Array || len(reply.Array) != 3 {
+		t.Fatalf("SLOWLOG GET: unexpected reply %+v", reply)
+	}
+	// SLOWLOG LEN is the most recent entry, then HSET.
+	newest := reply.Array[1].Array
+	if args := bulkStrings(t, newest[3]); !slices.Equal(args, []string{"HSET", "h", "f", "v"}) {
+		t.Errorf("Unexpected arguments %v", args)
+	}
+
+	long := reply.Array[2].Array
+	if long[4].Bulk != client.Addr || long[5].Bulk != "worker" {
+		t.Errorf("Expected client %s/worker, got %+v", client.Addr, long)
+	}
+	if key := bulkStrings(t, long[3])[1]; key != strings.Repeat("k", 128)+"... (72 more bytes)" {
+		t.Errorf("Expected a truncated key, got %q", key)
+	}
+	if long[0].Num != 2 {
+		t.Errorf("Expected entry id 2, got %d", long[0].Num)
+	}
+
+	if reply := execute(executor, nil, "SLOWLOG", "GET", "1"); len(reply.Array) != 1 {
+		t.Errorf("SLOWLOG GET 1: expected 1 entry, got %d", len(reply.Array))
+	}
+
+	executor.SlowLog().SetThreshold(time.Hour)
+	expectOK(t, execute(executor, nil, "SLOWLOG", "RESET"))
+	execute(executor, nil, "GET", "a")
+	expectInteger(t, execute(executor, nil, "SLOWLOG", "LEN"), 0)
+}
+
+func TestSlowLogArgsTruncation(t *testing.T) {
+	args := make([]string, 40)
+	for i := range args {
+		args[i] = "x"
+	}
+
+	truncated := slowLogArgs(&Command{Name: "DEL", Args: args})
+	if len(truncated) != slowLogMaxArgs {
+		t.Fatalf("Expected %d arguments, got %d", slowLogMaxArgs, len(truncated))
+	}
+	if last := truncated[len(truncated)-1]; last != "... (10 more arguments)" {
+		t.Errorf("Unexpected last argument %q", last)
+	}
+}
+
+func TestSlowLogResize(t *testing.T) {
+	log := NewSlowLog(0, 3)
+	for _, name := range []string{"A", "B", "C"} {
+		log.record(nil, &Command{Name: name}, time.Millisecond)
+	}
+
+	log.SetMaxLen(2)
+	log.SetMaxLen(4)
+	log.record(nil, &Command{Name: "D"}, time.Millisecond)
+
+	var names []string
+	for _, entry := range log.Entries(-1) {
+		names = append(names, entry.Args[0])
+	}
+	if !slices.Equal(names, []string{"D", "C", "B"}) {
+		t.Errorf("Expected [D C B], got %v", names)
+	}
+}
@@ -0,0 +1,75 @@
+package command
+
+import "ivanSaichkin/myredis/internal/protocol"
+
+// Exec runs the commands queued by a client's MULTI as one transaction: no
+// other command runs until all of them have completed. The transaction is
+// aborted with a null reply when a key the client watches was modified.
+// The client's watched keys are released either way.
+func (e *Executor) Exec(client *Client, cmds []*Command) protocol.Value {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.watches.unwatch(client)
+
+	if e.watches.dirty(client) {
+		return protocol.Value{
+			Type:   protocol.Array,
+			IsNull: true,
+		}
+	}
+
+	replies := make([]protocol.Value, len(cmds))
+	for i, cmd := range cmds {
+		// Permissions may have changed since the command was queued.
+		if reply, ok := e.authorize(client, cmd); !ok {
+			replies[i] = reply
+			continue
+		}
+		replies[i] = e.call(client, cmd)
+	}
+	return protocol.Value{
+		Type:  protocol.Array,
+		Array: replies,
+	}
+}
+
+// Unwatch releases the keys client watches.
+func (e *Executor) Unwatch(client *Client) {
+	e.watches.unwatch(client)
+}
+
+// signalModifiedKey makes the EXEC of clients watching key fail.
+func (e *Executor) signalModifiedKey(key string) {
+	e.watches.touch(key)
+}
+
+// Transaction commands
+
+func (e *Executor) watch(client *Client, cmd *Command) protocol.Value {
+	if client == nil {
+		return connectionRequired(cmd)
+	}
+
+	e.watches.watch(client, cmd.Args)
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+func (e *Executor) unwatch(client *Client, cmd *Command) protocol.Value {
+	if client != nil {
+		e.watches.unwatch(client)
+	}
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "OK",
+	}
+}
+
+// handledByConnection replies to MULTI, EXEC and DISCARD, which the server
+// handles per connection; they only reach the executor from callers
+// without one.
+func (e *Executor) handledByConnection(cmd *Command) protocol.Value {
+	return connectionRequired(cmd)
+}
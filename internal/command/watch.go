@@ -0,0 +1,93 @@
+package command
+
+import "sync"
+
+// watchedKeys tracks the keys clients WATCH. A client is flagged dirty
+// when one of its watched keys is modified, which makes its next EXEC
+// fail.
+type watchedKeys struct {
+	mu      sync.Mutex
+	keys    map[string]map[*Client]struct{}
+	clients map[*Client]*watchState
+}
+
+type watchState struct {
+	keys  []string
+	dirty bool
+}
+
+func newWatchedKeys() *watchedKeys {
+	return &watchedKeys{
+		keys:    make(map[string]map[*Client]struct{}),
+		clients: make(map[*Client]*watchState),
+	}
+}
+
+func (w *watchedKeys) watch(client *Client, keys []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state, ok := w.clients[client]
+	if !ok {
+		state = &watchState{}
+		w.clients[client] = state
+	}
+
+	for _, key := range keys {
+		watchers, ok := w.keys[key]
+		if !ok {
+			watchers = make(map[*Client]struct{})
+			w.keys[key] = watchers
+		}
+		if _, watching := watchers[client]; watching {
+			continue
+		}
+		watchers[client] = struct{}{}
+		state.keys = append(state.keys, key)
+	}
+}
+
+func (w *watchedKeys) unwatch(client *Client) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state, ok := w.clients[client]
+	if !ok {
+		return
+	}
+	for _, key := range state.keys {
+		delete(w.keys[key], client)
+		if len(w.keys[key]) == 0 {
+			delete(w.keys, key)
+		}
+	}
+	delete(w.clients, client)
+}
+
+// touch flags the clients watching key.
+func (w *watchedKeys) touch(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for client := range w.keys[key] {
+		w.clients[client].dirty = true
+	}
+}
+
+// touchAll flags every watching client, e.g. after FLUSHDB.
+func (w *watchedKeys) touchAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, state := range w.clients {
+		state.dirty = true
+	}
+}
+
+func (w *watchedKeys) dirty(client *Client) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state, ok := w.clients[client]
+	return ok && state.dirty
+}
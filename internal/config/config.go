@@ -1,7 +1,9 @@
 package config
 
 import (
+	"maps"
 	"os"
+	"slices"
 	"time"
 )
 
@@ -26,14 +28,224 @@ func DefaultePersistenceConfig() *PersistenceConfig {
 	}
 }
 
+// TLSConfig configures the TLS listener. TLS is disabled when Address is
+// empty.
+type TLSConfig struct {
+	Address  string
+	CertFile string
+	KeyFile  string
+	// CAFile holds the certificates used to verify client certificates.
+	CAFile string
+	// ClientAuth is "no", "optional" or "yes"; "optional" verifies client
+	// certificates only when presented.
+	ClientAuth string
+	// MinVersion is "1.2" or "1.3".
+	MinVersion string
+	// CipherSuites lists TLS 1.2 cipher suite names as reported by
+	// crypto/tls; empty selects Go's defaults. TLS 1.3 suites are not
+	// configurable.
+	CipherSuites []string
+}
+
+// ListenerConfig describes one additional listener served by the shared
+// handler, e.g. an admin port or a Unix socket for co-located sidecars.
+type ListenerConfig struct {
+	// Name identifies the listener in logs.
+	Name string
+	// Network is "tcp" or "unix".
+	Network string
+	// Address is host:port for TCP or the socket path for Unix sockets.
+	Address string
+	// TLS serves the listener with the certificates from Config.TLS.
+	TLS bool
+	// Permissions are applied to Unix socket files, e.g. 0700.
+	Permissions os.FileMode
+}
+
+// OutputBufferLimit bounds the replies waiting to be written to a client:
+// a client exceeding Hard bytes, or staying above Soft bytes for
+// SoftDuration, is disconnected. Zero disables a limit.
+type OutputBufferLimit struct {
+	Hard         int64
+	Soft         int64
+	SoftDuration time.Duration
+}
+
+// ClientClasses are the client classes with separate output buffer
+// limits.
+var ClientClasses = []string{"normal", "replica", "pubsub"}
+
 type Config struct {
+	// Address is the plaintext listen address, empty disables it.
 	Address     string
 	Persistence PersistenceConfig
+	TLS         TLSConfig
+	Listeners   []ListenerConfig
+
+	// MaxClients limits the number of simultaneous connections, zero means
+	// no limit. IdleTimeout disconnects clients idle for longer, zero
+	// disables it.
+	MaxClients  int
+	IdleTimeout time.Duration
+
+	// RequirePass sets a password for the default user. ACLFile is read at
+	// startup and by ACL LOAD, and written by ACL SAVE.
+	RequirePass string
+	ACLFile     string
+
+	// ProtectedMode refuses clients connecting from other hosts while the
+	// default user has no password. AllowCIDRs, when set, limits clients to
+	// the listed networks or addresses; DenyCIDRs rejects clients and takes
+	// precedence. Unix socket clients are not subject to these checks.
+	ProtectedMode bool
+	AllowCIDRs    []string
+	DenyCIDRs     []string
+
+	// EnableDebugCommand is "no", "yes" or "local" and selects the clients
+	// allowed to run DEBUG; "local" allows loopback and Unix socket
+	// clients only.
+	EnableDebugCommand string
+
+	// NotifyKeyspaceEvents selects the keyspace notifications published
+	// over Pub/Sub using Redis' notify-keyspace-events flags, e.g. "KEA".
+	// Empty disables notifications.
+	NotifyKeyspaceEvents string
+
+	// SlowLogSlowerThan records commands taking at least this long in the
+	// slow log; negative disables it and zero records every command.
+	// SlowLogMaxLen bounds the number of entries kept.
+	SlowLogSlowerThan time.Duration
+	SlowLogMaxLen     int
+
+	// LazyFreeExpire, LazyFreeServerDel, LazyFreeUserDel and
+	// LazyFreeUserFlush are accepted for compatibility with redis.conf and
+	// have no effect: removed values are always left to the garbage
+	// collector.
+	LazyFreeExpire    bool
+	LazyFreeServerDel bool
+	LazyFreeUserDel   bool
+	LazyFreeUserFlush bool
+
+	// HotKeysSampleRatio samples the keys of one in that many commands to
+	// estimate the most accessed keys; zero disables sampling.
+	HotKeysSampleRatio int
+
+	// RateLimitCommands and RateLimitBytes bound the commands and bytes
+	// per second of the connections sharing a bucket, zero means no limit.
+	// RateLimitScope is "client", "user" or "ip" and selects which
+	// connections share a bucket. RateLimitMode is "reject", which fails
+	// excess commands, or "delay", which holds them back. MaxAcceptRate
+	// bounds the connections accepted per second.
+	RateLimitCommands int
+	RateLimitBytes    int
+	RateLimitScope    string
+	RateLimitMode     string
+	MaxAcceptRate     int
+
+	// OutputBufferLimits maps client classes to their output buffer
+	// limits. WriteTimeout bounds a single write to a client, zero
+	// disables it.
+	OutputBufferLimits map[string]OutputBufferLimit
+	WriteTimeout       time.Duration
+
+	// MetricsAddress serves Prometheus metrics over HTTP at /metrics,
+	// empty disables it. It should not be reachable by untrusted clients.
+	MetricsAddress string
+
+	// ShutdownTimeout bounds how long a shutdown waits for connections to
+	// finish their current command before closing them.
+	ShutdownTimeout time.Duration
+
+	// ExpirationCheckInterval is how often expired keys are removed in the
+	// background.
+	ExpirationCheckInterval time.Duration
+
+	// LogLevel is "debug", "verbose", "notice" or "warning". LogFile is
+	// written instead of standard error when set, and reopened on SIGHUP.
+	// LogFormat is "text" or "json".
+	LogLevel  string
+	LogFile   string
+	LogFormat string
+
+	// AuditLogFile receives a JSON line for every write and admin command
+	// when set. It is rotated before it grows beyond AuditLogMaxSize
+	// bytes, keeping AuditLogMaxFiles rotated files; a zero size never
+	// rotates. AuditLogRedact is "values", "none" or "all" and selects the
+	// arguments hidden in the log.
+	AuditLogFile     string
+	AuditLogMaxSize  int64
+	AuditLogMaxFiles int
+	AuditLogRedact   string
+
+	// ConfigFile is the file the configuration was read from and that
+	// CONFIG REWRITE writes to, empty when there is none.
+	ConfigFile string
 }
 
 func DefaulteConfig() *Config {
 	return &Config{
 		Address:     ":6379",
 		Persistence: *DefaultePersistenceConfig(),
+		TLS: TLSConfig{
+			ClientAuth: "no",
+			MinVersion: "1.2",
+		},
+		MaxClients:        10000,
+		IdleTimeout:       0,
+		ProtectedMode:     true,
+		SlowLogSlowerThan: 10 * time.Millisecond,
+		SlowLogMaxLen:     128,
+		ShutdownTimeout:   10 * time.Second,
+		RateLimitScope:    "client",
+		RateLimitMode:     "reject",
+		OutputBufferLimits: map[string]OutputBufferLimit{
+			"normal":  {},
+			"replica": {Hard: 256 << 20, Soft: 64 << 20, SoftDuration: 60 * time.Second},
+			"pubsub":  {Hard: 32 << 20, Soft: 8 << 20, SoftDuration: 60 * time.Second},
+		},
+		WriteTimeout: 30 * time.Second,
+
+		HotKeysSampleRatio: 10,
+		EnableDebugCommand: "no",
+
+		ExpirationCheckInterval: 30 * time.Second,
+		LogLevel:                "notice",
+		LogFormat:               "text",
+		AuditLogMaxSize:         100 << 20,
+		AuditLogMaxFiles:        5,
+		AuditLogRedact:          "values",
+	}
+}
+
+// Clone returns a deep copy of c.
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.TLS.CipherSuites = slices.Clone(c.TLS.CipherSuites)
+	clone.Listeners = slices.Clone(c.Listeners)
+	clone.AllowCIDRs = slices.Clone(c.AllowCIDRs)
+	clone.DenyCIDRs = slices.Clone(c.DenyCIDRs)
+	clone.OutputBufferLimits = maps.Clone(c.OutputBufferLimits)
+	return &clone
+}
+
+// AllListeners returns the plaintext and TLS addresses followed by the
+// additional listeners, in the order they are started.
+func (c *Config) AllListeners() []ListenerConfig {
+	listeners := make([]ListenerConfig, 0, len(c.Listeners)+2)
+	if c.Address != "" {
+		listeners = append(listeners, ListenerConfig{
+			Name:    "main",
+			Network: "tcp",
+			Address: c.Address,
+		})
+	}
+	if c.TLS.Address != "" {
+		listeners = append(listeners, ListenerConfig{
+			Name:    "tls",
+			Network: "tcp",
+			Address: c.TLS.Address,
+			TLS:     true,
+		})
 	}
+	return append(listeners, c.Listeners...)
 }
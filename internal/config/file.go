@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadFile reads the redis.conf style file at path into c. Each line holds
+// a parameter name followed by its value; values containing spaces or
+// quotes are written in double quotes with Go escapes, or in single quotes.
+// Empty lines and lines starting with # are ignored.
+func (c *Config) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return c.parse(file, path)
+}
+
+func (c *Config) parse(r io.Reader, name string) error {
+	scanner := bufio.NewScanner(r)
+	var errs []error
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args, err := splitArgs(line)
+		if err == nil {
+			err = c.setDirective(strings.ToLower(args[0]), args[1:])
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", name, lineNo, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) setDirective(name string, args []string) error {
+	if name == "listener" {
+		if err := c.addListener(args); err != nil {
+			return fmt.Errorf("listener: %w", err)
+		}
+		return nil
+	}
+
+	p, ok := LookupParam(name)
+	if !ok {
+		return fmt.Errorf("unknown parameter '%s'", name)
+	}
+	if p.List {
+		return p.Set(c, strings.Join(args, " "))
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("%s: expected one value, got %d", p.Name, len(args))
+	}
+	return p.Set(c, args[0])
+}
+
+// set sets the parameter called name to value. The pseudo parameter
+// "listener" adds a listener described as in a config file. Unlike
+// Param.Set, errors are not prefixed with the name.
+func (c *Config) set(name, value string) error {
+	name = strings.ToLower(name)
+	if name == "listener" {
+		return c.addListener(strings.Fields(value))
+	}
+
+	p, ok := LookupParam(name)
+	if !ok {
+		return fmt.Errorf("unknown parameter '%s'", name)
+	}
+	return p.set(c, value)
+}
+
+// addListener parses "name network address [tls] [permissions]", e.g.
+// "local unix /tmp/myredis.sock 0700".
+func (c *Config) addListener(args []string) error {
+	if len(args) < 3 {
+		return errors.New("expected name, network and address")
+	}
+
+	lc := ListenerConfig{
+		Name:    args[0],
+		Network: strings.ToLower(args[1]),
+		Address: args[2],
+	}
+	for _, option := range args[3:] {
+		if strings.EqualFold(option, "tls") {
+			lc.TLS = true
+			continue
+		}
+		perm, err := strconv.ParseUint(option, 8, 32)
+		if err != nil || perm > 0o777 {
+			return fmt.Errorf("invalid option %q, expected tls or octal permissions", option)
+		}
+		lc.Permissions = os.FileMode(perm)
+	}
+	c.Listeners = append(c.Listeners, lc)
+	return nil
+}
+
+// splitArgs splits a config file line into arguments. Double quoted
+// arguments are unquoted with strconv.Unquote, so that values written by
+// Rewrite read back unchanged; in single quoted arguments only \' is an
+// escape.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	for {
+		line = strings.TrimLeft(line, " \t\r")
+		if line == "" {
+			return args, nil
+		}
+
+		var arg string
+		switch line[0] {
+		case '"':
+			end := 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, errors.New("unbalanced quotes")
+			}
+			unquoted, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value %s", line[:end+1])
+			}
+			arg, line = unquoted, line[end+1:]
+		case '\'':
+			var b strings.Builder
+			end := 1
+			for end < len(line) && line[end] != '\'' {
+				if line[end] == '\\' && end+1 < len(line) && line[end+1] == '\'' {
+					end++
+				}
+				b.WriteByte(line[end])
+				end++
+			}
+			if end >= len(line) {
+				return nil, errors.New("unbalanced quotes")
+			}
+			arg, line = b.String(), line[end+1:]
+		default:
+			end := strings.IndexAny(line, " \t\r")
+			if end < 0 {
+				end = len(line)
+			}
+			arg, line = line[:end], line[end:]
+			args = append(args, arg)
+			continue
+		}
+
+		if line != "" && !strings.ContainsRune(" \t\r", rune(line[0])) {
+			return nil, errors.New("closing quote must be followed by a space")
+		}
+		args = append(args, arg)
+	}
+}
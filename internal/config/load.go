@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+)
+
+// EnvPrefix prefixes the environment variables that override parameters,
+// e.g. MYREDIS_MAXCLIENTS or MYREDIS_TLS_CERT_FILE.
+const EnvPrefix = "MYREDIS_"
+
+// Override is a parameter value given on the command line.
+type Override struct {
+	Name  string
+	Value string
+}
+
+// EnvName returns the environment variable overriding the parameter name.
+func EnvName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the config file at path if it is not empty, the
+// environment variables found by lookupEnv and overrides. The result
+// still has to be checked with Validate.
+func Load(path string, lookupEnv func(string) (string, bool), overrides []Override) (*Config, error) {
+	c := DefaulteConfig()
+
+	if path != "" {
+		if err := c.LoadFile(path); err != nil {
+			return nil, err
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		c.ConfigFile = abs
+	}
+
+	var errs []error
+	for _, p := range Params() {
+		if value, ok := lookupEnv(EnvName(p.Name)); ok {
+			if err := p.set(c, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", EnvName(p.Name), err))
+			}
+		}
+	}
+	for _, o := range overrides {
+		if err := c.set(o.Name, o.Value); err != nil {
+			errs = append(errs, fmt.Errorf("--%s: %w", o.Name, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate reports every inconsistency in c that individual parameters
+// cannot catch on their own.
+func (c *Config) Validate() error {
+	var errs []error
+
+	listeners := c.AllListeners()
+	if len(listeners) == 0 {
+		errs = append(errs, errors.New("no listeners: set address, tls-address or a listener"))
+	}
+	names := make(map[string]bool)
+	usesTLS := false
+	for _, lc := range listeners {
+		if names[lc.Name] {
+			errs = append(errs, fmt.Errorf("listener %s: duplicate name", lc.Name))
+		}
+		names[lc.Name] = true
+		usesTLS = usesTLS || lc.TLS
+
+		switch lc.Network {
+		case "tcp":
+			if _, _, err := net.SplitHostPort(lc.Address); err != nil {
+				errs = append(errs, fmt.Errorf("listener %s: invalid address %q, expected host:port", lc.Name, lc.Address))
+			}
+		case "unix":
+			if lc.Address == "" {
+				errs = append(errs, fmt.Errorf("listener %s: missing socket path", lc.Name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("listener %s: unsupported network %q, expected tcp or unix", lc.Name, lc.Network))
+		}
+	}
+	if usesTLS && (c.TLS.CertFile == "" || c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("tls-cert-file and tls-key-file are required by the TLS listeners"))
+	}
+	if c.TLS.ClientAuth != "no" && c.TLS.CAFile == "" {
+		errs = append(errs, fmt.Errorf("tls-ca-cert-file is required with tls-auth-clients %s", c.TLS.ClientAuth))
+	}
+
+	if c.MetricsAddress != "" {
+		if _, _, err := net.SplitHostPort(c.MetricsAddress); err != nil {
+			errs = append(errs, fmt.Errorf("metrics-address: invalid address %q, expected host:port", c.MetricsAddress))
+		}
+	}
+	if c.Persistence.Enabled && (c.Persistence.DataDir == "" || c.Persistence.Filename == "") {
+		errs = append(errs, errors.New("dir and dbfilename are required when persistence is enabled"))
+	}
+	return errors.Join(errs...)
+}
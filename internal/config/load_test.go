@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "myredis.conf")
+	data := `# MyRedis configuration
+port 7000
+requirepass "s3cret \"pass\""
+notify-keyspace-events 'Ex'
+allow-cidrs 10.0.0.0/8 127.0.0.1
+timeout 5m
+listener local unix /tmp/myredis.sock 0700
+listener admin tcp 127.0.0.1:6380 tls
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path, func(string) (string, bool) { return "", false }, nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.Address != ":7000" || c.RequirePass != `s3cret "pass"` || c.NotifyKeyspaceEvents != "Ex" || c.IdleTimeout != 5*time.Minute {
+		t.Errorf("Unexpected config %+v", c)
+	}
+	if !reflect.DeepEqual(c.AllowCIDRs, []string{"10.0.0.0/8", "127.0.0.1"}) {
+		t.Errorf("Unexpected allow-cidrs %v", c.AllowCIDRs)
+	}
+	expected := []ListenerConfig{
+		{Name: "local", Network: "unix", Address: "/tmp/myredis.sock", Permissions: 0o700},
+		{Name: "admin", Network: "tcp", Address: "127.0.0.1:6380", TLS: true},
+	}
+	if !reflect.DeepEqual(c.Listeners, expected) {
+		t.Errorf("Expected listeners %+v, got %+v", expected, c.Listeners)
+	}
+	if c.ConfigFile != path {
+		t.Errorf("Expected ConfigFile %s, got %s", path, c.ConfigFile)
+	}
+
+	// A rewritten file reads back to the same configuration.
+	if err := c.Rewrite(path); err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+	reloaded, err := Load(path, func(string) (string, bool) { return "", false }, nil)
+	if err != nil {
+		t.Fatalf("Load after Rewrite failed: %v", err)
+	}
+	if !reflect.DeepEqual(reloaded, c) {
+		t.Errorf("Expected %+v after Rewrite, got %+v", c, reloaded)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "myredis.conf")
+	data := "maxclients many\nnosuch 1\nrequirepass \"open\ntimeout 1 2\nlistener local unix\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(path, func(string) (string, bool) { return "", false }, nil)
+	if err == nil {
+		t.Fatal("Expected Load to fail")
+	}
+	for _, expected := range []string{
+		path + `:1: maxclients: invalid value "many"`,
+		path + ":2: unknown parameter 'nosuch'",
+		path + ":3: unbalanced quotes",
+		path + ":4: timeout: expected one value, got 2",
+		path + ":5: listener: expected name, network and address",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got:\n%v", expected, err)
+		}
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "myredis.conf")
+	if err := os.WriteFile(path, []byte("maxclients 10\nslowlog-max-len 20\ntimeout 30\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"MYREDIS_SLOWLOG_MAX_LEN": "21",
+		"MYREDIS_TIMEOUT":         "31",
+	}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	c, err := Load(path, lookupEnv, []Override{{Name: "timeout", Value: "32"}})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.MaxClients != 10 || c.SlowLogMaxLen != 21 || c.IdleTimeout != 32*time.Second {
+		t.Errorf("Unexpected precedence: maxclients %d, slowlog-max-len %d, timeout %v", c.MaxClients, c.SlowLogMaxLen, c.IdleTimeout)
+	}
+
+	env["MYREDIS_MAXCLIENTS"] = "lots"
+	_, err = Load(path, lookupEnv, []Override{{Name: "port", Value: "70000"}})
+	if err == nil || !strings.Contains(err.Error(), `MYREDIS_MAXCLIENTS: invalid value "lots"`) || !strings.Contains(err.Error(), `--port: invalid port "70000"`) {
+		t.Errorf("Expected environment and flag errors, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := DefaulteConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Expected the defaults to be valid, got %v", err)
+	}
+
+	c.Address = ""
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "no listeners") {
+		t.Errorf("Expected a missing listener error, got %v", err)
+	}
+
+	c.TLS.Address = ":6380"
+	c.TLS.ClientAuth = "yes"
+	c.Listeners = []ListenerConfig{{Name: "tls", Network: "udp", Address: ":1"}}
+	err := c.Validate()
+	for _, expected := range []string{
+		"listener tls: duplicate name",
+		`listener tls: unsupported network "udp"`,
+		"tls-cert-file and tls-key-file are required",
+		"tls-ca-cert-file is required",
+	} {
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"fmt"
+	"ivanSaichkin/myredis/internal/logging"
+	"maps"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Param is a configuration parameter, named like its redis.conf directive.
+type Param struct {
+	Name string
+	// Mutable parameters can be changed at runtime with CONFIG SET.
+	Mutable bool
+	// List parameters hold several space separated values.
+	List bool
+
+	get func(c *Config) string
+	set func(c *Config, value string) error
+}
+
+var params = []*Param{
+	// Listeners
+	{Name: "address", get: func(c *Config) string { return c.Address }, set: setString(func(c *Config) *string { return &c.Address })},
+	{Name: "port", get: getPort, set: setPort},
+	{Name: "tls-address", get: func(c *Config) string { return c.TLS.Address }, set: setString(func(c *Config) *string { return &c.TLS.Address })},
+	{Name: "tls-cert-file", get: func(c *Config) string { return c.TLS.CertFile }, set: setString(func(c *Config) *string { return &c.TLS.CertFile })},
+	{Name: "tls-key-file", get: func(c *Config) string { return c.TLS.KeyFile }, set: setString(func(c *Config) *string { return &c.TLS.KeyFile })},
+	{Name: "tls-ca-cert-file", get: func(c *Config) string { return c.TLS.CAFile }, set: setString(func(c *Config) *string { return &c.TLS.CAFile })},
+	{Name: "tls-auth-clients", get: func(c *Config) string { return c.TLS.ClientAuth }, set: setEnum(func(c *Config) *string { return &c.TLS.ClientAuth }, "no", "optional", "yes")},
+	{Name: "tls-min-version", get: func(c *Config) string { return c.TLS.MinVersion }, set: setEnum(func(c *Config) *string { return &c.TLS.MinVersion }, "1.2", "1.3")},
+	{Name: "tls-ciphers", List: true, get: func(c *Config) string { return strings.Join(c.TLS.CipherSuites, " ") }, set: setList(func(c *Config) *[]string { return &c.TLS.CipherSuites })},
+	{Name: "metrics-address", get: func(c *Config) string { return c.MetricsAddress }, set: setString(func(c *Config) *string { return &c.MetricsAddress })},
+
+	// Persistence
+	{Name: "persistence", get: func(c *Config) string { return formatBool(c.Persistence.Enabled) }, set: setBool(func(c *Config) *bool { return &c.Persistence.Enabled })},
+	{Name: "dir", get: func(c *Config) string { return c.Persistence.DataDir }, set: setString(func(c *Config) *string { return &c.Persistence.DataDir })},
+	{Name: "dbfilename", get: func(c *Config) string { return c.Persistence.Filename }, set: setString(func(c *Config) *string { return &c.Persistence.Filename })},
+	{Name: "autosave", Mutable: true, get: func(c *Config) string { return formatBool(c.Persistence.AutoSave) }, set: setBool(func(c *Config) *bool { return &c.Persistence.AutoSave })},
+	{Name: "save-interval", Mutable: true, get: func(c *Config) string { return c.Persistence.SaveInterval.String() }, set: setDuration(func(c *Config) *time.Duration { return &c.Persistence.SaveInterval }, false)},
+	{Name: "lazyfree-lazy-expire", Mutable: true, get: func(c *Config) string { return formatBool(c.LazyFreeExpire) }, set: setBool(func(c *Config) *bool { return &c.LazyFreeExpire })},
+	{Name: "lazyfree-lazy-server-del", Mutable: true, get: func(c *Config) string { return formatBool(c.LazyFreeServerDel) }, set: setBool(func(c *Config) *bool { return &c.LazyFreeServerDel })},
+	{Name: "lazyfree-lazy-user-del", Mutable: true, get: func(c *Config) string { return formatBool(c.LazyFreeUserDel) }, set: setBool(func(c *Config) *bool { return &c.LazyFreeUserDel })},
+	{Name: "lazyfree-lazy-user-flush", Mutable: true, get: func(c *Config) string { return formatBool(c.LazyFreeUserFlush) }, set: setBool(func(c *Config) *bool { return &c.LazyFreeUserFlush })},
+	{Name: "expire-check-interval", Mutable: true, get: func(c *Config) string { return c.ExpirationCheckInterval.String() }, set: setDuration(func(c *Config) *time.Duration { return &c.ExpirationCheckInterval }, false)},
+
+	// Limits
+	{Name: "maxclients", Mutable: true, get: func(c *Config) string { return strconv.Itoa(c.MaxClients) }, set: setInt(func(c *Config) *int { return &c.MaxClients })},
+	{Name: "timeout", Mutable: true, get: func(c *Config) string { return formatSeconds(c.IdleTimeout) }, set: setDuration(func(c *Config) *time.Duration { return &c.IdleTimeout }, true)},
+	{Name: "ratelimit-commands", Mutable: true, get: func(c *Config) string { return strconv.Itoa(c.RateLimitCommands) }, set: setInt(func(c *Config) *int { return &c.RateLimitCommands })},
+	{Name: "ratelimit-bytes", Mutable: true, get: func(c *Config) string { return strconv.Itoa(c.RateLimitBytes) }, set: setInt(func(c *Config) *int { return &c.RateLimitBytes })},
+	{Name: "ratelimit-scope", Mutable: true, get: func(c *Config) string { return c.RateLimitScope }, set: setEnum(func(c *Config) *string { return &c.RateLimitScope }, "client", "user", "ip")},
+	{Name: "ratelimit-mode", Mutable: true, get: func(c *Config) string { return c.RateLimitMode }, set: setEnum(func(c *Config) *string { return &c.RateLimitMode }, "reject", "delay")},
+	{Name: "maxaccept-rate", Mutable: true, get: func(c *Config) string { return strconv.Itoa(c.MaxAcceptRate) }, set: setInt(func(c *Config) *int { return &c.MaxAcceptRate })},
+	{Name: "client-output-buffer-limit", Mutable: true, List: true, get: getOutputBufferLimits, set: setOutputBufferLimits},
+	{Name: "write-timeout", Mutable: true, get: func(c *Config) string { return c.WriteTimeout.String() }, set: setDuration(func(c *Config) *time.Duration { return &c.WriteTimeout }, true)},
+	{Name: "shutdown-timeout", Mutable: true, get: func(c *Config) string { return c.ShutdownTimeout.String() }, set: setDuration(func(c *Config) *time.Duration { return &c.ShutdownTimeout }, true)},
+
+	// Security
+	{Name: "requirepass", Mutable: true, get: func(c *Config) string { return c.RequirePass }, set: setString(func(c *Config) *string { return &c.RequirePass })},
+	{Name: "aclfile", get: func(c *Config) string { return c.ACLFile }, set: setString(func(c *Config) *string { return &c.ACLFile })},
+	{Name: "protected-mode", get: func(c *Config) string { return formatBool(c.ProtectedMode) }, set: setBool(func(c *Config) *bool { return &c.ProtectedMode })},
+	{Name: "allow-cidrs", List: true, get: func(c *Config) string { return strings.Join(c.AllowCIDRs, " ") }, set: setList(func(c *Config) *[]string { return &c.AllowCIDRs })},
+	{Name: "deny-cidrs", List: true, get: func(c *Config) string { return strings.Join(c.DenyCIDRs, " ") }, set: setList(func(c *Config) *[]string { return &c.DenyCIDRs })},
+	{Name: "enable-debug-command", get: func(c *Config) string { return c.EnableDebugCommand }, set: setEnum(func(c *Config) *string { return &c.EnableDebugCommand }, "no", "yes", "local")},
+
+	// Monitoring
+	{Name: "notify-keyspace-events", Mutable: true, get: func(c *Config) string { return c.NotifyKeyspaceEvents }, set: setString(func(c *Config) *string { return &c.NotifyKeyspaceEvents })},
+	{Name: "slowlog-log-slower-than", Mutable: true, get: func(c *Config) string { return strconv.FormatInt(int64(c.SlowLogSlowerThan/time.Microsecond), 10) }, set: setMicroseconds(func(c *Config) *time.Duration { return &c.SlowLogSlowerThan })},
+	{Name: "slowlog-max-len", Mutable: true, get: func(c *Config) string { return strconv.Itoa(c.SlowLogMaxLen) }, set: setInt(func(c *Config) *int { return &c.SlowLogMaxLen })},
+	{Name: "hotkeys-sample-ratio", Mutable: true, get: func(c *Config) string { return strconv.Itoa(c.HotKeysSampleRatio) }, set: setInt(func(c *Config) *int { return &c.HotKeysSampleRatio })},
+	{Name: "loglevel", Mutable: true, get: func(c *Config) string { return c.LogLevel }, set: setLogLevel},
+	{Name: "logfile", get: func(c *Config) string { return c.LogFile }, set: setString(func(c *Config) *string { return &c.LogFile })},
+	{Name: "logformat", get: func(c *Config) string { return c.LogFormat }, set: setEnum(func(c *Config) *string { return &c.LogFormat }, "text", "json")},
+	{Name: "audit-log", get: func(c *Config) string { return c.AuditLogFile }, set: setString(func(c *Config) *string { return &c.AuditLogFile })},
+	{Name: "audit-log-max-size", Mutable: true, get: func(c *Config) string { return strconv.FormatInt(c.AuditLogMaxSize, 10) }, set: setMemory(func(c *Config) *int64 { return &c.AuditLogMaxSize })},
+	{Name: "audit-log-max-files", Mutable: true, get: func(c *Config) string { return strconv.Itoa(c.AuditLogMaxFiles) }, set: setInt(func(c *Config) *int { return &c.AuditLogMaxFiles })},
+	{Name: "audit-log-redact", Mutable: true, get: func(c *Config) string { return c.AuditLogRedact }, set: setEnum(func(c *Config) *string { return &c.AuditLogRedact }, "values", "none", "all")},
+}
+
+var paramsByName = func() map[string]*Param {
+	byName := make(map[string]*Param, len(params))
+	for _, p := range params {
+		byName[p.Name] = p
+	}
+	return byName
+}()
+
+// LookupParam returns the parameter called name, ignoring case.
+func LookupParam(name string) (*Param, bool) {
+	p, ok := paramsByName[strings.ToLower(name)]
+	return p, ok
+}
+
+// Params returns all parameters ordered by name.
+func Params() []*Param {
+	sorted := make([]*Param, len(params))
+	copy(sorted, params)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// Get returns the value of p in c, formatted as in a config file.
+func (p *Param) Get(c *Config) string {
+	return p.get(c)
+}
+
+// Set parses value and stores it in c.
+func (p *Param) Set(c *Config, value string) error {
+	if err := p.set(c, value); err != nil {
+		return fmt.Errorf("%s: %w", p.Name, err)
+	}
+	return nil
+}
+
+func setString(field func(c *Config) *string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+func setEnum(field func(c *Config) *string, values ...string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		for _, v := range values {
+			if strings.EqualFold(value, v) {
+				*field(c) = v
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid value %q, must be one of %s", value, strings.Join(values, ", "))
+	}
+}
+
+func setList(field func(c *Config) *[]string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		*field(c) = strings.Fields(value)
+		return nil
+	}
+}
+
+func setBool(field func(c *Config) *bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		switch strings.ToLower(value) {
+		case "yes":
+			*field(c) = true
+		case "no":
+			*field(c) = false
+		default:
+			return fmt.Errorf("invalid value %q, must be yes or no", value)
+		}
+		return nil
+	}
+}
+
+func setInt(field func(c *Config) *int) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value %q, must be a non-negative integer", value)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// setMemory accepts a number of bytes with an optional unit such as "1mb".
+func setMemory(field func(c *Config) *int64) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		n, err := parseMemory(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// setDuration accepts a Go duration such as "1m30s" or a number of
+// seconds. Zero is only accepted when allowZero is set.
+func setDuration(field func(c *Config) *time.Duration, allowZero bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		d, err := parseDuration(value, time.Second)
+		if err != nil || d < 0 || d == 0 && !allowZero {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// setMicroseconds accepts a number of microseconds, as Redis does for
+// slowlog-log-slower-than, or a Go duration. Negative values are allowed.
+func setMicroseconds(field func(c *Config) *time.Duration) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		d, err := parseDuration(value, time.Microsecond)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func parseDuration(value string, unit time.Duration) (time.Duration, error) {
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(n) * unit, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// getOutputBufferLimits formats the limits like Redis:
+// "class hard soft seconds" for every class.
+func getOutputBufferLimits(c *Config) string {
+	var parts []string
+	for _, class := range ClientClasses {
+		limit := c.OutputBufferLimits[class]
+		parts = append(parts, class,
+			strconv.FormatInt(limit.Hard, 10),
+			strconv.FormatInt(limit.Soft, 10),
+			strconv.Itoa(int(limit.SoftDuration/time.Second)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// setOutputBufferLimits sets the limits of the classes given; sizes accept
+// the suffixes kb, mb and gb.
+func setOutputBufferLimits(c *Config, value string) error {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields)%4 != 0 {
+		return fmt.Errorf("invalid value %q, expected <class> <hard> <soft> <seconds> groups", value)
+	}
+
+	limits := maps.Clone(c.OutputBufferLimits)
+	if limits == nil {
+		limits = make(map[string]OutputBufferLimit)
+	}
+	for i := 0; i < len(fields); i += 4 {
+		class := strings.ToLower(fields[i])
+		if !slices.Contains(ClientClasses, class) {
+			return fmt.Errorf("invalid client class %q, must be one of %s", fields[i], strings.Join(ClientClasses, ", "))
+		}
+		hard, err := parseMemory(fields[i+1])
+		if err != nil {
+			return err
+		}
+		soft, err := parseMemory(fields[i+2])
+		if err != nil {
+			return err
+		}
+		seconds, err := strconv.Atoi(fields[i+3])
+		if err != nil || seconds < 0 {
+			return fmt.Errorf("invalid soft limit seconds %q", fields[i+3])
+		}
+		limits[class] = OutputBufferLimit{Hard: hard, Soft: soft, SoftDuration: time.Duration(seconds) * time.Second}
+	}
+	c.OutputBufferLimits = limits
+	return nil
+}
+
+// parseMemory parses a byte count with an optional k, kb, m, mb, g or gb
+// suffix, as in redis.conf.
+func parseMemory(value string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30},
+		{"k", 1000}, {"m", 1000 * 1000}, {"g", 1000 * 1000 * 1000},
+		{"b", 1},
+	}
+
+	lower := strings.ToLower(value)
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(lower, unit.suffix) {
+			lower = strings.TrimSuffix(lower, unit.suffix)
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(lower, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid memory size %q", value)
+	}
+	return n * multiplier, nil
+}
+
+func getPort(c *Config) string {
+	if c.Address == "" {
+		return "0"
+	}
+	_, port, err := net.SplitHostPort(c.Address)
+	if err != nil {
+		return ""
+	}
+	return port
+}
+
+// setPort replaces the port of the plaintext address, keeping its host.
+// Port 0 disables the plaintext listener.
+func setPort(c *Config, value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 0 || port > 65535 {
+		return fmt.Errorf("invalid port %q", value)
+	}
+	if port == 0 {
+		c.Address = ""
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(c.Address)
+	if err != nil {
+		host = ""
+	}
+	c.Address = net.JoinHostPort(host, value)
+	return nil
+}
+
+func setLogLevel(c *Config, value string) error {
+	level, err := logging.ParseLevel(value)
+	if err != nil {
+		return err
+	}
+	c.LogLevel = logging.LevelName(level)
+	return nil
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// formatSeconds formats a whole number of seconds as a plain number, as
+// Redis does, and any other duration in Go syntax so that no precision is
+// lost.
+func formatSeconds(d time.Duration) string {
+	if d%time.Second == 0 {
+		return strconv.FormatInt(int64(d/time.Second), 10)
+	}
+	return d.String()
+}
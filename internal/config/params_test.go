@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParamSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "maxclients", value: "42", expected: "42"},
+		{name: "maxclients", value: "-1", wantErr: true},
+		{name: "timeout", value: "90", expected: "90"},
+		{name: "timeout", value: "2m", expected: "120"},
+		{name: "timeout", value: "0", expected: "0"},
+		{name: "timeout", value: "1500ms", expected: "1.5s"},
+		{name: "timeout", value: "500ms", expected: "500ms"},
+		{name: "save-interval", value: "0", wantErr: true},
+		{name: "save-interval", value: "30", expected: "30s"},
+		{name: "slowlog-log-slower-than", value: "-1", expected: "-1"},
+		{name: "slowlog-log-slower-than", value: "2ms", expected: "2000"},
+		{name: "autosave", value: "YES", expected: "yes"},
+		{name: "autosave", value: "maybe", wantErr: true},
+		{name: "tls-auth-clients", value: "Optional", expected: "optional"},
+		{name: "tls-auth-clients", value: "always", wantErr: true},
+		{name: "allow-cidrs", value: "10.0.0.0/8  192.168.0.0/16", expected: "10.0.0.0/8 192.168.0.0/16"},
+		{name: "loglevel", value: "WARNING", expected: "warning"},
+		{name: "loglevel", value: "loud", wantErr: true},
+		{name: "client-output-buffer-limit", value: "pubsub 1mb 1k 30", expected: "normal 0 0 0 replica 268435456 67108864 60 pubsub 1048576 1000 30"},
+		{name: "client-output-buffer-limit", value: "normal 1gb 0 0 replica 0 0 0", expected: "normal 1073741824 0 0 replica 0 0 0 pubsub 33554432 8388608 60"},
+		{name: "client-output-buffer-limit", value: "slave 1mb 0 0", wantErr: true},
+		{name: "client-output-buffer-limit", value: "normal 1mb -1 0", wantErr: true},
+		{name: "write-timeout", value: "0", expected: "0s"},
+		{name: "audit-log-max-size", value: "1mb", expected: "1048576"},
+		{name: "audit-log-max-size", value: "lots", wantErr: true},
+		{name: "audit-log-redact", value: "ALL", expected: "all"},
+		{name: "audit-log-redact", value: "keys", wantErr: true},
+		{name: "enable-debug-command", value: "LOCAL", expected: "local"},
+		{name: "enable-debug-command", value: "remote", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		c := DefaulteConfig()
+		p, ok := LookupParam(tt.name)
+		if !ok {
+			t.Fatalf("Unknown parameter %s", tt.name)
+		}
+		err := p.Set(c, tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s %q: expected an error", tt.name, tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: unexpected error %v", tt.name, tt.value, err)
+		} else if got := p.Get(c); got != tt.expected {
+			t.Errorf("%s %q: expected %q, got %q", tt.name, tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "myredis.conf")
+	existing := "# MyRedis\n\nmaxclients 100\nunknown-directive 1\nmaxclients 200\n"
+	if err := os.WriteFile(path, []byte(existing), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	c := DefaulteConfig()
+	c.MaxClients = 300
+	c.IdleTimeout = time.Minute
+	c.RequirePass = ""
+	if err := c.Rewrite(path); err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# MyRedis\n\nmaxclients 300\nunknown-directive 1\ntimeout 60\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("Expected the file mode to be kept, got %v", info.Mode().Perm())
+	}
+}
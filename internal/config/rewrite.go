@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Rewrite writes c to path in redis.conf format. Comments and unknown
+// directives of an existing file are kept, parameters it already sets are
+// updated in place and other parameters that differ from their defaults
+// are appended.
+func (c *Config) Rewrite(path string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var out bytes.Buffer
+	written := make(map[string]bool)
+	for _, line := range strings.SplitAfter(string(existing), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			out.WriteString(line)
+			continue
+		}
+
+		p, ok := LookupParam(fields[0])
+		if !ok {
+			out.WriteString(line)
+			continue
+		}
+		// Later occurrences of a parameter are dropped, they no longer
+		// take effect.
+		if !written[p.Name] {
+			out.WriteString(formatParam(p, p.Get(c)) + "\n")
+			written[p.Name] = true
+		}
+	}
+
+	defaults := DefaulteConfig()
+	for _, p := range Params() {
+		if written[p.Name] || p.Get(c) == p.Get(defaults) {
+			continue
+		}
+		out.WriteString(formatParam(p, p.Get(c)) + "\n")
+	}
+
+	return writeFileAtomic(path, out.Bytes())
+}
+
+// formatParam formats a config file line setting p to value.
+func formatParam(p *Param, value string) string {
+	if p.List && value != "" {
+		return p.Name + " " + value
+	}
+	return p.Name + " " + quoteValue(value)
+}
+
+// quoteValue quotes value when it would not be read back as a single
+// argument.
+func quoteValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\r\n\"'\\#") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"os"
+	"sync"
+)
+
+// File is an append-only log file that can be reopened after it has been
+// rotated, e.g. on SIGHUP.
+type File struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func OpenFile(path string) (*File, error) {
+	file, err := openLog(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{path: path, file: file}, nil
+}
+
+func openLog(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Write(p)
+}
+
+// Reopen closes the file and opens path again, creating it if it was
+// moved away. On error the current file stays in use.
+func (f *File) Reopen() error {
+	file, err := openLog(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	old := f.file
+	f.file = file
+	return old.Close()
+}
+
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
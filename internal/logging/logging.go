@@ -0,0 +1,96 @@
+// Package logging builds the structured server log on log/slog, using
+// Redis' verbosity levels.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Redis' log levels. Notice and Warning are slog's Info and Warn.
+const (
+	LevelDebug   = slog.LevelDebug
+	LevelVerbose = slog.Level(-2)
+	LevelNotice  = slog.LevelInfo
+	LevelWarning = slog.LevelWarn
+)
+
+var levels = []struct {
+	name  string
+	level slog.Level
+}{
+	{"debug", LevelDebug},
+	{"verbose", LevelVerbose},
+	{"notice", LevelNotice},
+	{"warning", LevelWarning},
+}
+
+// ParseLevel parses a level name as used by Redis' loglevel directive.
+func ParseLevel(name string) (slog.Level, error) {
+	names := make([]string, len(levels))
+	for i, l := range levels {
+		if strings.EqualFold(name, l.name) {
+			return l.level, nil
+		}
+		names[i] = l.name
+	}
+	return 0, fmt.Errorf("invalid log level %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// LevelName returns the Redis name of level.
+func LevelName(level slog.Level) string {
+	for _, l := range levels {
+		if l.level == level {
+			return l.name
+		}
+	}
+	return strings.ToLower(level.String())
+}
+
+// level is shared by every logger built by New, so that CONFIG SET
+// loglevel applies to the whole server.
+var level slog.LevelVar
+
+// SetLevel sets the minimum level of the records written.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+func CurrentLevel() slog.Level {
+	return level.Level()
+}
+
+// New returns a logger writing records of the current level or above to
+// w. format is "text" or "json".
+func New(w io.Writer, format string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{
+		Level: &level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && len(groups) == 0 {
+				a.Value = slog.StringValue(LevelName(a.Value.Any().(slog.Level)))
+			}
+			return a
+		},
+	}
+
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q, must be text or json", format)
+}
+
+// Discard returns a logger that drops every record.
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// Verbose logs at LevelVerbose, which has no slog.Logger method.
+func Verbose(logger *slog.Logger, msg string, args ...any) {
+	logger.Log(context.Background(), LevelVerbose, msg, args...)
+}
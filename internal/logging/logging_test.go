@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	defer SetLevel(LevelNotice)
+
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	level, err := ParseLevel("VERBOSE")
+	if err != nil || level != LevelVerbose {
+		t.Fatalf("ParseLevel: got %v, %v", level, err)
+	}
+	SetLevel(level)
+
+	logger.Debug("dropped")
+	Verbose(logger, "kept", "keys", 3)
+	logger.Warn("kept too")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 records, got %q", buf.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["level"] != "verbose" || record["msg"] != "kept" || record["keys"] != 3.0 {
+		t.Errorf("Unexpected record %v", record)
+	}
+	if !strings.Contains(lines[1], `"level":"warning"`) {
+		t.Errorf("Expected a warning record, got %s", lines[1])
+	}
+
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+	if _, err := New(&buf, "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "myredis.log")
+
+	file, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	logger, err := New(file, "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("before rotation")
+	rotated := filepath.Join(dir, "myredis.log.1")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("still in the rotated file")
+	if err := file.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	logger.Info("after rotation")
+
+	old, _ := os.ReadFile(rotated)
+	current, _ := os.ReadFile(path)
+	if !strings.Contains(string(old), "still in the rotated file") || strings.Contains(string(old), "after rotation") {
+		t.Errorf("Unexpected rotated file %q", old)
+	}
+	if !strings.Contains(string(current), "level=notice msg=\"after rotation\"") {
+		t.Errorf("Unexpected log file %q", current)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// Each record lands in one file; the oldest rotated file is dropped.
+	for _, record := range []string{"one\n", "two\n", "three\n", "four\n", "five\n", "six\n"} {
+		if _, err := file.Write([]byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "six\n",
+		path + ".1": "four\nfive\n",
+		path + ".2": "two\nthree\n",
+		path + ".3": "",
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(name)
+		if content == "" {
+			if !os.IsNotExist(err) {
+				t.Errorf("Expected %s to be removed, got %q", name, data)
+			}
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s to hold %q, got %q", name, content, data)
+		}
+	}
+}
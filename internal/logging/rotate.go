@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// RotatingFile is an append-only file that is rotated before a write
+// would grow it beyond maxSize bytes: path.1 becomes path.2 and so on,
+// path becomes path.1 and path is created anew. At most backups rotated
+// files are kept. A zero maxSize never rotates.
+type RotatingFile struct {
+	path    string
+	maxSize int64
+	backups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func OpenRotatingFile(path string, maxSize int64, backups int) (*RotatingFile, error) {
+	file, err := openLog(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &RotatingFile{
+		path:    path,
+		maxSize: maxSize,
+		backups: backups,
+		file:    file,
+		size:    info.Size(),
+	}, nil
+}
+
+// Write writes p as a whole to a single file, so that records written
+// with one call are never split across a rotation.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the rotated files and starts a new file; mu must be held.
+// If the new file cannot be created, writes continue on the current one.
+func (f *RotatingFile) rotate() error {
+	for i := f.backups - 1; i >= 1; i-- {
+		if err := os.Rename(f.backupPath(i), f.backupPath(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	var err error
+	if f.backups > 0 {
+		err = os.Rename(f.path, f.backupPath(1))
+	} else {
+		err = os.Remove(f.path)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	file, err := openLog(f.path)
+	if err != nil {
+		return err
+	}
+	old := f.file
+	f.file = file
+	f.size = 0
+	return old.Close()
+}
+
+func (f *RotatingFile) backupPath(i int) string {
+	return f.path + "." + strconv.Itoa(i)
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
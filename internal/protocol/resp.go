@@ -165,8 +165,14 @@ func (w *RESPWriter) Write(value Value) error {
 	case Integer:
 		return w.writeInteger(value.Num)
 	case BulkString:
+		if value.IsNull {
+			return w.writeNullBulkString()
+		}
 		return w.writeBulkString(value.Bulk)
 	case Array:
+		if value.IsNull {
+			return w.writeNullArray()
+		}
 		return w.writeArray(value.Array)
 	default:
 		return ErrUnsupportedType
@@ -198,14 +204,32 @@ func (w *RESPWriter) writeInteger(n int) error {
 }
 
 func (w *RESPWriter) writeBulkString(s string) error {
-	if s == "" {
-		if _, err := w.writer.WriteString("$-1\r\n"); err != nil {
-			return err
-		}
-	} else {
-		if _, err := w.writer.WriteString("$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"); err != nil {
-			return err
-		}
+	if _, err := w.writer.WriteString("$" + strconv.Itoa(len(s)) + "\r\n"); err != nil {
+		return err
+	}
+
+	if _, err := w.writer.WriteString(s); err != nil {
+		return err
+	}
+
+	if _, err := w.writer.WriteString("\r\n"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *RESPWriter) writeNullBulkString() error {
+	if _, err := w.writer.WriteString("$-1\r\n"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *RESPWriter) writeNullArray() error {
+	if _, err := w.writer.WriteString("*-1\r\n"); err != nil {
+		return err
 	}
 
 	return nil
@@ -225,6 +249,33 @@ func (w *RESPWriter) writeArray(arr []Value) error {
 	return nil
 }
 
+// EncodedLen returns the number of bytes Write produces for value.
+func EncodedLen(value Value) int {
+	switch value.Type {
+	case SimpleString:
+		return len(value.Str) + 3
+	case Error:
+		return len(value.Str) + 3
+	case Integer:
+		return len(strconv.Itoa(value.Num)) + 3
+	case BulkString:
+		if value.IsNull {
+			return 5
+		}
+		return len(strconv.Itoa(len(value.Bulk))) + len(value.Bulk) + 5
+	case Array:
+		if value.IsNull {
+			return 5
+		}
+		n := len(strconv.Itoa(len(value.Array))) + 3
+		for _, item := range value.Array {
+			n += EncodedLen(item)
+		}
+		return n
+	}
+	return 0
+}
+
 func (w *RESPWriter) Flush() error {
 	return w.writer.Flush()
 }
@@ -243,7 +294,7 @@ func (w *RESPWriter) WriteInteger(n int) error {
 }
 
 func (w *RESPWriter) WriteNull() error {
-	return w.writeBulkString("")
+	return w.writeNullBulkString()
 }
 
 func (w *RESPWriter) WriteError(err error) error {
@@ -177,3 +177,117 @@ func TestRESPWriter_WriteArray(t *testing.T) {
 		t.Errorf("Expected '%s', got '%s'", expected, buf.String())
 	}
 }
+
+func TestRESPWriter_WriteEmptyBulkString(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewRESPWriter(&buf)
+
+	err := writer.Write(Value{Type: BulkString, Bulk: ""})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	writer.Flush()
+
+	expected := "$0\r\n\r\n"
+	if buf.String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, buf.String())
+	}
+}
+
+func TestRESPWriter_WriteNull(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewRESPWriter(&buf)
+
+	if err := writer.Write(Value{Type: BulkString, IsNull: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := writer.Write(Value{Type: Array, IsNull: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := writer.WriteNull(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	writer.Flush()
+
+	expected := "$-1\r\n*-1\r\n$-1\r\n"
+	if buf.String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, buf.String())
+	}
+}
+
+func TestRESP_BinaryRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"hello",
+		"line\r\nbreak",
+		"\x00\x01\x02\xff",
+		"$-1\r\n",
+		"\r\n\r\n",
+	}
+
+	var buf bytes.Buffer
+	writer := NewRESPWriter(&buf)
+
+	array := make([]Value, len(values))
+	for i, v := range values {
+		array[i] = Value{Type: BulkString, Bulk: v}
+	}
+	array = append(array, Value{Type: BulkString, IsNull: true})
+
+	if err := writer.Write(Value{Type: Array, Array: array}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	writer.Flush()
+
+	reader := NewRESPReader(&buf)
+	value, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(value.Array) != len(values)+1 {
+		t.Fatalf("Expected array length %d, got %d", len(values)+1, len(value.Array))
+	}
+
+	for i, v := range values {
+		got := value.Array[i]
+		if got.IsNull {
+			t.Errorf("Element %d: expected non-null bulk string", i)
+		}
+		if got.Bulk != v {
+			t.Errorf("Element %d: expected %q, got %q", i, v, got.Bulk)
+		}
+	}
+
+	if !value.Array[len(values)].IsNull {
+		t.Error("Expected last element to be null")
+	}
+}
+
+func TestEncodedLen(t *testing.T) {
+	values := []Value{
+		{Type: SimpleString, Str: "OK"},
+		{Type: Error, Str: "ERR oops"},
+		{Type: Integer, Num: -42},
+		{Type: BulkString, Bulk: "hello"},
+		{Type: BulkString, IsNull: true},
+		{Type: Array, IsNull: true},
+		{Type: Array, Array: []Value{
+			{Type: BulkString, Bulk: strings.Repeat("x", 100)},
+			{Type: Array, Array: []Value{{Type: Integer, Num: 7}}},
+		}},
+	}
+
+	for _, v := range values {
+		var buf bytes.Buffer
+		writer := NewRESPWriter(&buf)
+		writer.Write(v)
+		writer.Flush()
+
+		if n := EncodedLen(v); n != buf.Len() {
+			t.Errorf("EncodedLen(%+v) = %d, expected %d", v, n, buf.Len())
+		}
+	}
+}
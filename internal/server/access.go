@@ -0,0 +1,124 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"ivanSaichkin/myredis/internal/command"
+	"ivanSaichkin/myredis/internal/config"
+	"net"
+	"strings"
+)
+
+var (
+	ErrProtectedMode = errors.New("running in protected mode because protected mode is enabled and no password is set for the default user. " +
+		"In this mode connections are only accepted from the loopback interface. " +
+		"Set a password with RequirePass or ACL SETUSER, or disable ProtectedMode")
+	ErrAddressDenied     = errors.New("client address is not allowed")
+	ErrAddressNotAllowed = errors.New("client address is not in the allowlist")
+)
+
+// AccessControl decides which client addresses may connect. Unix socket
+// clients are always local and bypass the address checks.
+type AccessControl struct {
+	protectedMode bool
+	allow         []*net.IPNet
+	deny          []*net.IPNet
+	acl           *command.ACL
+}
+
+// NewAccessControl parses the allow and deny lists from cfg. Protected
+// mode is lifted as soon as acl requires a password for the default user.
+func NewAccessControl(cfg *config.Config, acl *command.ACL) (*AccessControl, error) {
+	allow, err := parseNetworks(cfg.AllowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseNetworks(cfg.DenyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessControl{
+		protectedMode: cfg.ProtectedMode,
+		allow:         allow,
+		deny:          deny,
+		acl:           acl,
+	}, nil
+}
+
+// Check returns an error if a client connecting from addr must be
+// rejected. The deny list takes precedence over the allow list.
+func (a *AccessControl) Check(addr net.Addr) error {
+	ip := addrIP(addr)
+	if ip == nil {
+		return nil
+	}
+
+	if containsIP(a.deny, ip) {
+		return ErrAddressDenied
+	}
+	if len(a.allow) > 0 && !containsIP(a.allow, ip) {
+		return ErrAddressNotAllowed
+	}
+	if a.protectedMode && !ip.IsLoopback() && !a.acl.RequiresAuth() {
+		return ErrProtectedMode
+	}
+	return nil
+}
+
+// ProtectedModeActive reports whether non-loopback clients are currently
+// refused by protected mode.
+func (a *AccessControl) ProtectedModeActive() bool {
+	return a.protectedMode && !a.acl.RequiresAuth()
+}
+
+func addrIP(addr net.Addr) net.IP {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		return addr.IP
+	case *net.UnixAddr:
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNetworks accepts CIDR blocks and single addresses.
+func parseNetworks(entries []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
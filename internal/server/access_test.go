@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"ivanSaichkin/myredis/internal/command"
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func tcpAddr(ip string) net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP(ip), Port: 50000}
+}
+
+func TestAccessControlProtectedMode(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	acl := command.NewACL("")
+
+	access, err := NewAccessControl(cfg, acl)
+	if err != nil {
+		t.Fatalf("NewAccessControl failed: %v", err)
+	}
+
+	if err := access.Check(tcpAddr("127.0.0.1")); err != nil {
+		t.Errorf("Loopback clients should be accepted, got %v", err)
+	}
+	if err := access.Check(tcpAddr("::1")); err != nil {
+		t.Errorf("IPv6 loopback clients should be accepted, got %v", err)
+	}
+	if err := access.Check(tcpAddr("10.1.2.3")); err != ErrProtectedMode {
+		t.Errorf("Expected ErrProtectedMode, got %v", err)
+	}
+	if err := access.Check(&net.UnixAddr{Name: "/tmp/myredis.sock", Net: "unix"}); err != nil {
+		t.Errorf("Unix socket clients should be accepted, got %v", err)
+	}
+
+	// Setting a password lifts protected mode.
+	acl.SetUser(command.DefaultUser, ">secret")
+	if err := access.Check(tcpAddr("10.1.2.3")); err != nil {
+		t.Errorf("Expected remote clients to be accepted with a password set, got %v", err)
+	}
+}
+
+func TestAccessControlCIDRLists(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	cfg.ProtectedMode = false
+	cfg.AllowCIDRs = []string{"10.0.0.0/8", "192.168.1.5", "fd00::/8"}
+	cfg.DenyCIDRs = []string{"10.0.0.0/24"}
+
+	access, err := NewAccessControl(cfg, command.NewACL(""))
+	if err != nil {
+		t.Fatalf("NewAccessControl failed: %v", err)
+	}
+
+	tests := []struct {
+		ip  string
+		err error
+	}{
+		{"10.1.2.3", nil},
+		{"10.0.0.7", ErrAddressDenied},
+		{"192.168.1.5", nil},
+		{"192.168.1.6", ErrAddressNotAllowed},
+		{"fd00::1", nil},
+		{"::ffff:10.1.2.3", nil},
+	}
+	for _, tt := range tests {
+		if err := access.Check(tcpAddr(tt.ip)); err != tt.err {
+			t.Errorf("Check(%s): expected %v, got %v", tt.ip, tt.err, err)
+		}
+	}
+
+	for _, entries := range [][]string{{"10.0.0.0/33"}, {"not-an-ip"}} {
+		cfg.AllowCIDRs = entries
+		if _, err := NewAccessControl(cfg, command.NewACL("")); err == nil {
+			t.Errorf("Expected NewAccessControl to reject %v", entries)
+		}
+	}
+}
+
+func TestRejectedConnectionGetsError(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	cfg.DenyCIDRs = []string{"127.0.0.0/8"}
+
+	handler := NewHandler(storage.NewMemoryStorage(), cfg)
+	access, err := NewAccessControl(cfg, handler.ACL())
+	if err != nil {
+		t.Fatalf("NewAccessControl failed: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := NewTCPServer(listener.Addr().String(), handler)
+	srv.SetAccessControl(access)
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+	addr := listener.Addr().String()
+
+	conn := dial(t, addr)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reply, err := conn.reader.Read()
+	if err != nil {
+		t.Fatalf("Failed to read rejection: %v", err)
+	}
+	if reply.Type != protocol.Error || !strings.HasPrefix(reply.Str, "DENIED ") {
+		t.Errorf("Expected a DENIED error, got %+v", reply)
+	}
+
+	if _, err := conn.reader.Read(); err == nil {
+		t.Error("Expected the rejected connection to be closed")
+	}
+}
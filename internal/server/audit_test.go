@@ -0,0 +1,60 @@
+package server
+
+import (
+	"encoding/json"
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	cfg := config.DefaulteConfig()
+	cfg.AuditLogFile = path
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), cfg)
+	conn := dial(t, addr)
+
+	expectStatus(t, conn.do(t, "CONFIG", "SET", "audit-log-redact", "none", "audit-log-max-size", "1mb"), "OK")
+	conn.do(t, "SET", "key", "value")
+	conn.do(t, "GET", "key")
+	conn.do(t, "MULTI")
+	conn.do(t, "DEL", "key")
+	conn.do(t, "EXEC")
+	// The file can only be set at startup.
+	expectError(t, conn.do(t, "CONFIG", "SET", "audit-log", filepath.Join(t.TempDir(), "other.log")),
+		"ERR CONFIG SET failed - can't set immutable parameter 'audit-log'")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var commands []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record struct {
+			Command string   `json:"command"`
+			Args    []string `json:"args"`
+			User    string   `json:"user"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid audit line %q: %v", line, err)
+		}
+		if record.User != "default" {
+			t.Errorf("Expected the default user, got %q", record.User)
+		}
+		commands = append(commands, record.Command+" "+strings.Join(record.Args, " "))
+	}
+
+	// CONFIG SET arguments are always redacted.
+	expected := []string{
+		"config|set (redacted) (redacted) (redacted) (redacted)",
+		"set key value",
+		"del key",
+		"config|set (redacted) (redacted)",
+	}
+	if strings.Join(commands, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected audit records %q, got %q", expected, commands)
+	}
+}
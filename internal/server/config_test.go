@@ -0,0 +1,121 @@
+package server
+
+import (
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func configValues(t *testing.T, reply protocol.Value) map[string]string {
+	t.Helper()
+
+	if reply.Type != protocol.Array || len(reply.Array)%2 != 0 {
+		t.Fatalf("CONFIG GET: unexpected reply %+v", reply)
+	}
+	values := make(map[string]string)
+	for i := 0; i < len(reply.Array); i += 2 {
+		values[reply.Array[i].Bulk] = reply.Array[i+1].Bulk
+	}
+	return values
+}
+
+func TestConfigGetSet(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), cfg)
+	conn := dial(t, addr)
+
+	values := configValues(t, conn.do(t, "CONFIG", "GET", "slowlog-*", "MAXCLIENTS"))
+	if len(values) != 3 || values["maxclients"] != "10000" || values["slowlog-max-len"] != "128" {
+		t.Errorf("CONFIG GET: unexpected values %v", values)
+	}
+
+	expectStatus(t, conn.do(t, "CONFIG", "SET", "maxclients", "1", "slowlog-log-slower-than", "0"), "OK")
+	values = configValues(t, conn.do(t, "CONFIG", "GET", "maxclients", "slowlog-log-slower-than"))
+	if values["maxclients"] != "1" || values["slowlog-log-slower-than"] != "0" {
+		t.Errorf("CONFIG GET after SET: unexpected values %v", values)
+	}
+
+	// The new limit applies to the next connection.
+	rejected := dial(t, addr)
+	expectError(t, rejected.do(t, "PING"), "ERR max number of clients reached")
+
+	// Every command is now slower than the threshold.
+	conn.do(t, "SET", "key", "value")
+	if reply := conn.do(t, "SLOWLOG", "LEN"); reply.Num == 0 {
+		t.Error("Expected commands to be logged after lowering the threshold")
+	}
+
+	expectError(t, conn.do(t, "CONFIG", "SET", "address", ":7000"), "ERR CONFIG SET failed - can't set immutable parameter 'address'")
+	expectError(t, conn.do(t, "CONFIG", "SET", "nosuch", "1"), "ERR CONFIG SET failed - unknown parameter 'nosuch'")
+	expectError(t, conn.do(t, "CONFIG", "SET", "maxclients"), "ERR wrong number of arguments")
+
+	// A failed SET leaves every parameter unchanged.
+	expectError(t, conn.do(t, "CONFIG", "SET", "slowlog-max-len", "5", "timeout", "soon"), "ERR CONFIG SET failed - timeout")
+	if values := configValues(t, conn.do(t, "CONFIG", "GET", "slowlog-max-len")); values["slowlog-max-len"] != "128" {
+		t.Errorf("Expected slowlog-max-len to be unchanged, got %v", values)
+	}
+}
+
+func TestConfigSetTimeout(t *testing.T) {
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), config.DefaulteConfig())
+	conn := dial(t, addr)
+
+	expectStatus(t, conn.do(t, "CONFIG", "SET", "timeout", "1"), "OK")
+
+	idle := dial(t, addr)
+	expectStatus(t, idle.do(t, "PING"), "PONG")
+	idle.SetReadDeadline(time.Now().Add(3 * time.Second))
+	if _, err := idle.reader.Read(); err == nil {
+		t.Error("Expected the idle connection to be closed")
+	}
+}
+
+func TestConfigRewrite(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), cfg)
+	conn := dial(t, addr)
+	expectError(t, conn.do(t, "CONFIG", "REWRITE"), "ERR Rewriting config file: the server is running without a config file")
+
+	path := filepath.Join(t.TempDir(), "myredis.conf")
+	if err := os.WriteFile(path, []byte("# comment\nmaxclients 100\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg = config.DefaulteConfig()
+	cfg.ConfigFile = path
+	_, addr, _ = startServer(t, storage.NewMemoryStorage(), cfg)
+	conn = dial(t, addr)
+
+	expectStatus(t, conn.do(t, "CONFIG", "SET", "maxclients", "50", "requirepass", "s3cret pass"), "OK")
+	// Connections that have not authenticated must do so once a password
+	// is required.
+	expectStatus(t, conn.do(t, "AUTH", "s3cret pass"), "OK")
+	expectStatus(t, conn.do(t, "CONFIG", "REWRITE"), "OK")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# comment\nmaxclients 50\nrequirepass \"s3cret pass\"\n"
+	if string(data) != expected {
+		t.Errorf("Expected config file %q, got %q", expected, data)
+	}
+}
+
+func TestConfigResetStat(t *testing.T) {
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), config.DefaulteConfig())
+	conn := dial(t, addr)
+
+	conn.do(t, "SET", "key", "value")
+	conn.do(t, "GET", "key")
+	expectStatus(t, conn.do(t, "CONFIG", "RESETSTAT"), "OK")
+
+	info := conn.do(t, "INFO", "stats").Bulk
+	if !strings.Contains(info, "total_commands_processed:1\r\n") {
+		t.Errorf("Expected statistics to be reset, got %q", info)
+	}
+}
@@ -1,30 +1,133 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"ivanSaichkin/myredis/internal/command"
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/logging"
 	"ivanSaichkin/myredis/internal/protocol"
 	"ivanSaichkin/myredis/internal/storage"
+	"log/slog"
 	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+var errOutputLimit = errors.New("client output buffer limit exceeded")
+
 type Handler struct {
 	storage  storage.Storage
 	executor *command.Executor
+	clients  *command.ClientRegistry
+	acl      *command.ACL
+	config   *runtimeConfig
+	logger   *slog.Logger
+	limiter  *rateLimiter
+
+	// idleTimeout and writeTimeout are time.Durations, changed by CONFIG
+	// SET.
+	idleTimeout  atomic.Int64
+	writeTimeout atomic.Int64
+
+	// closing is set when the server shuts down; connections exit after
+	// the command they are executing. done is closed at the same time to
+	// wake connections delayed by the rate limit.
+	closing   atomic.Bool
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-func NewHandler(store storage.Storage) *Handler {
-	return &Handler{
+func NewHandler(store storage.Storage, cfg *config.Config) *Handler {
+	clients := command.NewClientRegistry(cfg.MaxClients)
+
+	acl := command.NewACL(cfg.ACLFile)
+	executor := command.NewExecutor(store, clients)
+	executor.SetACL(acl)
+
+	h := &Handler{
 		storage:  store,
-		executor: command.NewExecutor(store),
+		executor: executor,
+		clients:  clients,
+		acl:      acl,
+		logger:   slog.Default(),
+		limiter:  newRateLimiter(),
+		done:     make(chan struct{}),
 	}
+	h.config = newRuntimeConfig(h, cfg)
+	executor.SetConfigController(h.config)
+	if err := h.applyConfig(nil, cfg); err != nil {
+		h.logger.Warn("Invalid configuration", "error", err)
+	}
+	return h
+}
+
+// Config returns a copy of the running configuration, including changes
+// made with CONFIG SET.
+func (h *Handler) Config() *config.Config {
+	return h.config.current()
+}
+
+// SetLogger sets the logger used by the handler and its executor. It must
+// be called before connections are served.
+func (h *Handler) SetLogger(logger *slog.Logger) {
+	h.logger = logger
+	h.executor.SetLogger(logger)
+}
+
+// ACL returns the users and permissions enforced on connections.
+func (h *Handler) ACL() *command.ACL {
+	return h.acl
 }
 
 func (h *Handler) HandleConnection(conn net.Conn) error {
-	reader := protocol.NewRESPReader(conn)
+	counter := &countingReader{conn: conn}
+	reader := protocol.NewRESPReader(counter)
 	writer := protocol.NewRESPWriter(conn)
 	parser := command.NewParser(reader)
 
+	client, err := h.clients.Register(conn)
+	if err != nil {
+		writer.Write(protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR " + err.Error(),
+		})
+		writer.Flush()
+		return err
+	}
+	defer h.clients.Unregister(client)
+	defer func() {
+		if reason := client.CloseReason(); reason != "" {
+			h.logger.Warn("Disconnected client", "id", client.ID, "addr", client.Addr, "class", client.Class().String(), "reason", reason)
+		}
+	}()
+	defer h.executor.ClientClosed(client)
+
+	out := &replyWriter{writer: writer, conn: conn, client: client, handler: h}
+	defer out.close()
+
+	tx := &transaction{}
+
 	for {
+		// Subscribers and monitors wait for messages, not commands, so
+		// they are never considered idle. The deadline is cleared when
+		// the timeout is disabled, as CONFIG SET may have done since the
+		// last command.
+		var deadline time.Time
+		idleTimeout := time.Duration(h.idleTimeout.Load())
+		if idleTimeout > 0 && client.SubscriptionCount() == 0 && !client.IsMonitor() {
+			deadline = time.Now().Add(idleTimeout)
+		}
+		conn.SetReadDeadline(deadline)
+
+		// Checked after arming the deadline so that beginShutdown's
+		// interrupt is never overwritten by a fresh idle deadline.
+		if h.closing.Load() {
+			return nil
+		}
+
 		cmd, err := parser.ParseCommand()
 		if err != nil {
 			if err == protocol.ErrInvalidSyntax {
@@ -33,25 +136,196 @@ func (h *Handler) HandleConnection(conn net.Conn) error {
 					Str:  "ERR Protocol error: invalid syntax",
 				}
 
-				if err := writer.Write(respErr); err != nil {
+				if err := out.write(respErr); err != nil {
 					return err
 				}
-				writer.Flush()
 				continue
 			}
-			if err.Error() == "EOF" {
+			if err.Error() == "EOF" || errors.Is(err, net.ErrClosed) || h.closing.Load() {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				logging.Verbose(h.logger, "Closing idle client", "id", client.ID, "addr", client.Addr, "timeout", time.Duration(h.idleTimeout.Load()))
 				return nil
 			}
 			return err
 		}
 
-		resp := h.executor.Execute(cmd)
+		wait, reject := h.limiter.throttle(client, counter.consume())
+		if reject {
+			// Like any command that fails to queue, a throttled command
+			// makes EXEC discard the transaction.
+			if tx.active {
+				tx.aborted = true
+			}
+			if err := out.write(throttledReply()); err != nil {
+				return err
+			}
+			continue
+		}
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-h.done:
+				timer.Stop()
+				return nil
+			}
+		}
 
-		if err := writer.Write(resp); err != nil {
+		resp := h.execute(client, tx, cmd)
+
+		if err := out.write(resp); err != nil {
 			return err
 		}
-		if err := writer.Flush(); err != nil {
-			return err
+
+		if client.ShouldClose() {
+			return nil
 		}
 	}
 }
+
+// replyWriter writes replies straight to the connection until the client
+// first subscribes. From then on replies are queued on the client together
+// with Pub/Sub messages and written by a delivery goroutine, so messages
+// can arrive while the connection waits for the next command. Either way
+// the bytes waiting to be written count against the client's output
+// buffer limit, and writes time out after the write timeout.
+type replyWriter struct {
+	writer  *protocol.RESPWriter
+	conn    net.Conn
+	client  *command.Client
+	handler *Handler
+
+	delivering bool
+	done       chan struct{}
+	wg         sync.WaitGroup
+}
+
+func (w *replyWriter) write(v protocol.Value) error {
+	messages := w.client.Messages()
+	if messages == nil {
+		size := int64(protocol.EncodedLen(v))
+		if !w.client.ReserveOutput(size) {
+			return errOutputLimit
+		}
+		defer w.client.ReleaseOutput(size)
+
+		// A reply above the soft limit may only take as long to drain
+		// as the limit allows.
+		timeout := time.Duration(w.handler.writeTimeout.Load())
+		overSoft := false
+		if limit := w.client.OutputLimit(); limit.Soft > 0 && size > limit.Soft && limit.SoftDuration > 0 &&
+			(timeout == 0 || limit.SoftDuration < timeout) {
+			timeout = limit.SoftDuration
+			overSoft = true
+		}
+		w.setWriteDeadline(timeout)
+
+		err := w.writer.Write(v)
+		if err == nil {
+			err = w.writer.Flush()
+		}
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			if overSoft {
+				w.client.DisconnectForOutputLimit(fmt.Sprintf("output buffer above the soft limit of %d bytes for %v", w.client.OutputLimit().Soft, timeout))
+			} else {
+				w.client.CloseWithReason(fmt.Sprintf("write timed out after %v", timeout))
+			}
+		}
+		return err
+	}
+
+	if !w.delivering {
+		w.delivering = true
+		w.done = make(chan struct{})
+		w.wg.Add(1)
+		go w.deliver(messages)
+	}
+
+	if !command.IsNoReply(v) && !w.client.Push(v) {
+		return errOutputLimit
+	}
+	return nil
+}
+
+func (w *replyWriter) setWriteDeadline(timeout time.Duration) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	w.conn.SetWriteDeadline(deadline)
+}
+
+func (w *replyWriter) deliver(messages <-chan protocol.Value) {
+	defer w.wg.Done()
+
+	// pending counts the bytes written to the buffer but not flushed;
+	// they are released from the output buffer once flushed.
+	var pending int64
+	fail := func(err error) {
+		timeout := time.Duration(w.handler.writeTimeout.Load())
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			w.client.CloseWithReason(fmt.Sprintf("write timed out after %v", timeout))
+		} else {
+			w.client.Close()
+		}
+	}
+
+	for {
+		select {
+		case v := <-messages:
+			w.setWriteDeadline(time.Duration(w.handler.writeTimeout.Load()))
+			if err := w.writer.Write(v); err != nil {
+				fail(err)
+				return
+			}
+			pending += int64(protocol.EncodedLen(v))
+			if len(messages) == 0 {
+				if err := w.writer.Flush(); err != nil {
+					fail(err)
+					return
+				}
+				w.client.ReleaseOutput(pending)
+				pending = 0
+			}
+		case <-w.done:
+			// Flush what is already queued, e.g. the reply to the
+			// command that closes the connection.
+			w.setWriteDeadline(time.Duration(w.handler.writeTimeout.Load()))
+			for {
+				select {
+				case v := <-messages:
+					w.writer.Write(v)
+				default:
+					w.writer.Flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *replyWriter) close() {
+	if w.delivering {
+		close(w.done)
+		w.wg.Wait()
+	}
+}
+
+// beginShutdown makes every connection return once its current command has
+// been answered, interrupting connections blocked waiting for a command.
+func (h *Handler) beginShutdown() {
+	h.closing.Store(true)
+	h.closeOnce.Do(func() { close(h.done) })
+	for _, client := range h.clients.List() {
+		client.InterruptRead()
+	}
+}
+
+func (h *Handler) closeConnections() {
+	for _, client := range h.clients.List() {
+		client.Close()
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"ivanSaichkin/myredis/internal/config"
+	"net"
+	"os"
+	"sync"
+)
+
+// Listen opens the listener described by lc, wrapping it in TLS when lc.TLS
+// is set. A stale Unix socket file left behind by a previous run is
+// replaced; any other file at the socket path is an error.
+func Listen(lc config.ListenerConfig, tlsConfig *tls.Config) (net.Listener, error) {
+	network := lc.Network
+	if network == "" {
+		network = "tcp"
+	}
+
+	if lc.TLS && tlsConfig == nil {
+		return nil, fmt.Errorf("listener %s: TLS is not configured", lc.Address)
+	}
+
+	var listener net.Listener
+	var err error
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		listener, err = net.Listen(network, lc.Address)
+	case "unix":
+		listener, err = listenUnix(lc.Address, lc.Permissions)
+	default:
+		return nil, fmt.Errorf("listener %s: unsupported network %q", lc.Address, network)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s %s: %v", network, lc.Address, err)
+	}
+
+	if lc.TLS {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	return listener, nil
+}
+
+func listenUnix(path string, perm os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if perm != 0 {
+		if err := os.Chmod(path, perm); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return listener, nil
+}
+
+// ListenAndServe opens every listener before serving any of them, so a bad
+// address fails startup as a whole, then serves them all with the shared
+// handler until Shutdown. It returns ErrServerClosed after a shutdown or the
+// first error that stopped a listener.
+func (t *TCPServer) ListenAndServe(listenerConfigs []config.ListenerConfig, tlsConfig *tls.Config) error {
+	if len(listenerConfigs) == 0 {
+		return errors.New("no listeners configured")
+	}
+
+	listeners := make([]net.Listener, 0, len(listenerConfigs))
+	for _, lc := range listenerConfigs {
+		listener, err := Listen(lc, tlsConfig)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return err
+		}
+		listeners = append(listeners, listener)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(listeners))
+	for i, listener := range listeners {
+		lc := listenerConfigs[i]
+		t.logger.Info("Listening", "listener", lc.Name, "network", listener.Addr().Network(), "address", listener.Addr().String(), "tls", lc.TLS)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- t.Serve(listener)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	result := ErrServerClosed
+	for err := range errs {
+		if err != ErrServerClosed {
+			result = err
+		}
+	}
+	return result
+}
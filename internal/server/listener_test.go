@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenAndServeMultipleListeners(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "myredis.sock")
+
+	// A stale socket from a previous run is replaced.
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to create stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	srv := NewTCPServer("", NewHandler(storage.NewMemoryStorage(), config.DefaulteConfig()))
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.ListenAndServe([]config.ListenerConfig{
+			{Name: "main", Network: "tcp", Address: "127.0.0.1:0"},
+			{Name: "local", Network: "unix", Address: socket, Permissions: 0700},
+		}, nil)
+	}()
+
+	var conn net.Conn
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if conn, err = net.Dial("unix", socket); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Failed to connect to Unix socket: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer conn.Close()
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatalf("Failed to stat socket: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("Expected socket permissions 0700, got %o", perm)
+	}
+
+	pingOver(t, conn)
+
+	writer := protocol.NewRESPWriter(conn)
+	writer.Write(protocol.Value{Type: protocol.Array, Array: []protocol.Value{
+		{Type: protocol.BulkString, Bulk: "CLIENT"},
+		{Type: protocol.BulkString, Bulk: "INFO"},
+	}})
+	writer.Flush()
+	reply, err := protocol.NewRESPReader(conn).Read()
+	if err != nil {
+		t.Fatalf("Failed to read CLIENT INFO reply: %v", err)
+	}
+	if !strings.Contains(reply.Bulk, "addr="+socket+":0") {
+		t.Errorf("Expected the Unix socket client address in %q", reply.Bulk)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if err := <-served; err != ErrServerClosed {
+		t.Errorf("Expected ErrServerClosed, got %v", err)
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket file to be removed, got %v", err)
+	}
+}
+
+func TestListenRejectsInvalidListeners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regular-file")
+	writeFile(t, path, []byte("data"))
+
+	tests := []config.ListenerConfig{
+		{Network: "unix", Address: path},
+		{Network: "udp", Address: "127.0.0.1:0"},
+		{Network: "tcp", Address: "127.0.0.1:0", TLS: true},
+	}
+	for _, lc := range tests {
+		if listener, err := Listen(lc, nil); err == nil {
+			listener.Close()
+			t.Errorf("Expected Listen(%+v) to fail", lc)
+		}
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("A regular file at the socket path must not be removed: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"bytes"
+	"ivanSaichkin/myredis/internal/command"
+	"ivanSaichkin/myredis/internal/storage"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metrics serves the server's metrics in the Prometheus text exposition
+// format.
+type Metrics struct {
+	handler *Handler
+}
+
+func NewMetrics(handler *Handler) *Metrics {
+	return &Metrics{handler: handler}
+}
+
+// NewMetricsServer returns an HTTP server serving the metrics at /metrics.
+func NewMetricsServer(addr string, handler *Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", NewMetrics(handler))
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var out metricsWriter
+	m.writeCommands(&out)
+	m.writeClients(&out)
+	m.writeThrottling(&out)
+	m.writeKeyspace(&out)
+	m.writePersistence(&out)
+	writeRuntime(&out)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(out.buf.Bytes())
+}
+
+func (m *Metrics) writeCommands(out *metricsWriter) {
+	stats := m.handler.executor.CommandStats()
+
+	out.family("myredis_commands_total", "counter", "Commands executed, by command.")
+	for _, s := range stats {
+		out.sample("myredis_commands_total", float64(s.Calls), "cmd", s.Name)
+	}
+
+	out.family("myredis_command_errors_total", "counter", "Commands that replied with an error, by command.")
+	for _, s := range stats {
+		out.sample("myredis_command_errors_total", float64(s.FailedCalls), "cmd", s.Name)
+	}
+
+	bounds := commandLatencyBuckets()
+	out.family("myredis_command_duration_seconds", "histogram", "Command execution latency, by command.")
+	for _, s := range stats {
+		var cumulative int64
+		for i, bound := range bounds {
+			cumulative += s.Buckets[i]
+			out.sample("myredis_command_duration_seconds_bucket", float64(cumulative), "cmd", s.Name, "le", formatFloat(bound))
+		}
+		out.sample("myredis_command_duration_seconds_bucket", float64(s.Calls), "cmd", s.Name, "le", "+Inf")
+		out.sample("myredis_command_duration_seconds_sum", s.Duration.Seconds(), "cmd", s.Name)
+		out.sample("myredis_command_duration_seconds_count", float64(s.Calls), "cmd", s.Name)
+	}
+}
+
+func (m *Metrics) writeClients(out *metricsWriter) {
+	clients := m.handler.clients
+
+	out.family("myredis_connected_clients", "gauge", "Connected clients.")
+	out.sample("myredis_connected_clients", float64(clients.Len()))
+
+	out.family("myredis_connections_received_total", "counter", "Connections accepted.")
+	out.sample("myredis_connections_received_total", float64(clients.TotalConnections()))
+
+	out.family("myredis_connections_rejected_total", "counter", "Connections rejected because of the client limit.")
+	out.sample("myredis_connections_rejected_total", float64(clients.RejectedConnections()))
+
+	out.family("myredis_output_buffer_limit_disconnections_total", "counter", "Clients disconnected for exceeding an output buffer limit.")
+	out.sample("myredis_output_buffer_limit_disconnections_total", float64(clients.OutputLimitDisconnections()))
+
+	out.family("myredis_uptime_seconds", "gauge", "Time since the server started.")
+	out.sample("myredis_uptime_seconds", time.Since(m.handler.executor.StartTime()).Seconds())
+}
+
+func (m *Metrics) writeThrottling(out *metricsWriter) {
+	limiter := m.handler.limiter
+
+	out.family("myredis_throttled_commands_total", "counter", "Commands over the rate limit, by action taken.")
+	out.sample("myredis_throttled_commands_total", float64(limiter.rejectedCommands.Load()), "action", "rejected")
+	out.sample("myredis_throttled_commands_total", float64(limiter.delayedCommands.Load()), "action", "delayed")
+
+	out.family("myredis_throttle_delay_seconds_total", "counter", "Time commands were held back by the rate limit.")
+	out.sample("myredis_throttle_delay_seconds_total", time.Duration(limiter.delay.Load()).Seconds())
+
+	out.family("myredis_throttled_accepts_total", "counter", "Connections whose accept was delayed by the accept rate limit.")
+	out.sample("myredis_throttled_accepts_total", float64(limiter.delayedAccepts.Load()))
+}
+
+func (m *Metrics) writeKeyspace(out *metricsWriter) {
+	if stats, ok := m.handler.storage.(interface{ KeyspaceStats() storage.KeyspaceStats }); ok {
+		keyspace := stats.KeyspaceStats()
+
+		out.family("myredis_keys", "gauge", "Keys in the keyspace, by value type.")
+		for _, valueType := range []storage.ValueType{storage.StringType, storage.HashType, storage.ListType, storage.SetType} {
+			out.sample("myredis_keys", float64(keyspace.ByType[valueType]), "type", valueType.String())
+		}
+
+		out.family("myredis_keys_with_expiry", "gauge", "Keys with a time to live.")
+		out.sample("myredis_keys_with_expiry", float64(keyspace.Expires))
+	}
+
+	out.family("myredis_expired_keys_total", "counter", "Keys removed because they expired.")
+	out.sample("myredis_expired_keys_total", float64(m.handler.executor.ExpiredKeys()))
+}
+
+func (m *Metrics) writePersistence(out *metricsWriter) {
+	persistence, ok := m.handler.storage.(interface {
+		PersistenceStats() (storage.PersistenceStats, bool)
+	})
+	if !ok {
+		return
+	}
+	stats, enabled := persistence.PersistenceStats()
+	if !enabled {
+		return
+	}
+
+	out.family("myredis_snapshot_saves_total", "counter", "Snapshots written successfully.")
+	out.sample("myredis_snapshot_saves_total", float64(stats.Saves))
+
+	out.family("myredis_snapshot_failures_total", "counter", "Snapshots that failed to be written.")
+	out.sample("myredis_snapshot_failures_total", float64(stats.Failures))
+
+	out.family("myredis_snapshot_last_duration_seconds", "gauge", "Duration of the last snapshot attempt.")
+	out.sample("myredis_snapshot_last_duration_seconds", stats.LastSaveDuration.Seconds())
+
+	out.family("myredis_snapshot_last_size_bytes", "gauge", "Size of the last snapshot written.")
+	out.sample("myredis_snapshot_last_size_bytes", float64(stats.LastSaveSize))
+
+	out.family("myredis_snapshot_last_success_timestamp_seconds", "gauge", "Unix time of the last successful snapshot.")
+	out.sample("myredis_snapshot_last_success_timestamp_seconds", float64(stats.LastSave.Unix()))
+
+	out.family("myredis_snapshot_changes_since_last_save", "gauge", "Modifications not yet included in a snapshot.")
+	out.sample("myredis_snapshot_changes_since_last_save", float64(stats.ChangesSinceSave))
+}
+
+func writeRuntime(out *metricsWriter) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	out.family("go_goroutines", "gauge", "Number of goroutines that currently exist.")
+	out.sample("go_goroutines", float64(runtime.NumGoroutine()))
+
+	out.family("go_memstats_alloc_bytes", "gauge", "Bytes of allocated heap objects.")
+	out.sample("go_memstats_alloc_bytes", float64(mem.HeapAlloc))
+
+	out.family("go_memstats_heap_inuse_bytes", "gauge", "Bytes in in-use heap spans.")
+	out.sample("go_memstats_heap_inuse_bytes", float64(mem.HeapInuse))
+
+	out.family("go_memstats_sys_bytes", "gauge", "Bytes of memory obtained from the OS.")
+	out.sample("go_memstats_sys_bytes", float64(mem.Sys))
+
+	out.family("go_memstats_mallocs_total", "counter", "Heap objects allocated.")
+	out.sample("go_memstats_mallocs_total", float64(mem.Mallocs))
+
+	out.family("go_memstats_frees_total", "counter", "Heap objects freed.")
+	out.sample("go_memstats_frees_total", float64(mem.Frees))
+
+	out.family("go_gc_cycles_total", "counter", "Completed GC cycles.")
+	out.sample("go_gc_cycles_total", float64(mem.NumGC))
+
+	out.family("go_gc_pause_seconds_total", "counter", "Total GC stop-the-world pause time.")
+	out.sample("go_gc_pause_seconds_total", float64(mem.PauseTotalNs)/1e9)
+}
+
+// commandLatencyBuckets returns the histogram bounds in seconds.
+func commandLatencyBuckets() []float64 {
+	bounds := make([]float64, len(command.LatencyBuckets))
+	for i, bound := range command.LatencyBuckets {
+		bounds[i] = bound.Seconds()
+	}
+	return bounds
+}
+
+// metricsWriter renders metrics in the Prometheus text format.
+type metricsWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *metricsWriter) family(name, kind, help string) {
+	w.buf.WriteString("# HELP " + name + " " + help + "\n")
+	w.buf.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// sample writes one sample; labels are given as name, value pairs.
+func (w *metricsWriter) sample(name string, value float64, labels ...string) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			w.buf.WriteString(labels[i] + `="` + labelEscaper.Replace(labels[i+1]) + `"`)
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(formatFloat(value))
+	w.buf.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
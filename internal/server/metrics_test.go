@@ -0,0 +1,76 @@
+package server
+
+import (
+	"io"
+	"ivanSaichkin/myredis/internal/command"
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	cfg.Persistence.DataDir = t.TempDir()
+	store := storage.NewMemoryStorageWithPersistence(&cfg.Persistence)
+	handler := NewHandler(store, cfg)
+
+	for _, args := range [][]string{
+		{"SET", "a", "1"},
+		{"SET", "b", "2", "EX", "60"},
+		{"HSET", "h", "f", "v"},
+		{"GET", "a"},
+		{"GET"},
+		{"SAVE"},
+	} {
+		handler.executor.Execute(nil, &command.Command{Name: args[0], Args: args[1:]})
+	}
+
+	srv := httptest.NewServer(NewMetrics(handler))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	metrics := string(body)
+
+	for _, line := range []string{
+		`myredis_commands_total{cmd="set"} 2`,
+		`myredis_commands_total{cmd="get"} 1`,
+		`myredis_command_duration_seconds_bucket{cmd="set",le="+Inf"} 2`,
+		`myredis_command_duration_seconds_count{cmd="hset"} 1`,
+		`myredis_keys{type="string"} 2`,
+		`myredis_keys{type="hash"} 1`,
+		`myredis_keys_with_expiry 1`,
+		`myredis_snapshot_saves_total 1`,
+		`myredis_snapshot_failures_total 0`,
+		"# TYPE myredis_command_duration_seconds histogram",
+		"# TYPE go_goroutines gauge",
+	} {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Errorf("Metrics do not contain %q", line)
+		}
+	}
+
+	// GET without a key is rejected before execution and not counted.
+	if strings.Contains(metrics, `myredis_command_errors_total{cmd="get"} 1`) {
+		t.Error("Rejected commands should not be counted as executed")
+	}
+
+	resp, err = http.Post(srv.URL, "text/plain", nil)
+	if err != nil {
+		t.Fatalf("POST /metrics failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected 405, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestMonitor(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	cfg.IdleTimeout = 200 * time.Millisecond
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), cfg)
+
+	monitor := dial(t, addr)
+	expectStatus(t, monitor.do(t, "MONITOR"), "OK")
+
+	conn := dial(t, addr)
+	conn.do(t, "SET", "key", "a b\n\"c\"\x00")
+	conn.do(t, "AUTH", "secret")
+	conn.do(t, "CLIENT", "LIST")
+	conn.do(t, "GET", "key")
+
+	// Monitors are not closed as idle while waiting for the feed.
+	time.Sleep(2 * cfg.IdleTimeout)
+
+	line := regexp.MustCompile(`^\d+\.\d{6} \[0 127\.0\.0\.1:\d+\] (.*)$`)
+	for _, expected := range []string{
+		`"set" "key" "a b\n\"c\"\x00"`,
+		`"get" "key"`,
+	} {
+		reply := monitor.read(t)
+		if reply.Type != protocol.SimpleString {
+			t.Fatalf("Expected a status reply, got %+v", reply)
+		}
+		match := line.FindStringSubmatch(reply.Str)
+		if match == nil || match[1] != expected {
+			t.Errorf("Expected a line ending in %s, got %q", expected, reply.Str)
+		}
+	}
+
+	// The monitor can still run commands and does not see its own.
+	if reply := monitor.do(t, "PING"); reply.Str != "PONG" {
+		t.Errorf("PING on monitor: unexpected %+v", reply)
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/storage"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForClients waits until CLIENT LIST on conn reports n clients.
+func waitForClients(t *testing.T, conn *testConn, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		list := conn.do(t, "CLIENT", "LIST").Bulk
+		if strings.Count(list, "\n") == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d clients, got %q", n, list)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestOutputBufferHardLimit(t *testing.T) {
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), config.DefaulteConfig())
+	conn := dial(t, addr)
+
+	expectStatus(t, conn.do(t, "CONFIG", "SET", "client-output-buffer-limit", "normal 1kb 0 0"), "OK")
+	values := configValues(t, conn.do(t, "CONFIG", "GET", "client-output-buffer-limit"))
+	if expected := "normal 1024 0 0 replica 268435456 67108864 60 pubsub 33554432 8388608 60"; values["client-output-buffer-limit"] != expected {
+		t.Errorf("Expected %q, got %q", expected, values["client-output-buffer-limit"])
+	}
+	expectError(t, conn.do(t, "CONFIG", "SET", "client-output-buffer-limit", "normal 1kb 0"), "ERR CONFIG SET failed - client-output-buffer-limit")
+
+	expectStatus(t, conn.do(t, "SET", "small", "value"), "OK")
+	expectStatus(t, conn.do(t, "SET", "big", strings.Repeat("x", 2000)), "OK")
+
+	client := dial(t, addr)
+	if reply := client.do(t, "GET", "small"); reply.Bulk != "value" {
+		t.Errorf("Expected a small reply to be written, got %+v", reply)
+	}
+	client.send(t, "GET", "big")
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if reply, err := client.reader.Read(); err == nil {
+		t.Fatalf("Expected the connection to be closed, got %+v", reply)
+	}
+
+	waitForClients(t, conn, 1)
+	if info := conn.do(t, "INFO", "stats").Bulk; !strings.Contains(info, "client_output_buffer_limit_disconnections:1\r\n") {
+		t.Errorf("Expected the disconnection to be counted, got %q", info)
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), config.DefaulteConfig())
+	conn := dial(t, addr)
+
+	expectStatus(t, conn.do(t, "CONFIG", "SET", "write-timeout", "100ms"), "OK")
+	expectStatus(t, conn.do(t, "SET", "big", strings.Repeat("x", 32<<20)), "OK")
+
+	// A client that never reads a reply larger than the socket buffers
+	// is disconnected once the write times out.
+	slow := dial(t, addr)
+	slow.Conn.(*net.TCPConn).SetReadBuffer(4096)
+	slow.send(t, "GET", "big")
+
+	waitForClients(t, conn, 1)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"testing"
+	"time"
+)
+
+func (c *testConn) read(t *testing.T) protocol.Value {
+	t.Helper()
+
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reply, err := c.reader.Read()
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	return reply
+}
+
+func expectMessage(t *testing.T, reply protocol.Value, expected ...string) {
+	t.Helper()
+
+	if reply.Type != protocol.Array || len(reply.Array) != len(expected) {
+		t.Fatalf("Expected %v, got %+v", expected, reply)
+	}
+	for i, item := range reply.Array {
+		if item.Bulk != expected[i] {
+			t.Errorf("Expected %v, got %+v", expected, reply)
+			return
+		}
+	}
+}
+
+func TestPubSub(t *testing.T) {
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), config.DefaulteConfig())
+
+	subscriber := dial(t, addr)
+	subscriber.send(t, "SUBSCRIBE", "news", "sports")
+	for i, channel := range []string{"news", "sports"} {
+		reply := subscriber.read(t)
+		if reply.Array[0].Bulk != "subscribe" || reply.Array[1].Bulk != channel || reply.Array[2].Num != i+1 {
+			t.Fatalf("Unexpected SUBSCRIBE confirmation %+v", reply)
+		}
+	}
+	subscriber.send(t, "PSUBSCRIBE", "news.*")
+	subscriber.read(t)
+
+	publisher := dial(t, addr)
+	if reply := publisher.do(t, "PUBLISH", "news", "hello"); reply.Num != 1 {
+		t.Errorf("PUBLISH: expected 1 receiver, got %+v", reply)
+	}
+	expectMessage(t, subscriber.read(t), "message", "news", "hello")
+
+	if reply := publisher.do(t, "PUBLISH", "news.tech", "go"); reply.Num != 1 {
+		t.Errorf("PUBLISH: expected 1 receiver, got %+v", reply)
+	}
+	expectMessage(t, subscriber.read(t), "pmessage", "news.*", "news.tech", "go")
+
+	numsub := publisher.do(t, "PUBSUB", "NUMSUB", "news", "weather")
+	if len(numsub.Array) != 4 || numsub.Array[1].Num != 1 || numsub.Array[3].Num != 0 {
+		t.Errorf("PUBSUB NUMSUB: unexpected %+v", numsub)
+	}
+	if reply := publisher.do(t, "PUBSUB", "NUMPAT"); reply.Num != 1 {
+		t.Errorf("PUBSUB NUMPAT: expected 1, got %+v", reply)
+	}
+	channels := publisher.do(t, "PUBSUB", "CHANNELS")
+	if len(channels.Array) != 2 {
+		t.Errorf("PUBSUB CHANNELS: unexpected %+v", channels)
+	}
+
+	// Only Pub/Sub commands and PING are accepted in subscriber mode.
+	subscriber.send(t, "GET", "key")
+	if reply := subscriber.read(t); reply.Type != protocol.Error {
+		t.Errorf("GET in subscriber mode should fail, got %+v", reply)
+	}
+	subscriber.send(t, "PING")
+	expectMessage(t, subscriber.read(t), "pong", "")
+
+	subscriber.send(t, "UNSUBSCRIBE")
+	subscriber.read(t)
+	subscriber.read(t)
+	subscriber.send(t, "PUNSUBSCRIBE")
+	if reply := subscriber.read(t); reply.Array[2].Num != 0 {
+		t.Errorf("Expected no subscriptions left, got %+v", reply)
+	}
+
+	// Back in normal mode replies keep flowing through the queue.
+	subscriber.send(t, "SET", "key", "value")
+	if reply := subscriber.read(t); reply.Str != "OK" {
+		t.Errorf("SET after unsubscribing: unexpected %+v", reply)
+	}
+	if reply := publisher.do(t, "PUBLISH", "news", "again"); reply.Num != 0 {
+		t.Errorf("PUBLISH after unsubscribing: expected 0 receivers, got %+v", reply)
+	}
+}
+
+func TestKeyspaceNotifications(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	cfg.NotifyKeyspaceEvents = "KEA"
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), cfg)
+
+	subscriber := dial(t, addr)
+	subscriber.send(t, "SUBSCRIBE", "__keyspace@0__:mykey", "__keyevent@0__:del")
+	subscriber.read(t)
+	subscriber.read(t)
+
+	client := dial(t, addr)
+	client.do(t, "SET", "mykey", "value")
+	client.do(t, "DEL", "mykey")
+
+	expectMessage(t, subscriber.read(t), "message", "__keyspace@0__:mykey", "set")
+	expectMessage(t, subscriber.read(t), "message", "__keyspace@0__:mykey", "del")
+	expectMessage(t, subscriber.read(t), "message", "__keyevent@0__:del", "mykey")
+}
+
+func TestKeyspaceExpiredNotification(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	cfg.NotifyKeyspaceEvents = "Ex"
+	store := storage.NewMemoryStorage()
+	_, addr, _ := startServer(t, store, cfg)
+
+	subscriber := dial(t, addr)
+	subscriber.send(t, "PSUBSCRIBE", "__keyevent@0__:*")
+	subscriber.read(t)
+
+	store.SetWithTTL("temp", "value", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	store.CleanupExpired()
+
+	expectMessage(t, subscriber.read(t), "pmessage", "__keyevent@0__:*", "__keyevent@0__:expired", "temp")
+}
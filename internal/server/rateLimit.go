@@ -0,0 +1,227 @@
+package server
+
+import (
+	"ivanSaichkin/myredis/internal/command"
+	"ivanSaichkin/myredis/internal/protocol"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tokenBucket refills at rate tokens per second up to one second's worth.
+// Tokens may be taken on credit, leaving the bucket in debt until it has
+// refilled.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, tokens: rate, last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	b.tokens = min(b.tokens, b.rate)
+	b.last = now
+}
+
+// take removes n tokens and returns how long it takes to pay back the
+// resulting debt, zero if there was enough.
+func (b *tokenBucket) take(n float64, now time.Time) time.Duration {
+	b.refill(now)
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// charge removes n tokens and returns how long it takes to pay back the
+// debt the bucket was in before, so that a single charge larger than the
+// bucket still goes through once.
+func (b *tokenBucket) charge(n float64, now time.Time) time.Duration {
+	b.refill(now)
+	wait := time.Duration(max(-b.tokens, 0) / b.rate * float64(time.Second))
+	b.tokens -= n
+	return wait
+}
+
+func (b *tokenBucket) full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.rate
+}
+
+// rateLimits configures a rateLimiter. Zero rates are unlimited.
+type rateLimits struct {
+	commands float64
+	bytes    float64
+	// scope is "client", "user" or "ip": the connections sharing a bucket.
+	scope string
+	// delay makes throttled commands wait instead of failing.
+	delay   bool
+	accepts float64
+}
+
+// clientBuckets are the buckets of one scope key.
+type clientBuckets struct {
+	commands *tokenBucket
+	bytes    *tokenBucket
+}
+
+// pruneThreshold is the number of buckets above which full buckets, which
+// behave like new ones, are dropped.
+const pruneThreshold = 1024
+
+// rateLimiter throttles commands and accepted connections.
+type rateLimiter struct {
+	mu      sync.Mutex
+	limits  rateLimits
+	buckets map[string]*clientBuckets
+	accept  *tokenBucket
+
+	rejectedCommands atomic.Int64
+	delayedCommands  atomic.Int64
+	delay            atomic.Int64
+	delayedAccepts   atomic.Int64
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*clientBuckets)}
+}
+
+// setLimits replaces the limits; buckets start over full.
+func (l *rateLimiter) setLimits(limits rateLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits = limits
+	clear(l.buckets)
+	l.accept = nil
+	if limits.accepts > 0 {
+		l.accept = newTokenBucket(limits.accepts, time.Now())
+	}
+}
+
+// bucketKey names the bucket shared by client under scope.
+func bucketKey(scope string, client *command.Client) string {
+	switch scope {
+	case "user":
+		return "user:" + client.User()
+	case "ip":
+		if host, _, err := net.SplitHostPort(client.Addr); err == nil {
+			return "ip:" + host
+		}
+		return "ip:" + client.Addr
+	}
+	return "client:" + strconv.FormatInt(client.ID, 10)
+}
+
+// throttle charges one command that arrived with n bytes to client. It
+// returns how long the command must wait before it runs, or reject when
+// it must not run at all.
+func (l *rateLimiter) throttle(client *command.Client, n int) (wait time.Duration, reject bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limits := l.limits
+	if limits.commands <= 0 && limits.bytes <= 0 {
+		return 0, false
+	}
+
+	now := time.Now()
+	k := bucketKey(limits.scope, client)
+	buckets, ok := l.buckets[k]
+	if !ok {
+		if len(l.buckets) >= pruneThreshold {
+			l.prune(now)
+		}
+		buckets = &clientBuckets{}
+		if limits.commands > 0 {
+			buckets.commands = newTokenBucket(limits.commands, now)
+		}
+		if limits.bytes > 0 {
+			buckets.bytes = newTokenBucket(limits.bytes, now)
+		}
+		l.buckets[k] = buckets
+	}
+
+	// The size of a command is only known once it has been read, so bytes
+	// are charged afterwards and throttle the commands that follow. They
+	// are charged even if the command is rejected.
+	if buckets.bytes != nil {
+		wait = buckets.bytes.charge(float64(n), now)
+	}
+	if buckets.commands != nil {
+		wait = max(wait, buckets.commands.take(1, now))
+	}
+	if wait == 0 {
+		return 0, false
+	}
+
+	if !limits.delay {
+		if buckets.commands != nil {
+			buckets.commands.tokens++
+		}
+		l.rejectedCommands.Add(1)
+		return 0, true
+	}
+	l.delayedCommands.Add(1)
+	l.delay.Add(int64(wait))
+	return wait, false
+}
+
+// prune drops the buckets that have refilled; mu must be held.
+func (l *rateLimiter) prune(now time.Time) {
+	for k, buckets := range l.buckets {
+		if (buckets.commands == nil || buckets.commands.full(now)) &&
+			(buckets.bytes == nil || buckets.bytes.full(now)) {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// acceptWait returns how long to wait before accepting another
+// connection.
+func (l *rateLimiter) acceptWait() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.accept == nil {
+		return 0
+	}
+	wait := l.accept.take(1, time.Now())
+	if wait > 0 {
+		l.delayedAccepts.Add(1)
+	}
+	return wait
+}
+
+func throttledReply() protocol.Value {
+	return protocol.Value{
+		Type: protocol.Error,
+		Str:  "THROTTLED rate limit exceeded, try again later",
+	}
+}
+
+// countingReader counts the bytes read from a connection.
+type countingReader struct {
+	conn net.Conn
+	n    int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.conn.Read(p)
+	r.n += n
+	return n, err
+}
+
+// consume returns the bytes read since the last call.
+func (r *countingReader) consume() int {
+	n := r.n
+	r.n = 0
+	return n
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(10, now)
+
+	if wait := b.take(10, now); wait != 0 {
+		t.Errorf("Expected a full bucket, got a wait of %v", wait)
+	}
+	if wait := b.take(5, now); wait != 500*time.Millisecond {
+		t.Errorf("Expected a wait of 500ms, got %v", wait)
+	}
+	if wait := b.take(0, now.Add(time.Second)); wait != 0 {
+		t.Errorf("Expected the debt to be paid back after 500ms, got %v", wait)
+	}
+	if wait := b.charge(25, now.Add(time.Second)); wait != 0 {
+		t.Errorf("Expected a charge larger than the bucket to go through, got a wait of %v", wait)
+	}
+	if wait := b.charge(1, now.Add(time.Second)); wait != 2*time.Second {
+		t.Errorf("Expected a wait of 2s, got %v", wait)
+	}
+	if !b.full(now.Add(5 * time.Second)) {
+		t.Error("Expected the bucket to refill")
+	}
+}
+
+// pipeline sends every command before reading the replies.
+func pipeline(t *testing.T, conn *testConn, commands ...[]string) []protocol.Value {
+	t.Helper()
+
+	for _, args := range commands {
+		conn.send(t, args...)
+	}
+	replies := make([]protocol.Value, len(commands))
+	for i := range commands {
+		replies[i] = conn.read(t)
+	}
+	return replies
+}
+
+func countThrottled(replies []protocol.Value) int {
+	n := 0
+	for _, reply := range replies {
+		if reply.Type == protocol.Error && strings.HasPrefix(reply.Str, "THROTTLED ") {
+			n++
+		}
+	}
+	return n
+}
+
+func repeat(n int, args ...string) [][]string {
+	commands := make([][]string, n)
+	for i := range commands {
+		commands[i] = args
+	}
+	return commands
+}
+
+func TestRateLimitReject(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	cfg.RateLimitCommands = 5
+	srv, addr, _ := startServer(t, storage.NewMemoryStorage(), cfg)
+	conn := dial(t, addr)
+
+	if n := countThrottled(pipeline(t, conn, repeat(10, "PING")...)); n < 4 || n > 5 {
+		t.Errorf("Expected about 5 of 10 commands to be throttled, got %d", n)
+	}
+	if n := srv.handler.limiter.rejectedCommands.Load(); n == 0 {
+		t.Error("Expected rejected commands to be counted")
+	}
+
+	// A throttled command aborts the transaction it was sent in.
+	time.Sleep(time.Second)
+	replies := pipeline(t, conn, append([][]string{{"MULTI"}}, repeat(5, "SET", "key", "value")...)...)
+	expectError(t, replies[len(replies)-1], "THROTTLED")
+	time.Sleep(time.Second)
+	expectError(t, conn.do(t, "EXEC"), "EXECABORT")
+
+	// Connections from other clients have their own buckets.
+	other := dial(t, addr)
+	expectStatus(t, other.do(t, "PING"), "PONG")
+}
+
+func TestRateLimitScopeAndBytes(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	cfg.RateLimitCommands = 3
+	cfg.RateLimitScope = "ip"
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), cfg)
+
+	first := dial(t, addr)
+	second := dial(t, addr)
+	pipeline(t, first, repeat(3, "PING")...)
+	expectError(t, second.do(t, "PING"), "THROTTLED")
+
+	// CONFIG SET applies new limits at once.
+	time.Sleep(time.Second)
+	expectStatus(t, first.do(t, "CONFIG", "SET", "ratelimit-commands", "0", "ratelimit-bytes", "100"), "OK")
+	expectStatus(t, first.do(t, "SET", "key", strings.Repeat("x", 200)), "OK")
+	expectError(t, first.do(t, "GET", "key"), "THROTTLED")
+}
+
+func TestRateLimitDelay(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	cfg.RateLimitCommands = 20
+	cfg.RateLimitMode = "delay"
+	srv, addr, _ := startServer(t, storage.NewMemoryStorage(), cfg)
+	conn := dial(t, addr)
+
+	start := time.Now()
+	if n := countThrottled(pipeline(t, conn, repeat(30, "PING")...)); n != 0 {
+		t.Errorf("Expected no command to be rejected, got %d", n)
+	}
+	// 10 commands over the burst at 20 per second.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("Expected the commands to be delayed, took %v", elapsed)
+	}
+	if n := srv.handler.limiter.delayedCommands.Load(); n < 9 {
+		t.Errorf("Expected delayed commands to be counted, got %d", n)
+	}
+}
+
+func TestMaxAcceptRate(t *testing.T) {
+	cfg := config.DefaulteConfig()
+	cfg.MaxAcceptRate = 5
+	srv, addr, _ := startServer(t, storage.NewMemoryStorage(), cfg)
+
+	start := time.Now()
+	for range 10 {
+		conn := dial(t, addr)
+		expectStatus(t, conn.do(t, "PING"), "PONG")
+	}
+	if elapsed := time.Since(start); elapsed < 800*time.Millisecond {
+		t.Errorf("Expected accepts to be delayed, took %v", elapsed)
+	}
+	if n := srv.handler.limiter.delayedAccepts.Load(); n == 0 {
+		t.Error("Expected delayed accepts to be counted")
+	}
+}
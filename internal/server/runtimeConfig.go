@@ -0,0 +1,247 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"ivanSaichkin/myredis/internal/command"
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/logging"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// runtimeConfig is the configuration read and changed by CONFIG. It
+// implements command.ConfigController.
+type runtimeConfig struct {
+	mu      sync.Mutex
+	cfg     *config.Config
+	handler *Handler
+}
+
+func newRuntimeConfig(handler *Handler, cfg *config.Config) *runtimeConfig {
+	return &runtimeConfig{
+		cfg:     cfg.Clone(),
+		handler: handler,
+	}
+}
+
+func (r *runtimeConfig) current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cfg.Clone()
+}
+
+func (r *runtimeConfig) Config() []command.ConfigParam {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []command.ConfigParam
+	for _, p := range config.Params() {
+		result = append(result, command.ConfigParam{
+			Name:  p.Name,
+			Value: p.Get(r.cfg),
+		})
+	}
+	return result
+}
+
+func (r *runtimeConfig) SetConfig(params []command.ConfigParam) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := r.cfg.Clone()
+	seen := make(map[string]bool)
+	for _, param := range params {
+		p, ok := config.LookupParam(param.Name)
+		if !ok {
+			return fmt.Errorf("unknown parameter '%s'", param.Name)
+		}
+		if !p.Mutable {
+			return fmt.Errorf("can't set immutable parameter '%s'", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("duplicate parameter '%s'", p.Name)
+		}
+		seen[p.Name] = true
+
+		if err := p.Set(next, param.Value); err != nil {
+			return err
+		}
+	}
+
+	if err := r.handler.applyConfig(r.cfg, next); err != nil {
+		r.handler.applyConfig(next, r.cfg)
+		return err
+	}
+	r.cfg = next
+	return nil
+}
+
+func (r *runtimeConfig) RewriteConfig() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cfg.ConfigFile == "" {
+		return errors.New("the server is running without a config file")
+	}
+	return r.cfg.Rewrite(r.cfg.ConfigFile)
+}
+
+// CheckConfig validates cfg as far as possible without starting the
+// server: TLS certificates and the ACL file are loaded and address lists
+// are parsed.
+func CheckConfig(cfg *config.Config) error {
+	errs := []error{cfg.Validate()}
+	if _, err := parseNetworks(cfg.AllowCIDRs); err != nil {
+		errs = append(errs, fmt.Errorf("allow-cidrs: %w", err))
+	}
+	if _, err := parseNetworks(cfg.DenyCIDRs); err != nil {
+		errs = append(errs, fmt.Errorf("deny-cidrs: %w", err))
+	}
+	if err := command.ValidateNotifyKeyspaceEvents(cfg.NotifyKeyspaceEvents); err != nil {
+		errs = append(errs, fmt.Errorf("notify-keyspace-events: %w", err))
+	}
+	if cfg.ACLFile != "" {
+		if err := command.NewACL(cfg.ACLFile).Load(); err != nil {
+			errs = append(errs, fmt.Errorf("aclfile: %w", err))
+		}
+	}
+	if cfg.AuditLogFile != "" {
+		if _, err := os.Stat(filepath.Dir(cfg.AuditLogFile)); err != nil {
+			errs = append(errs, fmt.Errorf("audit-log: %w", err))
+		}
+	}
+	// Missing certificate files are reported by Validate.
+	for _, lc := range cfg.AllListeners() {
+		if lc.TLS && cfg.TLS.CertFile != "" && cfg.TLS.KeyFile != "" {
+			if _, err := NewTLSManager(cfg.TLS); err != nil {
+				errs = append(errs, err)
+			}
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyConfig applies the runtime-changeable settings of next that differ
+// from prev; a nil prev applies all of them. Every setting is applied even
+// if an earlier one fails.
+func (h *Handler) applyConfig(prev, next *config.Config) error {
+	var errs []error
+	all := prev == nil
+	if all {
+		prev = next
+	}
+
+	if all || next.NotifyKeyspaceEvents != prev.NotifyKeyspaceEvents {
+		if err := h.executor.SetNotifyKeyspaceEvents(next.NotifyKeyspaceEvents); err != nil {
+			errs = append(errs, fmt.Errorf("notify-keyspace-events: %w", err))
+		}
+	}
+
+	if all || next.LogLevel != prev.LogLevel {
+		if level, err := logging.ParseLevel(next.LogLevel); err != nil {
+			errs = append(errs, fmt.Errorf("loglevel: %w", err))
+		} else {
+			logging.SetLevel(level)
+		}
+	}
+
+	if all || next.RequirePass != prev.RequirePass {
+		// An empty password is only applied when it is changed, so that
+		// a default user configured by the ACL file is left alone.
+		var err error
+		if next.RequirePass != "" {
+			err = h.acl.SetUser(command.DefaultUser, "resetpass", ">"+next.RequirePass)
+		} else if !all {
+			err = h.acl.SetUser(command.DefaultUser, "resetpass", "nopass")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("requirepass: %w", err))
+		}
+	}
+
+	if mode, err := command.ParseDebugCommandMode(next.EnableDebugCommand); err != nil {
+		errs = append(errs, fmt.Errorf("enable-debug-command: %w", err))
+	} else {
+		h.executor.SetDebugCommand(mode)
+	}
+
+	if redaction, err := command.ParseAuditRedaction(next.AuditLogRedact); err != nil {
+		errs = append(errs, fmt.Errorf("audit-log-redact: %w", err))
+	} else {
+		h.executor.AuditLog().SetRedaction(redaction)
+	}
+	// The audit log file is only set at startup, so that CONFIG SET cannot
+	// write or rename files elsewhere.
+	if all || next.AuditLogMaxSize != prev.AuditLogMaxSize || next.AuditLogMaxFiles != prev.AuditLogMaxFiles {
+		if err := h.openAuditLog(next); err != nil {
+			errs = append(errs, fmt.Errorf("audit-log: %w", err))
+		}
+	}
+
+	h.clients.SetMaxClients(next.MaxClients)
+	h.idleTimeout.Store(int64(next.IdleTimeout))
+	h.writeTimeout.Store(int64(next.WriteTimeout))
+	for name, limit := range next.OutputBufferLimits {
+		class, err := command.ParseClientClass(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("client-output-buffer-limit: %w", err))
+			continue
+		}
+		h.clients.SetOutputBufferLimit(class, command.OutputBufferLimit{
+			Hard:         limit.Hard,
+			Soft:         limit.Soft,
+			SoftDuration: limit.SoftDuration,
+		})
+	}
+	h.executor.SlowLog().SetThreshold(next.SlowLogSlowerThan)
+	if all || next.SlowLogMaxLen != prev.SlowLogMaxLen {
+		h.executor.SlowLog().SetMaxLen(next.SlowLogMaxLen)
+	}
+	h.executor.HotKeys().SetSampleRatio(next.HotKeysSampleRatio)
+
+	if all || next.RateLimitCommands != prev.RateLimitCommands || next.RateLimitBytes != prev.RateLimitBytes ||
+		next.RateLimitScope != prev.RateLimitScope || next.RateLimitMode != prev.RateLimitMode ||
+		next.MaxAcceptRate != prev.MaxAcceptRate {
+		h.limiter.setLimits(rateLimits{
+			commands: float64(next.RateLimitCommands),
+			bytes:    float64(next.RateLimitBytes),
+			scope:    next.RateLimitScope,
+			delay:    next.RateLimitMode == "delay",
+			accepts:  float64(next.MaxAcceptRate),
+		})
+	}
+
+	if s, ok := h.storage.(interface{ SetAutoSave(bool, time.Duration) }); ok {
+		s.SetAutoSave(next.Persistence.AutoSave, next.Persistence.SaveInterval)
+	}
+	if next.ExpirationCheckInterval > 0 && (all || next.ExpirationCheckInterval != prev.ExpirationCheckInterval) {
+		if s, ok := h.storage.(interface{ SetExpirationCheckInterval(time.Duration) }); ok {
+			s.SetExpirationCheckInterval(next.ExpirationCheckInterval)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// openAuditLog replaces the audit log's output with the file configured
+// in cfg, or disables the log if there is none. The current output is
+// kept if the file cannot be opened.
+func (h *Handler) openAuditLog(cfg *config.Config) error {
+	audit := h.executor.AuditLog()
+	if cfg.AuditLogFile == "" {
+		return audit.SetOutput(nil)
+	}
+
+	file, err := logging.OpenRotatingFile(cfg.AuditLogFile, cfg.AuditLogMaxSize, cfg.AuditLogMaxFiles)
+	if err != nil {
+		return err
+	}
+	if err := audit.SetOutput(file); err != nil {
+		h.logger.Warn("Failed to close audit log", "error", err)
+	}
+	h.logger.Info("Audit log opened", "path", cfg.AuditLogFile)
+	return nil
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"ivanSaichkin/myredis/internal/command"
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"net"
+	"testing"
+	"time"
+)
+
+type testConn struct {
+	net.Conn
+	reader *protocol.RESPReader
+	writer *protocol.RESPWriter
+}
+
+func dial(t *testing.T, addr string) *testConn {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testConn{
+		Conn:   conn,
+		reader: protocol.NewRESPReader(conn),
+		writer: protocol.NewRESPWriter(conn),
+	}
+}
+
+func (c *testConn) send(t *testing.T, args ...string) {
+	t.Helper()
+
+	array := make([]protocol.Value, len(args))
+	for i, arg := range args {
+		array[i] = protocol.Value{Type: protocol.BulkString, Bulk: arg}
+	}
+	if err := c.writer.Write(protocol.Value{Type: protocol.Array, Array: array}); err != nil {
+		t.Fatalf("Failed to write command: %v", err)
+	}
+	if err := c.writer.Flush(); err != nil {
+		t.Fatalf("Failed to flush command: %v", err)
+	}
+}
+
+func (c *testConn) do(t *testing.T, args ...string) protocol.Value {
+	t.Helper()
+
+	c.send(t, args...)
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reply, err := c.reader.Read()
+	if err != nil {
+		t.Fatalf("Failed to read reply to %v: %v", args, err)
+	}
+	return reply
+}
+
+// startServer serves store on a random loopback port and returns the server
+// and its address. Serve's result is delivered on the returned channel.
+func startServer(t *testing.T, store storage.Storage, cfg *config.Config) (*TCPServer, string, <-chan error) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	srv := NewTCPServer(listener.Addr().String(), NewHandler(store, cfg))
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		srv.Shutdown(context.Background())
+	})
+
+	return srv, listener.Addr().String(), served
+}
+
+func TestShutdownClosesIdleConnections(t *testing.T) {
+	srv, addr, served := startServer(t, storage.NewMemoryStorage(), config.DefaulteConfig())
+
+	conn := dial(t, addr)
+	if reply := conn.do(t, "SET", "key", "value"); reply.Str != "OK" {
+		t.Fatalf("SET: unexpected reply %+v", reply)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if err := <-served; err != ErrServerClosed {
+		t.Errorf("Expected ErrServerClosed from Serve, got %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.reader.Read(); err == nil {
+		t.Error("Expected the connection to be closed after shutdown")
+	}
+
+	if _, err := net.DialTimeout("tcp", addr, 200*time.Millisecond); err == nil {
+		t.Error("Expected new connections to be refused after shutdown")
+	}
+}
+
+func TestShutdownCommand(t *testing.T) {
+	srv, addr, _ := startServer(t, storage.NewMemoryStorage(), config.DefaulteConfig())
+
+	conn := dial(t, addr)
+
+	reply := conn.do(t, "SHUTDOWN", "ABORT")
+	if reply.Type != protocol.Error {
+		t.Errorf("SHUTDOWN ABORT without a pending shutdown should fail, got %+v", reply)
+	}
+
+	reply = conn.do(t, "SHUTDOWN", "NOSAVE")
+	if reply.Type != protocol.SimpleString || reply.Str != "OK" {
+		t.Fatalf("SHUTDOWN NOSAVE: unexpected reply %+v", reply)
+	}
+
+	select {
+	case <-srv.ShutdownRequested():
+	case <-time.After(time.Second):
+		t.Fatal("Expected ShutdownRequested to be closed")
+	}
+	if mode := srv.ShutdownMode(); mode != command.ShutdownNoSave {
+		t.Errorf("Expected ShutdownNoSave, got %v", mode)
+	}
+
+	// The connection that asked for the shutdown is closed after the reply.
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.reader.Read(); err == nil {
+		t.Error("Expected the connection to be closed after SHUTDOWN")
+	}
+}
+
+// blockingSaveStorage holds SaveSnapshot until release is closed.
+type blockingSaveStorage struct {
+	*storage.MemoryStorage
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSaveStorage) SaveSnapshot() error {
+	close(s.saving)
+	<-s.release
+	return nil
+}
+
+func TestShutdownAbort(t *testing.T) {
+	store := &blockingSaveStorage{
+		MemoryStorage: storage.NewMemoryStorage(),
+		saving:        make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	srv, addr, _ := startServer(t, store, config.DefaulteConfig())
+
+	requester := dial(t, addr)
+	requester.send(t, "SHUTDOWN")
+
+	select {
+	case <-store.saving:
+	case <-time.After(time.Second):
+		t.Fatal("Expected SHUTDOWN to start saving a snapshot")
+	}
+
+	other := dial(t, addr)
+	if reply := other.do(t, "SHUTDOWN", "ABORT"); reply.Str != "OK" {
+		t.Fatalf("SHUTDOWN ABORT: unexpected reply %+v", reply)
+	}
+	close(store.release)
+
+	requester.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reply, err := requester.reader.Read()
+	if err != nil {
+		t.Fatalf("Failed to read SHUTDOWN reply: %v", err)
+	}
+	if reply.Type != protocol.Error {
+		t.Errorf("Aborted SHUTDOWN should reply with an error, got %+v", reply)
+	}
+
+	select {
+	case <-srv.ShutdownRequested():
+		t.Error("Shutdown should not proceed after ABORT")
+	default:
+	}
+
+	if reply := other.do(t, "PING"); reply.Str != "PONG" {
+		t.Errorf("Server should keep serving after ABORT, got %+v", reply)
+	}
+}
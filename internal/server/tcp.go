@@ -1,53 +1,269 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"ivanSaichkin/myredis/internal/command"
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/logging"
+	"ivanSaichkin/myredis/internal/protocol"
+	"log/slog"
 	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrServerClosed         = errors.New("server closed")
+	ErrShutdownInProgress   = errors.New("shutdown already in progress")
+	ErrNoShutdownInProgress = errors.New("no shutdown in progress")
+	ErrShutdownAborted      = errors.New("shutdown aborted")
 )
 
 type TCPServer struct {
 	address string
 	handler *Handler
+	access  *AccessControl
+	logger  *slog.Logger
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	closed    bool
+	conns     sync.WaitGroup
+
+	shutdownMu        sync.Mutex
+	shutdownPending   bool
+	shutdownAborted   bool
+	shutdownMode      command.ShutdownMode
+	shutdownRequested chan struct{}
 }
 
 func NewTCPServer(addr string, handler *Handler) *TCPServer {
-	return &TCPServer{
-		address: addr,
-		handler: handler,
+	t := &TCPServer{
+		address:           addr,
+		handler:           handler,
+		logger:            slog.Default(),
+		listeners:         make(map[net.Listener]struct{}),
+		shutdownRequested: make(chan struct{}),
 	}
+	handler.executor.SetShutdownController(t)
+	return t
 }
 
+// SetLogger sets the logger for connection and shutdown events. It must be
+// called before the server starts.
+func (t *TCPServer) SetLogger(logger *slog.Logger) {
+	t.logger = logger
+}
+
+// SetAccessControl makes the server reject clients from addresses access
+// does not allow. Without it every client is accepted.
+func (t *TCPServer) SetAccessControl(access *AccessControl) {
+	t.access = access
+}
+
+// Start listens on the configured address and serves connections until
+// Shutdown is called, after which it returns ErrServerClosed.
 func (t *TCPServer) Start() error {
-	listener, err := net.Listen("tcp", t.address)
+	listener, err := Listen(config.ListenerConfig{Network: "tcp", Address: t.address}, nil)
 
 	if err != nil {
 		return fmt.Errorf("failed to start server: %v", err)
 	}
 
-	defer listener.Close()
+	t.logger.Info("Server started", "address", t.address)
+
+	return t.Serve(listener)
+}
+
+// Serve accepts connections on listener until Shutdown is called. The
+// listener is closed when Serve returns. Serve may be called concurrently
+// for several listeners sharing the same handler.
+func (t *TCPServer) Serve(listener net.Listener) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	t.listeners[listener] = struct{}{}
+	t.mu.Unlock()
 
-	log.Printf("Server started on %s", t.address)
+	defer func() {
+		t.mu.Lock()
+		delete(t.listeners, listener)
+		t.mu.Unlock()
+		listener.Close()
+	}()
 
 	for {
+		if wait := t.handler.limiter.acceptWait(); wait > 0 {
+			time.Sleep(wait)
+		}
 		conn, err := listener.Accept()
 		if err != nil {
-			log.Printf("Error accepting connection: %v", err)
+			if t.isClosed() {
+				return ErrServerClosed
+			}
+			t.logger.Warn("Error accepting connection", "error", err)
 			continue
 		}
 
+		// Registered under the lock so Shutdown never waits on a
+		// connection it cannot see.
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			conn.Close()
+			return ErrServerClosed
+		}
+		t.conns.Add(1)
+		t.mu.Unlock()
+
 		go t.handleConnection(conn)
 	}
 }
 
 func (t *TCPServer) handleConnection(conn net.Conn) {
+	defer t.conns.Done()
 	defer conn.Close()
 
-	log.Printf("Client connected: %s", conn.RemoteAddr())
+	// Checked here rather than in the accept loop so that writing the
+	// rejection never stalls accepting other clients.
+	if t.access != nil {
+		if err := t.access.Check(conn.RemoteAddr()); err != nil {
+			logging.Verbose(t.logger, "Rejected connection", "addr", conn.RemoteAddr().String(), "error", err)
+			t.reject(conn, err)
+			return
+		}
+	}
+
+	logging.Verbose(t.logger, "Client connected", "addr", conn.RemoteAddr().String())
 
 	if err := t.handler.HandleConnection(conn); err != nil {
-		log.Printf("Error handling connection from %s: %v", conn.RemoteAddr(), err)
+		logging.Verbose(t.logger, "Error handling connection", "addr", conn.RemoteAddr().String(), "error", err)
+	}
+
+	logging.Verbose(t.logger, "Client disconnected", "addr", conn.RemoteAddr().String())
+}
+
+// reject tells a refused client why before its connection is closed.
+func (t *TCPServer) reject(conn net.Conn, err error) {
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	writer := protocol.NewRESPWriter(conn)
+	writer.Write(protocol.Value{
+		Type: protocol.Error,
+		Str:  "DENIED " + err.Error(),
+	})
+	writer.Flush()
+}
+
+func (t *TCPServer) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+// Shutdown stops accepting connections on every listener and waits for
+// open connections to finish the command they are executing. Connections
+// still open when ctx expires are closed and ctx's error is returned. The
+// audit log is closed last.
+func (t *TCPServer) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	for listener := range t.listeners {
+		listener.Close()
+	}
+	t.mu.Unlock()
+
+	t.handler.beginShutdown()
+
+	done := make(chan struct{})
+	go func() {
+		t.conns.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.handler.closeConnections()
+		<-done
+		err = ctx.Err()
+	}
+
+	if closeErr := t.handler.executor.AuditLog().Close(); closeErr != nil {
+		t.logger.Warn("Failed to close audit log", "error", closeErr)
+	}
+	return err
+}
+
+// RequestShutdown is called by SHUTDOWN and on termination signals. Unless
+// mode is ShutdownNoSave it first saves a snapshot while still serving
+// clients, so the shutdown can be cancelled with AbortShutdown or is
+// abandoned when the save fails. On success ShutdownRequested is closed.
+func (t *TCPServer) RequestShutdown(mode command.ShutdownMode) error {
+	t.shutdownMu.Lock()
+	if t.shutdownPending {
+		t.shutdownMu.Unlock()
+		return ErrShutdownInProgress
+	}
+	select {
+	case <-t.shutdownRequested:
+		t.shutdownMu.Unlock()
+		return ErrShutdownInProgress
+	default:
+	}
+	t.shutdownPending = true
+	t.shutdownAborted = false
+	t.shutdownMu.Unlock()
+
+	var saveErr error
+	if mode != command.ShutdownNoSave {
+		saveErr = t.handler.storage.SaveSnapshot()
 	}
 
-	log.Printf("Client disconnected: %s", conn.RemoteAddr())
+	t.shutdownMu.Lock()
+	defer t.shutdownMu.Unlock()
+
+	t.shutdownPending = false
+	if t.shutdownAborted {
+		t.logger.Info("Shutdown aborted")
+		return ErrShutdownAborted
+	}
+	if saveErr != nil {
+		t.logger.Warn("Error saving snapshot before shutdown, shutdown aborted", "error", saveErr)
+		return saveErr
+	}
+
+	t.shutdownMode = mode
+	close(t.shutdownRequested)
+	return nil
+}
+
+// AbortShutdown cancels a shutdown that is still saving its snapshot.
+func (t *TCPServer) AbortShutdown() error {
+	t.shutdownMu.Lock()
+	defer t.shutdownMu.Unlock()
+
+	if !t.shutdownPending {
+		return ErrNoShutdownInProgress
+	}
+	t.shutdownAborted = true
+	return nil
+}
+
+// ShutdownRequested is closed once a shutdown has been requested and its
+// snapshot, if any, has been saved.
+func (t *TCPServer) ShutdownRequested() <-chan struct{} {
+	return t.shutdownRequested
+}
+
+// ShutdownMode returns the mode of the accepted shutdown request.
+func (t *TCPServer) ShutdownMode() command.ShutdownMode {
+	t.shutdownMu.Lock()
+	defer t.shutdownMu.Unlock()
+	return t.shutdownMode
 }
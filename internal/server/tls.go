@@ -0,0 +1,149 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"ivanSaichkin/myredis/internal/config"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TLSManager builds the TLS configuration for the TLS listener and swaps in
+// new certificates on Reload without dropping established connections.
+type TLSManager struct {
+	cfg config.TLSConfig
+
+	clientAuth   tls.ClientAuthType
+	minVersion   uint16
+	cipherSuites []uint16
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+}
+
+func NewTLSManager(cfg config.TLSConfig) (*TLSManager, error) {
+	m := &TLSManager{cfg: cfg}
+
+	var err error
+	if m.clientAuth, err = parseClientAuth(cfg.ClientAuth); err != nil {
+		return nil, err
+	}
+	if m.minVersion, err = parseTLSVersion(cfg.MinVersion); err != nil {
+		return nil, err
+	}
+	if m.cipherSuites, err = parseCipherSuites(cfg.CipherSuites); err != nil {
+		return nil, err
+	}
+	if m.clientAuth != tls.NoClientCert && cfg.CAFile == "" {
+		return nil, errors.New("tls: a CA file is required to verify client certificates")
+	}
+
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload re-reads the certificate, key and CA files. New connections use the
+// new files; on error the previous ones stay in effect.
+func (m *TLSManager) Reload() error {
+	if m.cfg.CertFile == "" || m.cfg.KeyFile == "" {
+		return errors.New("tls: certificate and key files are required")
+	}
+
+	certificate, err := tls.LoadX509KeyPair(m.cfg.CertFile, m.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tls: failed to load key pair: %v", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if m.cfg.CAFile != "" {
+		pem, err := os.ReadFile(m.cfg.CAFile)
+		if err != nil {
+			return fmt.Errorf("tls: failed to read CA file: %v", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in %s", m.cfg.CAFile)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.certificate = &certificate
+	m.clientCAs = clientCAs
+	return nil
+}
+
+// Config returns a TLS configuration that picks up reloaded certificates
+// for every new handshake.
+func (m *TLSManager) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: m.minVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return m.currentConfig(), nil
+		},
+	}
+}
+
+func (m *TLSManager) currentConfig() *tls.Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{*m.certificate},
+		ClientAuth:   m.clientAuth,
+		ClientCAs:    m.clientCAs,
+		MinVersion:   m.minVersion,
+		CipherSuites: m.cipherSuites,
+	}
+}
+
+func parseClientAuth(s string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(s) {
+	case "", "no":
+		return tls.NoClientCert, nil
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	case "yes":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("tls: invalid client auth %q, expected no, optional or yes", s)
+	}
+}
+
+func parseTLSVersion(s string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToUpper(s), "TLSV") {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("tls: unsupported minimum version %q, expected 1.2 or 1.3", s)
+	}
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	available := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		available[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := available[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("tls: unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
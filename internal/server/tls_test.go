@@ -0,0 +1,300 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "myredis test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse CA certificate: %v", err)
+	}
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue returns a PEM encoded certificate and key signed by the CA.
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// startTLSServer serves a fresh store over plaintext and TLS and returns
+// both addresses.
+func startTLSServer(t *testing.T, tlsManager *TLSManager) (string, string) {
+	t.Helper()
+
+	srv, plainAddr, _ := startServer(t, storage.NewMemoryStorage(), config.DefaulteConfig())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go srv.Serve(tls.NewListener(listener, tlsManager.Config()))
+
+	return plainAddr, listener.Addr().String()
+}
+
+func dialTLS(addr string, config *tls.Config) (*tls.Conn, error) {
+	conn, err := tls.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func pingOver(t *testing.T, conn net.Conn) {
+	t.Helper()
+
+	writer := protocol.NewRESPWriter(conn)
+	writer.Write(protocol.Value{Type: protocol.Array, Array: []protocol.Value{{Type: protocol.BulkString, Bulk: "PING"}}})
+	writer.Flush()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reply, err := protocol.NewRESPReader(conn).Read()
+	if err != nil {
+		t.Fatalf("Failed to read PING reply: %v", err)
+	}
+	if reply.Str != "PONG" {
+		t.Errorf("Expected PONG, got %+v", reply)
+	}
+}
+
+func TestTLSAndPlaintextListeners(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(dir, "server.crt"), certPEM)
+	writeFile(t, filepath.Join(dir, "server.key"), keyPEM)
+
+	tlsManager, err := NewTLSManager(config.TLSConfig{
+		CertFile:   filepath.Join(dir, "server.crt"),
+		KeyFile:    filepath.Join(dir, "server.key"),
+		MinVersion: "1.2",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TLS manager: %v", err)
+	}
+
+	plainAddr, tlsAddr := startTLSServer(t, tlsManager)
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+
+	conn, err := dialTLS(tlsAddr, &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	if err != nil {
+		t.Fatalf("TLS handshake failed: %v", err)
+	}
+	defer conn.Close()
+	pingOver(t, conn)
+
+	pingOver(t, dial(t, plainAddr))
+}
+
+func TestTLSClientAuthentication(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(dir, "server.crt"), certPEM)
+	writeFile(t, filepath.Join(dir, "server.key"), keyPEM)
+	writeFile(t, filepath.Join(dir, "ca.crt"), ca.pem)
+
+	tlsManager, err := NewTLSManager(config.TLSConfig{
+		CertFile:   filepath.Join(dir, "server.crt"),
+		KeyFile:    filepath.Join(dir, "server.key"),
+		CAFile:     filepath.Join(dir, "ca.crt"),
+		ClientAuth: "yes",
+		MinVersion: "1.3",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TLS manager: %v", err)
+	}
+
+	_, tlsAddr := startTLSServer(t, tlsManager)
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+
+	// Without a client certificate the server rejects the connection; with
+	// TLS 1.3 the failure surfaces on the first read.
+	conn, err := dialTLS(tlsAddr, &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	if err == nil {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	if err == nil {
+		t.Error("Expected a connection without a client certificate to be rejected")
+	}
+
+	clientCertPEM, clientKeyPEM := ca.issue(t, 3, x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	if err != nil {
+		t.Fatalf("Failed to load client certificate: %v", err)
+	}
+
+	conn, err = dialTLS(tlsAddr, &tls.Config{
+		RootCAs:      roots,
+		ServerName:   "localhost",
+		Certificates: []tls.Certificate{clientCert},
+	})
+	if err != nil {
+		t.Fatalf("TLS handshake with client certificate failed: %v", err)
+	}
+	defer conn.Close()
+	pingOver(t, conn)
+}
+
+func TestTLSCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(dir, "server.crt"), certPEM)
+	writeFile(t, filepath.Join(dir, "server.key"), keyPEM)
+
+	tlsManager, err := NewTLSManager(config.TLSConfig{
+		CertFile: filepath.Join(dir, "server.crt"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TLS manager: %v", err)
+	}
+
+	_, tlsAddr := startTLSServer(t, tlsManager)
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+
+	serial := func() int64 {
+		conn, err := dialTLS(tlsAddr, clientConfig)
+		if err != nil {
+			t.Fatalf("TLS handshake failed: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	if got := serial(); got != 10 {
+		t.Fatalf("Expected certificate serial 10, got %d", got)
+	}
+
+	certPEM, keyPEM = ca.issue(t, 11, x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(dir, "server.crt"), certPEM)
+	writeFile(t, filepath.Join(dir, "server.key"), keyPEM)
+
+	if err := tlsManager.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if got := serial(); got != 11 {
+		t.Errorf("Expected certificate serial 11 after reload, got %d", got)
+	}
+
+	// A broken key pair is rejected and the previous certificate stays.
+	writeFile(t, filepath.Join(dir, "server.key"), []byte("not a key"))
+	if err := tlsManager.Reload(); err == nil {
+		t.Error("Expected Reload to fail with an invalid key")
+	}
+	if got := serial(); got != 11 {
+		t.Errorf("Expected certificate serial 11 after failed reload, got %d", got)
+	}
+}
+
+func TestTLSManagerValidation(t *testing.T) {
+	tests := []config.TLSConfig{
+		{CertFile: "a", KeyFile: "b", ClientAuth: "maybe"},
+		{CertFile: "a", KeyFile: "b", MinVersion: "1.0"},
+		{CertFile: "a", KeyFile: "b", CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+		{CertFile: "a", KeyFile: "b", ClientAuth: "yes"},
+		{},
+	}
+
+	for _, cfg := range tests {
+		if _, err := NewTLSManager(cfg); err == nil {
+			t.Errorf("Expected NewTLSManager(%+v) to fail", cfg)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"ivanSaichkin/myredis/internal/command"
+	"ivanSaichkin/myredis/internal/protocol"
+)
+
+// transaction is the MULTI state of a connection.
+type transaction struct {
+	active bool
+	// aborted is set when a command failed to queue; EXEC then discards
+	// the transaction.
+	aborted bool
+	queued  []*command.Command
+}
+
+func (tx *transaction) reset() {
+	tx.active = false
+	tx.aborted = false
+	tx.queued = nil
+}
+
+// execute runs cmd for the connection, or queues it while a transaction
+// is open.
+func (h *Handler) execute(client *command.Client, tx *transaction, cmd *command.Command) protocol.Value {
+	switch cmd.Name {
+	case "MULTI", "EXEC", "DISCARD":
+		if reply, ok := h.executor.Admit(client, cmd); !ok {
+			if tx.active {
+				tx.aborted = true
+			}
+			return reply
+		}
+		return h.transactionCommand(client, tx, cmd)
+	}
+
+	if !tx.active {
+		return h.executor.Execute(client, cmd)
+	}
+
+	switch cmd.Name {
+	case "WATCH":
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR WATCH inside MULTI is not allowed",
+		}
+	case "SUBSCRIBE", "UNSUBSCRIBE", "PSUBSCRIBE", "PUNSUBSCRIBE", "MONITOR":
+		tx.aborted = true
+		return protocol.Value{
+			Type: protocol.Error,
+			Str:  "ERR Command not allowed inside a transaction",
+		}
+	}
+
+	if reply, ok := h.executor.Admit(client, cmd); !ok {
+		tx.aborted = true
+		return reply
+	}
+	tx.queued = append(tx.queued, cmd)
+	return protocol.Value{
+		Type: protocol.SimpleString,
+		Str:  "QUEUED",
+	}
+}
+
+func (h *Handler) transactionCommand(client *command.Client, tx *transaction, cmd *command.Command) protocol.Value {
+	switch cmd.Name {
+	case "MULTI":
+		if tx.active {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR MULTI calls can not be nested",
+			}
+		}
+		tx.active = true
+		return protocol.Value{
+			Type: protocol.SimpleString,
+			Str:  "OK",
+		}
+
+	case "EXEC":
+		if !tx.active {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR EXEC without MULTI",
+			}
+		}
+		queued, aborted := tx.queued, tx.aborted
+		tx.reset()
+
+		if aborted {
+			h.executor.Unwatch(client)
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "EXECABORT Transaction discarded because of previous errors.",
+			}
+		}
+		return h.executor.Exec(client, queued)
+
+	default: // DISCARD
+		if !tx.active {
+			return protocol.Value{
+				Type: protocol.Error,
+				Str:  "ERR DISCARD without MULTI",
+			}
+		}
+		tx.reset()
+		h.executor.Unwatch(client)
+		return protocol.Value{
+			Type: protocol.SimpleString,
+			Str:  "OK",
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/protocol"
+	"ivanSaichkin/myredis/internal/storage"
+	"strings"
+	"testing"
+)
+
+func expectStatus(t *testing.T, reply protocol.Value, expected string) {
+	t.Helper()
+	if reply.Type != protocol.SimpleString || reply.Str != expected {
+		t.Errorf("Expected %s, got %+v", expected, reply)
+	}
+}
+
+func expectError(t *testing.T, reply protocol.Value, prefix string) {
+	t.Helper()
+	if reply.Type != protocol.Error || !strings.HasPrefix(reply.Str, prefix) {
+		t.Errorf("Expected error starting with %q, got %+v", prefix, reply)
+	}
+}
+
+func TestMultiExec(t *testing.T) {
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), config.DefaulteConfig())
+	conn := dial(t, addr)
+
+	expectError(t, conn.do(t, "EXEC"), "ERR EXEC without MULTI")
+	expectError(t, conn.do(t, "DISCARD"), "ERR DISCARD without MULTI")
+
+	expectStatus(t, conn.do(t, "MULTI"), "OK")
+	expectError(t, conn.do(t, "MULTI"), "ERR MULTI calls can not be nested")
+	expectStatus(t, conn.do(t, "SET", "counter", "1"), "QUEUED")
+	expectStatus(t, conn.do(t, "RPUSH", "list", "a", "b"), "QUEUED")
+	expectStatus(t, conn.do(t, "GET", "counter"), "QUEUED")
+	expectError(t, conn.do(t, "WATCH", "counter"), "ERR WATCH inside MULTI is not allowed")
+
+	exec := conn.do(t, "EXEC")
+	if exec.Type != protocol.Array || len(exec.Array) != 3 {
+		t.Fatalf("EXEC: unexpected reply %+v", exec)
+	}
+	expectStatus(t, exec.Array[0], "OK")
+	if exec.Array[1].Num != 2 || exec.Array[2].Bulk != "1" {
+		t.Errorf("EXEC: unexpected replies %+v", exec.Array)
+	}
+
+	// DISCARD drops the queued commands.
+	expectStatus(t, conn.do(t, "MULTI"), "OK")
+	expectStatus(t, conn.do(t, "SET", "counter", "2"), "QUEUED")
+	expectStatus(t, conn.do(t, "DISCARD"), "OK")
+	if reply := conn.do(t, "GET", "counter"); reply.Bulk != "1" {
+		t.Errorf("DISCARD should drop queued commands, got %+v", reply)
+	}
+
+	// Errors while queueing abort the whole transaction.
+	expectStatus(t, conn.do(t, "MULTI"), "OK")
+	expectStatus(t, conn.do(t, "SET", "counter", "3"), "QUEUED")
+	expectError(t, conn.do(t, "GET"), "ERR wrong number of arguments")
+	expectError(t, conn.do(t, "NOSUCHCOMMAND"), "ERR unknown command")
+	expectError(t, conn.do(t, "EXEC"), "EXECABORT")
+	if reply := conn.do(t, "GET", "counter"); reply.Bulk != "1" {
+		t.Errorf("EXECABORT should not run queued commands, got %+v", reply)
+	}
+
+	// Errors while executing do not stop the other commands.
+	expectStatus(t, conn.do(t, "MULTI"), "OK")
+	expectStatus(t, conn.do(t, "LPUSH", "counter", "x"), "QUEUED")
+	expectStatus(t, conn.do(t, "SET", "counter", "4"), "QUEUED")
+	exec = conn.do(t, "EXEC")
+	if len(exec.Array) != 2 || exec.Array[0].Type != protocol.Error {
+		t.Errorf("EXEC: expected a WRONGTYPE error first, got %+v", exec)
+	}
+	if reply := conn.do(t, "GET", "counter"); reply.Bulk != "4" {
+		t.Errorf("Expected counter 4, got %+v", reply)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	_, addr, _ := startServer(t, storage.NewMemoryStorage(), config.DefaulteConfig())
+	conn := dial(t, addr)
+	other := dial(t, addr)
+
+	conn.do(t, "SET", "balance", "100")
+
+	// An unmodified watched key lets EXEC run.
+	expectStatus(t, conn.do(t, "WATCH", "balance"), "OK")
+	expectStatus(t, conn.do(t, "MULTI"), "OK")
+	expectStatus(t, conn.do(t, "SET", "balance", "90"), "QUEUED")
+	if exec := conn.do(t, "EXEC"); len(exec.Array) != 1 {
+		t.Fatalf("EXEC: unexpected reply %+v", exec)
+	}
+
+	// A modification by another client aborts EXEC with a null reply.
+	expectStatus(t, conn.do(t, "WATCH", "balance", "other"), "OK")
+	expectStatus(t, other.do(t, "SET", "balance", "50"), "OK")
+	expectStatus(t, conn.do(t, "MULTI"), "OK")
+	expectStatus(t, conn.do(t, "SET", "balance", "80"), "QUEUED")
+	if exec := conn.do(t, "EXEC"); exec.Type != protocol.Array || !exec.IsNull {
+		t.Fatalf("EXEC after a watched key changed: expected null array, got %+v", exec)
+	}
+	if reply := conn.do(t, "GET", "balance"); reply.Bulk != "50" {
+		t.Errorf("Expected balance 50, got %+v", reply)
+	}
+
+	// EXEC releases the watched keys.
+	expectStatus(t, other.do(t, "SET", "balance", "40"), "OK")
+	expectStatus(t, conn.do(t, "MULTI"), "OK")
+	expectStatus(t, conn.do(t, "SET", "balance", "30"), "QUEUED")
+	if exec := conn.do(t, "EXEC"); exec.IsNull {
+		t.Error("EXEC should not be affected by keys watched before the last EXEC")
+	}
+
+	// UNWATCH and FLUSHDB.
+	expectStatus(t, conn.do(t, "WATCH", "balance"), "OK")
+	expectStatus(t, conn.do(t, "UNWATCH"), "OK")
+	other.do(t, "DEL", "balance")
+	expectStatus(t, conn.do(t, "MULTI"), "OK")
+	if exec := conn.do(t, "EXEC"); exec.IsNull {
+		t.Error("EXEC should not be affected by unwatched keys")
+	}
+
+	expectStatus(t, conn.do(t, "WATCH", "missing"), "OK")
+	expectStatus(t, other.do(t, "FLUSHDB"), "OK")
+	expectStatus(t, conn.do(t, "MULTI"), "OK")
+	if exec := conn.do(t, "EXEC"); !exec.IsNull {
+		t.Errorf("FLUSHDB should abort transactions watching keys, got %+v", exec)
+	}
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+)
+
+// Digest is a SHA-1 based digest of a value or of the whole keyspace. Two
+// storages holding the same keys, types, values and TTL presence have the
+// same digest regardless of insertion order.
+type Digest [sha1.Size]byte
+
+func (d Digest) String() string {
+	return hex.EncodeToString(d[:])
+}
+
+// mix chains data into d, so the order of mixed data matters.
+func (d *Digest) mix(data string) {
+	h := sha1.New()
+	h.Write(d[:])
+	h.Write([]byte(data))
+	h.Sum(d[:0])
+}
+
+// xor combines the hash of data into d, so the order does not matter.
+func (d *Digest) xor(data string) {
+	sum := sha1.Sum([]byte(data))
+	for i := range d {
+		d[i] ^= sum[i]
+	}
+}
+
+// valueDigest digests the type, contents and TTL presence of value. List
+// elements are mixed in order, hash entries and set members are not. It
+// takes the value's own lock, so the caller need not hold the storage lock.
+func valueDigest(value *StorageValue, hasTTL bool) Digest {
+	var d Digest
+	d.mix(value.Type.String())
+
+	switch data := value.Data.(type) {
+	case string:
+		d.mix(data)
+	case *ListData:
+		data.mu.RLock()
+		for _, element := range data.elements {
+			d.mix(element)
+		}
+		data.mu.RUnlock()
+	case *HashData:
+		var fields Digest
+		data.mu.RLock()
+		for field, v := range data.fields {
+			var entry Digest
+			entry.mix(field)
+			entry.mix(v)
+			fields.xor(string(entry[:]))
+		}
+		data.mu.RUnlock()
+		d.mix(string(fields[:]))
+	case *SetData:
+		var members Digest
+		data.mu.RLock()
+		for member := range data.members {
+			members.xor(member)
+		}
+		data.mu.RUnlock()
+		d.mix(string(members[:]))
+	}
+
+	if hasTTL {
+		d.mix("!!expire!!")
+	}
+	return d
+}
+
+// digestEntry is a key captured for Digest along with its TTL presence,
+// which is guarded by the storage lock.
+type digestEntry struct {
+	key    string
+	value  *StorageValue
+	hasTTL bool
+}
+
+// Digest returns the digest of every key that has not expired, all zeros
+// for an empty keyspace. The storage lock is only held while the keys are
+// collected, and values are hashed under their own locks, so writes to
+// other keys proceed meanwhile and a busy keyspace may be digested as it
+// changes.
+func (s *MemoryStorage) Digest() Digest {
+	s.mu.RLock()
+	entries := make([]digestEntry, 0, len(s.data))
+	for key, value := range s.data {
+		if value.IsExpired() {
+			continue
+		}
+		entries = append(entries, digestEntry{key, value, !value.ExpiredAt.IsZero()})
+	}
+	s.mu.RUnlock()
+
+	var d Digest
+	for _, entry := range entries {
+		contents := valueDigest(entry.value, entry.hasTTL)
+		var keyDigest Digest
+		keyDigest.mix(entry.key)
+		keyDigest.mix(string(contents[:]))
+		d.xor(string(keyDigest[:]))
+	}
+	return d
+}
+
+// ValueDigest returns the digest of the value stored at key, or false if
+// there is none.
+func (s *MemoryStorage) ValueDigest(key string) (Digest, bool) {
+	s.mu.RLock()
+	value, exists := s.data[key]
+	if !exists || value.IsExpired() {
+		s.mu.RUnlock()
+		return Digest{}, false
+	}
+	hasTTL := !value.ExpiredAt.IsZero()
+	s.mu.RUnlock()
+
+	return valueDigest(value, hasTTL), true
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDigest(t *testing.T) {
+	first := NewMemoryStorage()
+	if first.Digest() != (Digest{}) {
+		t.Errorf("Expected an empty keyspace to digest to zeros, got %s", first.Digest())
+	}
+	first.Set("s", "hello")
+	first.HSet("h", "f1", "v1")
+	first.HSet("h", "f2", "v2")
+	first.RPush("l", "a", "bc")
+	first.SAdd("set", "x", "y", "z")
+
+	// The same data written in another order.
+	second := NewMemoryStorage()
+	second.SAdd("set", "z", "x", "y")
+	second.RPush("l", "a", "bc")
+	second.HSet("h", "f2", "v2")
+	second.HSet("h", "f1", "v1")
+	second.Set("s", "hello")
+	if first.Digest() != second.Digest() {
+		t.Fatalf("Expected equal digests, got %s and %s", first.Digest(), second.Digest())
+	}
+	for _, key := range []string{"s", "h", "l", "set"} {
+		a, _ := first.ValueDigest(key)
+		b, _ := second.ValueDigest(key)
+		if a != b {
+			t.Errorf("Expected equal value digests for %s", key)
+		}
+	}
+
+	changes := map[string]func(s *MemoryStorage){
+		"string value": func(s *MemoryStorage) { s.Set("s", "hellO") },
+		"list order":   func(s *MemoryStorage) { s.Delete("l"); s.RPush("l", "bc", "a") },
+		"list split":   func(s *MemoryStorage) { s.Delete("l"); s.RPush("l", "ab", "c") },
+		"hash value":   func(s *MemoryStorage) { s.HSet("h", "f1", "v2") },
+		"set member":   func(s *MemoryStorage) { s.SRem("set", "z") },
+		"type":         func(s *MemoryStorage) { s.Delete("s"); s.RPush("s", "hello") },
+		"ttl":          func(s *MemoryStorage) { s.Expire("s", time.Hour) },
+		"key name":     func(s *MemoryStorage) { s.Delete("s"); s.Set("t", "hello") },
+	}
+	for name, change := range changes {
+		changed := NewMemoryStorage()
+		changed.Set("s", "hello")
+		changed.HSet("h", "f1", "v1")
+		changed.HSet("h", "f2", "v2")
+		changed.RPush("l", "a", "bc")
+		changed.SAdd("set", "x", "y", "z")
+		change(changed)
+		if changed.Digest() == first.Digest() {
+			t.Errorf("%s: expected the digest to change", name)
+		}
+	}
+
+	// Expired keys that have not been removed yet are left out.
+	second.SetWithTTL("gone", "v", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if first.Digest() != second.Digest() {
+		t.Error("Expected expired keys to be left out of the digest")
+	}
+	if _, ok := second.ValueDigest("gone"); ok {
+		t.Error("Expected no value digest for an expired key")
+	}
+}
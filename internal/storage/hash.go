@@ -24,6 +24,7 @@ func (s *MemoryStorage) HSet(key, field, value string) (bool, error) {
 	hashData := storageValue.Data.(*HashData)
 	exists = hashData.Exists(field)
 	hashData.Set(field, value)
+	s.changes.Add(1)
 
 	return !exists, nil
 }
@@ -72,7 +73,11 @@ func (s *MemoryStorage) HDel(key, field string) (bool, error) {
 	}
 
 	hashData := value.Data.(*HashData)
-	return hashData.Delete(field), nil
+	if !hashData.Delete(field) {
+		return false, nil
+	}
+	s.changes.Add(1)
+	return true, nil
 }
 
 func (s *MemoryStorage) HExists(key, field string) (bool, error) {
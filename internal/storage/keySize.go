@@ -0,0 +1,57 @@
+package storage
+
+// Rough per-allocation overheads used to estimate the memory of a key.
+const (
+	keyOverhead     = 72 // map entry, StorageValue and key string header
+	elementOverhead = 16 // string header of a list element or set member
+	entryOverhead   = 32 // field and value string headers of a hash entry
+)
+
+// KeySize describes the size of the value stored at a key.
+type KeySize struct {
+	Type ValueType
+	// Elements is the number of elements of a hash, list or set, or the
+	// length of a string in bytes.
+	Elements int
+	// Memory estimates the bytes used by the key and its value.
+	Memory int64
+}
+
+// KeySize returns the size of the value stored at key, or false if there
+// is none. Only the key's own value is locked while it is measured, so
+// measuring a large value does not hold up other keys.
+func (s *MemoryStorage) KeySize(key string) (KeySize, bool) {
+	value, err := s.Get(key)
+	if err != nil {
+		return KeySize{}, false
+	}
+
+	size := KeySize{Type: value.Type, Memory: keyOverhead + int64(len(key))}
+	switch data := value.Data.(type) {
+	case string:
+		size.Elements = len(data)
+		size.Memory += int64(len(data))
+	case *HashData:
+		data.mu.RLock()
+		size.Elements = len(data.fields)
+		for field, v := range data.fields {
+			size.Memory += entryOverhead + int64(len(field)+len(v))
+		}
+		data.mu.RUnlock()
+	case *ListData:
+		data.mu.RLock()
+		size.Elements = len(data.elements)
+		for _, element := range data.elements {
+			size.Memory += elementOverhead + int64(len(element))
+		}
+		data.mu.RUnlock()
+	case *SetData:
+		data.mu.RLock()
+		size.Elements = len(data.members)
+		for member := range data.members {
+			size.Memory += elementOverhead + int64(len(member))
+		}
+		data.mu.RUnlock()
+	}
+	return size, true
+}
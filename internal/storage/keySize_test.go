@@ -0,0 +1,32 @@
+package storage
+
+import "testing"
+
+func TestKeySize(t *testing.T) {
+	store := NewMemoryStorage()
+	store.Set("s", "hello")
+	store.HSet("h", "f1", "v1")
+	store.HSet("h", "f2", "value2")
+	store.RPush("l", "a", "bc")
+	store.SAdd("set", "x")
+
+	tests := []struct {
+		key      string
+		expected KeySize
+	}{
+		{"s", KeySize{Type: StringType, Elements: 5, Memory: keyOverhead + 1 + 5}},
+		{"h", KeySize{Type: HashType, Elements: 2, Memory: keyOverhead + 1 + 2*entryOverhead + 4 + 8}},
+		{"l", KeySize{Type: ListType, Elements: 2, Memory: keyOverhead + 1 + 2*elementOverhead + 3}},
+		{"set", KeySize{Type: SetType, Elements: 1, Memory: keyOverhead + 3 + elementOverhead + 1}},
+	}
+	for _, tt := range tests {
+		size, ok := store.KeySize(tt.key)
+		if !ok || size != tt.expected {
+			t.Errorf("KeySize(%q): expected %+v, got %+v", tt.key, tt.expected, size)
+		}
+	}
+
+	if _, ok := store.KeySize("missing"); ok {
+		t.Error("Expected no size for a missing key")
+	}
+}
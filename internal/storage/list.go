@@ -25,6 +25,7 @@ func (s *MemoryStorage) LPush(key string, values ...string) (int, error) {
 	for _, value := range values {
 		listData.PushLeft(value)
 	}
+	s.changes.Add(int64(len(values)))
 
 	return listData.Len(), nil
 }
@@ -52,6 +53,7 @@ func (s *MemoryStorage) RPush(key string, values ...string) (int, error) {
 	for _, value := range values {
 		listData.PushRight(value)
 	}
+	s.changes.Add(int64(len(values)))
 
 	return listData.Len(), nil
 }
@@ -76,6 +78,7 @@ func (s *MemoryStorage) LPop(key string) (string, error) {
 
 	listData := value.Data.(*ListData)
 	if element, ok := listData.PopLeft(); ok {
+		s.changes.Add(1)
 		return element, nil
 	}
 
@@ -102,6 +105,7 @@ func (s *MemoryStorage) RPop(key string) (string, error) {
 
 	listData := value.Data.(*ListData)
 	if element, ok := listData.PopRight(); ok {
+		s.changes.Add(1)
 		return element, nil
 	}
 
@@ -3,8 +3,10 @@ package storage
 import (
 	"fmt"
 	"ivanSaichkin/myredis/internal/config"
+	"log/slog"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,18 +14,28 @@ type MemoryStorage struct {
 	mu          sync.RWMutex
 	data        map[string]*StorageValue
 	persistence *PersistenceManager
+	logger      *slog.Logger
+
+	// onExpire is called for every key removed by CleanupExpired.
+	onExpire func(key string)
+	// expirationTicker drives StartExpirationChecker, which skips its
+	// cycles while noActiveExpire is set.
+	expirationTicker *time.Ticker
+	noActiveExpire   atomic.Bool
+
+	// changes counts modifications for rdb_changes_since_last_save.
+	changes atomic.Int64
 }
 
 func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
-		data: make(map[string]*StorageValue),
+		data:   make(map[string]*StorageValue),
+		logger: slog.Default(),
 	}
 }
 
 func NewMemoryStorageWithPersistence(config *config.PersistenceConfig) *MemoryStorage {
-	storage := &MemoryStorage{
-		data: make(map[string]*StorageValue),
-	}
+	storage := NewMemoryStorage()
 
 	if config != nil && config.Enabled {
 		storage.persistence = NewPersistenceManager(config, storage)
@@ -32,6 +44,16 @@ func NewMemoryStorageWithPersistence(config *config.PersistenceConfig) *MemorySt
 	return storage
 }
 
+// SetLogger sets the logger used by the storage and its persistence.
+func (s *MemoryStorage) SetLogger(logger *slog.Logger) {
+	s.mu.Lock()
+	s.logger = logger
+	s.mu.Unlock()
+	if s.persistence != nil {
+		s.persistence.SetLogger(logger)
+	}
+}
+
 func (s *MemoryStorage) StartPersistence() error {
 	if s.persistence != nil {
 		return s.persistence.Start()
@@ -46,6 +68,13 @@ func (s *MemoryStorage) StopPersistence() error {
 	return nil
 }
 
+func (s *MemoryStorage) StopPersistenceNoSave() error {
+	if s.persistence != nil {
+		return s.persistence.StopNoSave()
+	}
+	return nil
+}
+
 func (s *MemoryStorage) SaveSnapshot() error {
 	if s.persistence != nil {
 		return s.persistence.Save()
@@ -53,6 +82,26 @@ func (s *MemoryStorage) SaveSnapshot() error {
 	return nil
 }
 
+// Reload saves a snapshot and replaces the dataset with the snapshot read
+// back from disk.
+func (s *MemoryStorage) Reload() error {
+	if s.persistence == nil {
+		return fmt.Errorf("persistence not enabled")
+	}
+	if err := s.persistence.Save(); err != nil {
+		return err
+	}
+	return s.persistence.Load()
+}
+
+// SetAutoSave changes the auto-save settings; it has no effect when
+// persistence is disabled.
+func (s *MemoryStorage) SetAutoSave(enabled bool, interval time.Duration) {
+	if s.persistence != nil {
+		s.persistence.SetAutoSave(enabled, interval)
+	}
+}
+
 func (s *MemoryStorage) Get(key string) (*StorageValue, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -97,6 +146,7 @@ func (s *MemoryStorage) setInternal(key string, value interface{}, ttl time.Dura
 	}
 
 	s.data[key] = storageValue
+	s.changes.Add(1)
 	return nil
 }
 
@@ -110,6 +160,7 @@ func (s *MemoryStorage) Delete(key string) bool {
 func (s *MemoryStorage) deleteKey(key string) bool {
 	if _, exists := s.data[key]; exists {
 		delete(s.data, key)
+		s.changes.Add(1)
 		return true
 	}
 	return false
@@ -180,6 +231,7 @@ func (s *MemoryStorage) Clear() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.changes.Add(int64(len(s.data)))
 	s.data = make(map[string]*StorageValue)
 }
 
@@ -195,6 +247,7 @@ func (s *MemoryStorage) Expire(key string, ttl time.Duration) bool {
 	}
 
 	value.ExpiredAt = time.Now().Add(ttl)
+	s.changes.Add(1)
 	return true
 }
 
@@ -222,23 +275,108 @@ func (s *MemoryStorage) TTL(key string) (time.Duration, error) {
 
 func (s *MemoryStorage) StartExpirationChecker(interval time.Duration) {
 	ticker := time.NewTicker(interval)
+	s.mu.Lock()
+	s.expirationTicker = ticker
+	s.mu.Unlock()
+
 	go func() {
 		for range ticker.C {
-			s.CleanupExpired()
+			if !s.noActiveExpire.Load() {
+				s.CleanupExpired()
+			}
 		}
 	}()
 }
 
-func (s *MemoryStorage) CleanupExpired() {
+// SetExpirationCheckInterval changes the interval of a running expiration
+// checker.
+func (s *MemoryStorage) SetExpirationCheckInterval(interval time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.expirationTicker != nil {
+		s.expirationTicker.Reset(interval)
+	}
+}
+
+// SetActiveExpire pauses or resumes the expiration checker. Expired keys
+// are still reported missing when accessed while it is paused.
+func (s *MemoryStorage) SetActiveExpire(enabled bool) {
+	s.noActiveExpire.Store(!enabled)
+}
+
+// OnExpire registers fn to be called with every key removed because it
+// expired. fn is called without holding the storage lock.
+func (s *MemoryStorage) OnExpire(fn func(key string)) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.onExpire = fn
+}
+
+func (s *MemoryStorage) CleanupExpired() {
+	s.mu.Lock()
 
+	var expired []string
 	now := time.Now()
 	for key, value := range s.data {
 		if !value.ExpiredAt.IsZero() && now.After(value.ExpiredAt) {
 			delete(s.data, key)
+			expired = append(expired, key)
+		}
+	}
+	onExpire := s.onExpire
+	logger := s.logger
+	s.changes.Add(int64(len(expired)))
+	s.mu.Unlock()
+
+	if len(expired) > 0 {
+		logger.Debug("Removed expired keys", "keys", len(expired))
+	}
+
+	if onExpire != nil {
+		for _, key := range expired {
+			onExpire(key)
+		}
+	}
+}
+
+// Changes returns the number of modifications made since the storage was
+// created.
+func (s *MemoryStorage) Changes() int64 {
+	return s.changes.Load()
+}
+
+// KeyspaceStats summarizes the keys held by a storage.
+type KeyspaceStats struct {
+	Keys    int
+	Expires int
+	ByType  map[ValueType]int
+}
+
+func (s *MemoryStorage) KeyspaceStats() KeyspaceStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := KeyspaceStats{ByType: make(map[ValueType]int)}
+	for _, value := range s.data {
+		if value.IsExpired() {
+			continue
+		}
+		stats.Keys++
+		stats.ByType[value.Type]++
+		if !value.ExpiredAt.IsZero() {
+			stats.Expires++
 		}
 	}
+	return stats
+}
+
+// PersistenceStats returns the snapshot statistics, or false when
+// persistence is disabled.
+func (s *MemoryStorage) PersistenceStats() (PersistenceStats, bool) {
+	if s.persistence == nil {
+		return PersistenceStats{}, false
+	}
+	return s.persistence.Stats(), true
 }
 
 // Helper functions
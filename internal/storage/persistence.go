@@ -1,10 +1,14 @@
 package storage
 
 import (
+	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
 	"ivanSaichkin/myredis/internal/config"
+	"ivanSaichkin/myredis/internal/logging"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
@@ -12,21 +16,63 @@ import (
 )
 
 type PersistenceManager struct {
-	config   *config.PersistenceConfig
-	storage  Storage
-	mu       sync.RWMutex
-	running  bool
-	stopChan chan struct{}
+	config  *config.PersistenceConfig
+	storage Storage
+	logger  *slog.Logger
+	mu      sync.RWMutex
+
+	// autoSaveMu guards running and the auto-save settings, which CONFIG
+	// SET may change while the manager runs.
+	autoSaveMu   sync.Mutex
+	running      bool
+	autoSave     bool
+	saveInterval time.Duration
+	stopAutoSave chan struct{}
+
+	// statsMu guards the snapshot statistics, which are read while a save
+	// holds mu.
+	statsMu sync.Mutex
+	stats   PersistenceStats
+	// savedChanges is the storage change count covered by the last
+	// successful snapshot.
+	savedChanges int64
+}
+
+// PersistenceStats describes the snapshots written by a
+// PersistenceManager.
+type PersistenceStats struct {
+	// LastSave is the time of the last successful save, or the time the
+	// manager was created if nothing was saved yet.
+	LastSave         time.Time
+	LastSaveOK       bool
+	LastSaveDuration time.Duration
+	LastSaveSize     int64
+	Saves            int64
+	Failures         int64
+	ChangesSinceSave int64
+	InProgress       bool
 }
 
 func NewPersistenceManager(config *config.PersistenceConfig, store Storage) *PersistenceManager {
 	return &PersistenceManager{
-		config:   config,
-		storage:  store,
-		stopChan: make(chan struct{}),
+		config:       config,
+		storage:      store,
+		logger:       slog.Default(),
+		autoSave:     config.AutoSave,
+		saveInterval: config.SaveInterval,
+		stats: PersistenceStats{
+			LastSave:   time.Now(),
+			LastSaveOK: true,
+		},
 	}
 }
 
+// SetLogger sets the logger used for snapshots. It must be called before
+// Start.
+func (p *PersistenceManager) SetLogger(logger *slog.Logger) {
+	p.logger = logger
+}
+
 func (p *PersistenceManager) Start() error {
 	if !p.config.Enabled {
 		return nil
@@ -36,26 +82,62 @@ func (p *PersistenceManager) Start() error {
 		return fmt.Errorf("failed to load data: %v", err)
 	}
 
-	if p.config.AutoSave {
-		go p.startAutoSave()
-	}
-
+	p.autoSaveMu.Lock()
+	defer p.autoSaveMu.Unlock()
 	p.running = true
+	if p.autoSave {
+		p.startAutoSave()
+	}
 	return nil
 }
 
+// SetAutoSave changes the auto-save settings of a running manager, taking
+// effect immediately.
+func (p *PersistenceManager) SetAutoSave(enabled bool, interval time.Duration) {
+	p.autoSaveMu.Lock()
+	defer p.autoSaveMu.Unlock()
+
+	if enabled == p.autoSave && interval == p.saveInterval {
+		return
+	}
+	p.autoSave = enabled
+	p.saveInterval = interval
+	if !p.running {
+		return
+	}
+
+	p.stopAutoSaveLocked()
+	if enabled {
+		p.startAutoSave()
+	}
+}
+
+// Stop stops auto-saving and writes a final snapshot.
 func (p *PersistenceManager) Stop() error {
+	return p.stop(true)
+}
+
+// StopNoSave stops auto-saving without writing a final snapshot.
+func (p *PersistenceManager) StopNoSave() error {
+	return p.stop(false)
+}
+
+func (p *PersistenceManager) stop(save bool) error {
+	p.autoSaveMu.Lock()
 	if !p.config.Enabled || !p.running {
+		p.autoSaveMu.Unlock()
 		return nil
 	}
+	p.stopAutoSaveLocked()
+	p.running = false
+	p.autoSaveMu.Unlock()
 
-	close(p.stopChan)
-
-	if err := p.Save(); err != nil {
-		return fmt.Errorf("failed to save final snapshot: %v", err)
+	if save {
+		if err := p.Save(); err != nil {
+			return fmt.Errorf("failed to save final snapshot: %v", err)
+		}
 	}
 
-	p.running = false
 	return nil
 }
 
@@ -71,19 +153,46 @@ func (p *PersistenceManager) Save() error {
 		return fmt.Errorf("storage is not initialized")
 	}
 
+	p.statsMu.Lock()
+	p.stats.InProgress = true
+	p.statsMu.Unlock()
+
+	changes := p.storageChanges()
+	start := time.Now()
+	size, err := p.writeSnapshot()
+
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+	p.stats.InProgress = false
+	p.stats.LastSaveOK = err == nil
+	p.stats.LastSaveDuration = time.Since(start)
+	if err != nil {
+		p.stats.Failures++
+		return err
+	}
+	p.stats.LastSave = start
+	p.stats.LastSaveSize = size
+	p.stats.Saves++
+	p.savedChanges = changes
+	return nil
+}
+
+// writeSnapshot writes the storage to the data file and returns the size
+// of the file.
+func (p *PersistenceManager) writeSnapshot() (int64, error) {
 	tempPath := p.getFilePath() + ".tmp"
 	file, err := os.Create(tempPath)
 	if err != nil {
-		return fmt.Errorf("failed to create data file: %v", err)
+		return 0, fmt.Errorf("failed to create data file: %v", err)
 	}
 	defer file.Close()
 
 	keys := p.storage.Keys()
 
 	snapshot := StorageSnapshot{
+		Version:   snapshotVersion,
 		Timestamp: time.Now(),
-		KeyCount:  len(keys),
-		Entries:   make([]StorageEntry, len(keys)),
+		Entries:   make([]StorageEntry, 0, len(keys)),
 	}
 
 	for _, key := range keys {
@@ -95,26 +204,31 @@ func (p *PersistenceManager) Save() error {
 		var serializableData interface{}
 		switch value.Type {
 		case StringType:
-			serializableData = value.Data
+			serializableData = encodeSnapshotString(toString(value.Data))
 		case HashType:
 			if hash, ok := value.Data.(*HashData); ok {
-				serializableData = hash.Fields()
+				fields := hash.Fields()
+				encoded := make(map[string]string, len(fields))
+				for field, fieldValue := range fields {
+					encoded[encodeSnapshotString(field)] = encodeSnapshotString(fieldValue)
+				}
+				serializableData = encoded
 			} else {
-				fmt.Printf("Warning: invalid hash data for key %s\n", key)
+				p.logger.Warn("Skipping key with invalid data", "key", key, "type", "hash")
 				continue
 			}
 		case ListType:
 			if list, ok := value.Data.(*ListData); ok {
-				serializableData = list.GetAll()
+				serializableData = encodeSnapshotStrings(list.GetAll())
 			} else {
-				fmt.Printf("Warning: invalid list data for key %s\n", key)
+				p.logger.Warn("Skipping key with invalid data", "key", key, "type", "list")
 				continue
 			}
 		case SetType:
 			if set, ok := value.Data.(*SetData); ok {
-				serializableData = set.Members()
+				serializableData = encodeSnapshotStrings(set.Members())
 			} else {
-				fmt.Printf("Warning: invalid set data for key %s\n", key)
+				p.logger.Warn("Skipping key with invalid data", "key", key, "type", "set")
 				continue
 			}
 		default:
@@ -122,38 +236,39 @@ func (p *PersistenceManager) Save() error {
 		}
 
 		entry := StorageEntry{
-			Key:       key,
+			Key:       encodeSnapshotString(key),
 			Type:      value.Type,
 			Data:      serializableData,
 			ExpiredAt: value.ExpiredAt,
 		}
 		snapshot.Entries = append(snapshot.Entries, entry)
 	}
+	snapshot.KeyCount = len(snapshot.Entries)
 
 	data, err := json.Marshal(snapshot)
 	if err != nil {
-		return fmt.Errorf("failed to marshal snapshot: %v", err)
+		return 0, fmt.Errorf("failed to marshal snapshot: %v", err)
 	}
 
 	dataLen := uint32(len(data))
 	if err := binary.Write(file, binary.BigEndian, dataLen); err != nil {
-		return fmt.Errorf("failed to write data length: %v", err)
+		return 0, fmt.Errorf("failed to write data length: %v", err)
 	}
 
 	if _, err := file.Write(data); err != nil {
-		return fmt.Errorf("failed to write data: %v", err)
+		return 0, fmt.Errorf("failed to write data: %v", err)
 	}
 
 	if err := file.Sync(); err != nil {
-		return fmt.Errorf("failed to sync file: %v", err)
+		return 0, fmt.Errorf("failed to sync file: %v", err)
 	}
 
 	if err := os.Rename(tempPath, p.getFilePath()); err != nil {
-		return fmt.Errorf("failed to rename data file: %v", err)
+		return 0, fmt.Errorf("failed to rename data file: %v", err)
 	}
 
-	fmt.Printf("Saved %d keys to persistence file\n", len(keys))
-	return nil
+	logging.Verbose(p.logger, "Saved snapshot", "keys", snapshot.KeyCount, "path", p.getFilePath())
+	return int64(4 + len(data)), nil
 }
 
 func (p *PersistenceManager) Load() error {
@@ -170,7 +285,7 @@ func (p *PersistenceManager) Load() error {
 
 	path := p.getFilePath()
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		fmt.Println("No persistence file found, starting with empty storage")
+		p.logger.Info("No persistence file found, starting with empty storage", "path", path)
 		return nil
 	}
 
@@ -186,7 +301,7 @@ func (p *PersistenceManager) Load() error {
 	}
 
 	data := make([]byte, dataLen)
-	if _, err := file.Read(data); err != nil {
+	if _, err := io.ReadFull(file, data); err != nil {
 		return fmt.Errorf("failed to read data: %v", err)
 	}
 
@@ -195,61 +310,101 @@ func (p *PersistenceManager) Load() error {
 		return fmt.Errorf("failed to unmarshal snapshot: %v", err)
 	}
 
+	if snapshot.Version > snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d, expected at most %d", snapshot.Version, snapshotVersion)
+	}
+
+	decode := decodeSnapshotString
+	if snapshot.Version < snapshotVersion {
+		decode = func(s string) (string, error) { return s, nil }
+	}
+
 	p.storage.Clear()
 
 	for _, entry := range snapshot.Entries {
+		key, err := decode(entry.Key)
+		if err != nil {
+			p.logger.Warn("Skipping key with invalid encoding", "key", entry.Key, "error", err)
+			continue
+		}
+		entry.Key = key
+
 		var data interface{}
 		switch entry.Type {
 		case StringType:
 			if str, ok := entry.Data.(string); ok {
-				data = str
+				decoded, err := decode(str)
+				if err != nil {
+					p.logger.Warn("Skipping key with invalid encoding", "key", entry.Key, "type", "string", "error", err)
+					continue
+				}
+				data = decoded
 			} else {
-				fmt.Printf("Warning: invalid string data for key %s: %T\n", entry.Key, entry.Data)
+				p.logger.Warn("Skipping key with invalid data", "key", entry.Key, "type", "string", "data", fmt.Sprintf("%T", entry.Data))
 				continue
 			}
 		case HashType:
 			if fields, ok := entry.Data.(map[string]interface{}); ok {
 				hash := NewHashData()
 				for field, value := range fields {
-					if strValue, ok := value.(string); ok {
-						hash.Set(field, strValue)
-					} else {
-						fmt.Printf("Warning: invalid hash field value for key %s.%s: %T\n", entry.Key, field, value)
+					strValue, ok := value.(string)
+					if !ok {
+						p.logger.Warn("Skipping invalid hash field", "key", entry.Key, "field", field, "data", fmt.Sprintf("%T", value))
+						continue
+					}
+					decodedField, fieldErr := decode(field)
+					decodedValue, valueErr := decode(strValue)
+					if fieldErr != nil || valueErr != nil {
+						p.logger.Warn("Skipping hash field with invalid encoding", "key", entry.Key, "field", field)
+						continue
 					}
+					hash.Set(decodedField, decodedValue)
 				}
 				data = hash
 			} else {
-				fmt.Printf("Warning: invalid hash data for key %s: %T\n", entry.Key, entry.Data)
+				p.logger.Warn("Skipping key with invalid data", "key", entry.Key, "type", "hash", "data", fmt.Sprintf("%T", entry.Data))
 				continue
 			}
 		case ListType:
 			if elements, ok := entry.Data.([]interface{}); ok {
 				list := NewListData()
 				for _, element := range elements {
-					if strElement, ok := element.(string); ok {
-						list.PushRight(strElement)
-					} else {
-						fmt.Printf("Warning: invalid list element for key %s: %T\n", entry.Key, element)
+					strElement, ok := element.(string)
+					if !ok {
+						p.logger.Warn("Skipping invalid list element", "key", entry.Key, "data", fmt.Sprintf("%T", element))
+						continue
 					}
+					decoded, err := decode(strElement)
+					if err != nil {
+						p.logger.Warn("Skipping list element with invalid encoding", "key", entry.Key, "error", err)
+						continue
+					}
+					list.PushRight(decoded)
 				}
 				data = list
 			} else {
-				fmt.Printf("Warning: invalid list data for key %s: %T\n", entry.Key, entry.Data)
+				p.logger.Warn("Skipping key with invalid data", "key", entry.Key, "type", "list", "data", fmt.Sprintf("%T", entry.Data))
 				continue
 			}
 		case SetType:
 			if members, ok := entry.Data.([]interface{}); ok {
 				set := NewSetData()
 				for _, member := range members {
-					if strMember, ok := member.(string); ok {
-						set.Add(strMember)
-					} else {
-						fmt.Printf("Warning: invalid set member for key %s: %T\n", entry.Key, member)
+					strMember, ok := member.(string)
+					if !ok {
+						p.logger.Warn("Skipping invalid set member", "key", entry.Key, "data", fmt.Sprintf("%T", member))
+						continue
+					}
+					decoded, err := decode(strMember)
+					if err != nil {
+						p.logger.Warn("Skipping set member with invalid encoding", "key", entry.Key, "error", err)
+						continue
 					}
+					set.Add(decoded)
 				}
 				data = set
 			} else {
-				fmt.Printf("Warning: invalid set data for key %s: %T\n", entry.Key, entry.Data)
+				p.logger.Warn("Skipping key with invalid data", "key", entry.Key, "type", "set", "data", fmt.Sprintf("%T", entry.Data))
 				continue
 			}
 		default:
@@ -262,31 +417,88 @@ func (p *PersistenceManager) Load() error {
 		}
 
 		if err := p.storage.Set(entry.Key, storageValue); err != nil {
-			fmt.Printf("Warning: failed to restore key %s: %v\n", entry.Key, err)
+			p.logger.Warn("Failed to restore key", "key", entry.Key, "error", err)
 			continue
 		}
 	}
 
-	fmt.Printf("Loaded %d keys from persistence file\n", len(snapshot.Entries))
+	// The loaded keys are already on disk.
+	p.statsMu.Lock()
+	p.savedChanges = p.storageChanges()
+	p.statsMu.Unlock()
+
+	p.logger.Info("Loaded snapshot", "keys", len(snapshot.Entries), "path", path)
 	return nil
 }
 
+// Stats returns the snapshot statistics.
+func (p *PersistenceManager) Stats() PersistenceStats {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+
+	stats := p.stats
+	stats.ChangesSinceSave = p.storageChanges() - p.savedChanges
+	return stats
+}
+
+func (p *PersistenceManager) storageChanges() int64 {
+	if counter, ok := p.storage.(interface{ Changes() int64 }); ok {
+		return counter.Changes()
+	}
+	return 0
+}
+
+func encodeSnapshotString(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func encodeSnapshotStrings(values []string) []string {
+	encoded := make([]string, len(values))
+	for i, value := range values {
+		encoded[i] = encodeSnapshotString(value)
+	}
+	return encoded
+}
+
+func decodeSnapshotString(s string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
 func (p *PersistenceManager) getFilePath() string {
 	return filepath.Join(p.config.DataDir, p.config.Filename)
 }
 
+// startAutoSave starts saving every saveInterval; autoSaveMu must be held.
 func (p *PersistenceManager) startAutoSave() {
-	ticker := time.NewTicker(p.config.SaveInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			if err := p.Save(); err != nil {
-				fmt.Printf("Auto-save failed: %v\n", err)
+	stop := make(chan struct{})
+	p.stopAutoSave = stop
+
+	go func(interval time.Duration) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.Save(); err != nil {
+					p.logger.Warn("Auto-save failed", "error", err)
+				}
+			case <-stop:
+				return
 			}
-		case <-p.stopChan:
-			return
 		}
+	}(p.saveInterval)
+}
+
+// stopAutoSaveLocked stops the auto-save loop, if any; autoSaveMu must be
+// held.
+func (p *PersistenceManager) stopAutoSaveLocked() {
+	if p.stopAutoSave != nil {
+		close(p.stopAutoSave)
+		p.stopAutoSave = nil
 	}
 }
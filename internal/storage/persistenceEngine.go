@@ -2,7 +2,14 @@ package storage
 
 import "time"
 
+// snapshotVersion 1 stores keys, fields and values base64-encoded so that
+// arbitrary bytes survive the JSON encoding. Snapshots written before
+// versioning have no version field, read as 0, and hold them as plain
+// JSON strings. Snapshots newer than snapshotVersion are refused.
+const snapshotVersion = 1
+
 type StorageSnapshot struct {
+	Version   int            `json:"version,omitempty"`
 	Timestamp time.Time      `json:"timestamp"`
 	KeyCount  int            `json:"key_count"`
 	Entries   []StorageEntry `json:"entries"`
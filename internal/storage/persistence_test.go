@@ -1,8 +1,10 @@
 package storage
 
 import (
+	"encoding/binary"
 	"ivanSaichkin/myredis/internal/config"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -118,3 +120,190 @@ func TestPersistenceAutoSave(t *testing.T) {
 		t.Fatal("Persistence file was not created")
 	}
 }
+
+func TestBinaryPersistence(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "myredis_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	config := &config.PersistenceConfig{
+		Enabled:  true,
+		DataDir:  tempDir,
+		Filename: "test.bin",
+		AutoSave: false,
+	}
+
+	binaryKey := "key\x00\xff"
+	binaryValue := "value\r\n\x00\xfe\xff"
+
+	store := NewMemoryStorageWithPersistence(config)
+	store.Set(binaryKey, binaryValue)
+	store.Set("empty_key", "")
+	store.HSet("hash_key", "field\r\n", binaryValue)
+	store.RPush("list_key", binaryValue, "", "\xff")
+	store.SAdd("set_key", binaryValue, "")
+
+	if err := store.SaveSnapshot(); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	newStore := NewMemoryStorageWithPersistence(config)
+	if err := newStore.StartPersistence(); err != nil {
+		t.Fatalf("Failed to start persistence: %v", err)
+	}
+
+	if size := newStore.Size(); size != 5 {
+		t.Errorf("Expected 5 keys after restoration, got %d", size)
+	}
+
+	val, err := newStore.Get(binaryKey)
+	if err != nil {
+		t.Fatalf("Failed to get binary key: %v", err)
+	}
+	if val.Data != binaryValue {
+		t.Errorf("Binary value mismatch: expected %q, got %q", binaryValue, val.Data)
+	}
+
+	val, err = newStore.Get("empty_key")
+	if err != nil {
+		t.Fatalf("Failed to get empty key: %v", err)
+	}
+	if val.Data != "" {
+		t.Errorf("Empty value mismatch: got %q", val.Data)
+	}
+
+	hashVal, err := newStore.HGet("hash_key", "field\r\n")
+	if err != nil {
+		t.Fatalf("Failed to get hash field: %v", err)
+	}
+	if hashVal != binaryValue {
+		t.Errorf("Hash value mismatch: expected %q, got %q", binaryValue, hashVal)
+	}
+
+	elements, err := newStore.LRange("list_key", 0, -1)
+	if err != nil {
+		t.Fatalf("Failed to get list range: %v", err)
+	}
+	if len(elements) != 3 || elements[0] != binaryValue || elements[1] != "" || elements[2] != "\xff" {
+		t.Errorf("List mismatch: got %q", elements)
+	}
+
+	for _, member := range []string{binaryValue, ""} {
+		isMember, err := newStore.SIsMember("set_key", member)
+		if err != nil {
+			t.Fatalf("Failed to check set membership: %v", err)
+		}
+		if !isMember {
+			t.Errorf("Set member %q not found after restoration", member)
+		}
+	}
+}
+
+// writeSnapshotFile writes data as the snapshot file of config, prefixed
+// by its length.
+func writeSnapshotFile(t *testing.T, config *config.PersistenceConfig, data string) {
+	t.Helper()
+
+	file := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(file, uint32(len(data)))
+	if err := os.WriteFile(filepath.Join(config.DataDir, config.Filename), append(file, data...), 0644); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+}
+
+func TestPersistenceUnversioned(t *testing.T) {
+	config := &config.PersistenceConfig{
+		Enabled:  true,
+		DataDir:  t.TempDir(),
+		Filename: "test.bin",
+	}
+
+	// A snapshot written before versioning holds plain strings.
+	writeSnapshotFile(t, config, `{"timestamp":"2024-01-01T00:00:00Z","key_count":4,"entries":[`+
+		`{"key":"string_key","type":0,"data":"aGVsbG8="},`+
+		`{"key":"hash_key","type":1,"data":{"field":"value"}},`+
+		`{"key":"list_key","type":2,"data":["a","b"]},`+
+		`{"key":"set_key","type":3,"data":["x"]}]}`)
+
+	store := NewMemoryStorageWithPersistence(config)
+	if err := store.persistence.Load(); err != nil {
+		t.Fatalf("Failed to load an unversioned snapshot: %v", err)
+	}
+
+	if val, err := store.Get("string_key"); err != nil || val.Data != "aGVsbG8=" {
+		t.Errorf("Expected the string to be read verbatim, got %v (%v)", val, err)
+	}
+	if value, err := store.HGet("hash_key", "field"); err != nil || value != "value" {
+		t.Errorf("Expected the hash field to be loaded, got %q (%v)", value, err)
+	}
+	if elements, err := store.LRange("list_key", 0, -1); err != nil || len(elements) != 2 || elements[0] != "a" || elements[1] != "b" {
+		t.Errorf("Expected the list to be loaded, got %v (%v)", elements, err)
+	}
+	if ok, err := store.SIsMember("set_key", "x"); err != nil || !ok {
+		t.Errorf("Expected the set member to be loaded (%v)", err)
+	}
+}
+
+func TestPersistenceFutureVersion(t *testing.T) {
+	config := &config.PersistenceConfig{
+		Enabled:  true,
+		DataDir:  t.TempDir(),
+		Filename: "test.bin",
+	}
+
+	writeSnapshotFile(t, config, `{"version":2,"entries":[{"key":"a","type":0,"data":"b"}]}`)
+
+	store := NewMemoryStorageWithPersistence(config)
+	store.Set("kept", "value")
+	if err := store.persistence.Load(); err == nil {
+		t.Fatal("Expected a snapshot from a newer version to be refused")
+	}
+	if !store.Exists("kept") {
+		t.Error("Expected the storage to be left alone")
+	}
+}
+
+func TestPersistenceStats(t *testing.T) {
+	config := &config.PersistenceConfig{
+		Enabled:  true,
+		DataDir:  t.TempDir(),
+		Filename: "test.bin",
+	}
+	store := NewMemoryStorageWithPersistence(config)
+
+	store.Set("a", "1")
+	store.RPush("list", "x", "y")
+	store.Delete("missing")
+
+	stats, enabled := store.PersistenceStats()
+	if !enabled {
+		t.Fatal("Expected persistence stats to be available")
+	}
+	if stats.ChangesSinceSave != 3 || stats.Saves != 0 {
+		t.Errorf("Before saving: unexpected stats %+v", stats)
+	}
+
+	if err := store.SaveSnapshot(); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	stats, _ = store.PersistenceStats()
+	if stats.ChangesSinceSave != 0 || stats.Saves != 1 || !stats.LastSaveOK || stats.LastSaveSize == 0 {
+		t.Errorf("After saving: unexpected stats %+v", stats)
+	}
+
+	store.Set("b", "2")
+	config.DataDir = filepath.Join(t.TempDir(), "missing")
+	if err := store.SaveSnapshot(); err == nil {
+		t.Fatal("Expected the save to a missing directory to fail")
+	}
+	stats, _ = store.PersistenceStats()
+	if stats.LastSaveOK || stats.Failures != 1 || stats.ChangesSinceSave != 1 {
+		t.Errorf("After a failed save: unexpected stats %+v", stats)
+	}
+
+	if _, enabled := NewMemoryStorage().PersistenceStats(); enabled {
+		t.Error("Expected no persistence stats without persistence")
+	}
+}
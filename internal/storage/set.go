@@ -28,6 +28,7 @@ func (s *MemoryStorage) SAdd(key string, members ...string) (int, error) {
 			added++
 		}
 	}
+	s.changes.Add(int64(added))
 
 	return added, nil
 }
@@ -57,6 +58,7 @@ func (s *MemoryStorage) SRem(key string, members ...string) (int, error) {
 			removed++
 		}
 	}
+	s.changes.Add(int64(removed))
 
 	return removed, nil
 }